@@ -55,6 +55,50 @@ type ResourceGroupSpec struct {
 	// descriptor regroups the information and metadata about a resource group
 	// +optional
 	Descriptor Descriptor `json:"descriptor,omitempty"`
+
+	// readinessPolicy configures how the Ready condition of the group is computed
+	// from the statuses of its resources. By default, the group is Ready when
+	// all its resources are Current.
+	// +optional
+	ReadinessPolicy *ReadinessPolicy `json:"readinessPolicy,omitempty"`
+
+	// clusters lists the resources of the group in member clusters. It is only
+	// used when the controller runs in hub mode.
+	// +listType=map
+	// +listMapKey=cluster
+	// +optional
+	Clusters []ClusterResources `json:"clusters,omitempty"`
+}
+
+// ClusterResources lists the resources of a ResourceGroup in a member cluster.
+type ClusterResources struct {
+	// cluster is the name of the member cluster, which is the name of the
+	// Secret holding its kubeconfig.
+	Cluster string `json:"cluster"`
+
+	// resources contains the list of resources of the group in the member cluster.
+	// +optional
+	Resources []ObjMetadata `json:"resources,omitempty"`
+}
+
+// ReadinessPolicy configures when a ResourceGroup is Ready.
+type ReadinessPolicy struct {
+	// ignoredKinds lists the kinds of the resources which are not taken into
+	// account for the readiness of the group.
+	// +optional
+	IgnoredKinds []GroupKind `json:"ignoredKinds,omitempty"`
+
+	// maxInProgressPercent is the maximum percentage of the resources which can
+	// be InProgress or Terminating for the group to be Ready. Defaults to 0.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	MaxInProgressPercent int32 `json:"maxInProgressPercent,omitempty"`
+
+	// ignoreNotFound excludes the resources which are NotFound from the readiness
+	// of the group. By default, a NotFound resource makes the group not Ready.
+	// +optional
+	IgnoreNotFound bool `json:"ignoreNotFound,omitempty"`
 }
 
 // status defines the observed state of ResourceGroup
@@ -70,13 +114,64 @@ type ResourceGroupStatus struct {
 	ObservedGeneration int64 `json:"observedGeneration"`
 
 	// resourceStatuses lists the status for each resource in the group
+	// +listType=map
+	// +listMapKey=group
+	// +listMapKey=kind
+	// +listMapKey=namespace
+	// +listMapKey=name
 	ResourceStatuses []ResourceStatus `json:"resourceStatuses,omitempty"`
 
 	// subgroupStatuses lists the status for each subgroup.
+	// +listType=map
+	// +listMapKey=namespace
+	// +listMapKey=name
 	SubgroupStatuses []GroupStatus `json:"subgroupStatuses,omitempty"`
 
 	// conditions lists the conditions of the current status for the group
 	Conditions []Condition `json:"conditions,omitempty"`
+
+	// statusShards lists the ConfigMaps holding the resource statuses of the
+	// group when there are too many resources to store their statuses in the
+	// ResourceGroup object. When it is set, resourceStatuses only includes the
+	// status of each resource and the fields set by the applier.
+	StatusShards []StatusShard `json:"statusShards,omitempty"`
+
+	// orphans lists the live objects annotated with the inventory id of the
+	// group which are not included in the group, e.g. because they failed to
+	// be pruned. It is only set when the controller scans for orphans. At most
+	// 100 orphans are listed, and orphanCount is the total number of orphans.
+	Orphans []ObjMetadata `json:"orphans,omitempty"`
+
+	// orphanCount is the number of the orphans of the group, including the
+	// ones which are not listed in orphans.
+	// +optional
+	OrphanCount int `json:"orphanCount,omitempty"`
+
+	// driftCounts counts the drifted resources of the group by cause. It is
+	// only set when the group has a revision.
+	// +optional
+	DriftCounts *DriftCounts `json:"driftCounts,omitempty"`
+
+	// clusterStatuses lists the status of the resources in each member cluster.
+	// +listType=map
+	// +listMapKey=cluster
+	ClusterStatuses []ClusterStatus `json:"clusterStatuses,omitempty"`
+
+	// generationChangedTime is the time the controller first observed the
+	// generation in observedGeneration.
+	// +optional
+	GenerationChangedTime *metav1.Time `json:"generationChangedTime,omitempty"`
+
+	// readyGeneration is the latest generation for which the resources of the
+	// group became ready, according to the Ready condition.
+	// +optional
+	ReadyGeneration int64 `json:"readyGeneration,omitempty"`
+
+	// lastReadyTime is the time the resources of the group became ready for
+	// readyGeneration. Its difference with generationChangedTime is the time
+	// taken to roll out this generation.
+	// +optional
+	LastReadyTime *metav1.Time `json:"lastReadyTime,omitempty"`
 }
 
 // each item organizes and stores the identifying information
@@ -157,6 +252,28 @@ type ConditionType string
 const (
 	Reconciling ConditionType = "Reconciling"
 	Stalled     ConditionType = "Stalled"
+	// Ready reflects whether the resources of the group are ready according
+	// to the readiness policy of the group.
+	Ready ConditionType = "Ready"
+	// Blocked reflects whether a resource depends on resources which are not
+	// Current yet. On the group, it reflects whether any of its resources is Blocked.
+	Blocked ConditionType = "Blocked"
+	// Drifted reflects whether any resource of the group was applied from
+	// another revision than the revision of the group, or modified out of band.
+	Drifted ConditionType = "Drifted"
+	// ReadFailed reflects whether the controller failed to read a resource
+	// from the API server, e.g. because it is not allowed to, so that the
+	// status of the resource is Unknown. On the group, it reflects whether
+	// the controller failed to read any of its resources.
+	ReadFailed ConditionType = "ReadFailed"
+	// WatchForbidden reflects whether the controller is not allowed to watch
+	// the kinds of some resources of the group, so that their statuses are
+	// not updated when they change.
+	WatchForbidden ConditionType = "WatchForbidden"
+	// OutsideRole reflects whether the group includes resources of kinds
+	// which the least-privilege ClusterRole of the controller does not grant
+	// access to. It is only set when such a ClusterRole is configured.
+	OutsideRole ConditionType = "OutsideRole"
 	// Ownership reflects if the current resource
 	// reflects the status for the specification in the current inventory object.
 	// Since two ResourceGroup CRs may contain the same resource in the inventory list.
@@ -200,6 +317,50 @@ type GroupStatus struct {
 	Conditions    []Condition `json:"conditions,omitempty"`
 }
 
+// ClusterStatus contains the status of the resources of a group in a member cluster.
+type ClusterStatus struct {
+	// cluster is the name of the member cluster.
+	Cluster string `json:"cluster"`
+
+	// resourceStatuses lists the status for each resource in the member cluster.
+	ResourceStatuses []ResourceStatus `json:"resourceStatuses,omitempty"`
+
+	// conditions lists the conditions of the member cluster, e.g. a Stalled
+	// condition when the cluster cannot be reached.
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// DriftCounts counts the resources of a group which drifted from the revision
+// of the group, by cause.
+type DriftCounts struct {
+	// otherRevision is the number of resources applied from another revision
+	// than the revision of the group.
+	// +optional
+	OtherRevision int `json:"otherRevision,omitempty"`
+
+	// sourceHashRemoved is the number of resources whose source hash
+	// annotation was removed, e.g. by an out-of-band edit.
+	// +optional
+	SourceHashRemoved int `json:"sourceHashRemoved,omitempty"`
+}
+
+// Drifted returns the number of drifted resources, or 0 if c is nil.
+func (c *DriftCounts) Drifted() int {
+	if c == nil {
+		return 0
+	}
+	return c.OtherRevision + c.SourceHashRemoved
+}
+
+// StatusShard references a ConfigMap in the namespace of the ResourceGroup
+// which holds a part of the resource statuses of the group.
+type StatusShard struct {
+	// name is the name of the ConfigMap.
+	Name string `json:"name"`
+	// count is the number of resource statuses in the ConfigMap.
+	Count int `json:"count"`
+}
+
 // status describes the status of a resource.
 type Status string
 
@@ -23,6 +23,55 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterResources) DeepCopyInto(out *ClusterResources) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]ObjMetadata, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterResources.
+func (in *ClusterResources) DeepCopy() *ClusterResources {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterResources)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterStatus) DeepCopyInto(out *ClusterStatus) {
+	*out = *in
+	if in.ResourceStatuses != nil {
+		in, out := &in.ResourceStatuses, &out.ResourceStatuses
+		*out = make([]ResourceStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterStatus.
+func (in *ClusterStatus) DeepCopy() *ClusterStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Condition) DeepCopyInto(out *Condition) {
 	*out = *in
@@ -59,6 +108,21 @@ func (in *Descriptor) DeepCopy() *Descriptor {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DriftCounts) DeepCopyInto(out *DriftCounts) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DriftCounts.
+func (in *DriftCounts) DeepCopy() *DriftCounts {
+	if in == nil {
+		return nil
+	}
+	out := new(DriftCounts)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GroupKind) DeepCopyInto(out *GroupKind) {
 	*out = *in
@@ -143,6 +207,26 @@ func (in *ObjMetadata) DeepCopy() *ObjMetadata {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReadinessPolicy) DeepCopyInto(out *ReadinessPolicy) {
+	*out = *in
+	if in.IgnoredKinds != nil {
+		in, out := &in.IgnoredKinds, &out.IgnoredKinds
+		*out = make([]GroupKind, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReadinessPolicy.
+func (in *ReadinessPolicy) DeepCopy() *ReadinessPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ReadinessPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceGroup) DeepCopyInto(out *ResourceGroup) {
 	*out = *in
@@ -216,6 +300,18 @@ func (in *ResourceGroupSpec) DeepCopyInto(out *ResourceGroupSpec) {
 		copy(*out, *in)
 	}
 	in.Descriptor.DeepCopyInto(&out.Descriptor)
+	if in.ReadinessPolicy != nil {
+		in, out := &in.ReadinessPolicy, &out.ReadinessPolicy
+		*out = new(ReadinessPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]ClusterResources, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceGroupSpec.
@@ -252,6 +348,36 @@ func (in *ResourceGroupStatus) DeepCopyInto(out *ResourceGroupStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.StatusShards != nil {
+		in, out := &in.StatusShards, &out.StatusShards
+		*out = make([]StatusShard, len(*in))
+		copy(*out, *in)
+	}
+	if in.Orphans != nil {
+		in, out := &in.Orphans, &out.Orphans
+		*out = make([]ObjMetadata, len(*in))
+		copy(*out, *in)
+	}
+	if in.DriftCounts != nil {
+		in, out := &in.DriftCounts, &out.DriftCounts
+		*out = new(DriftCounts)
+		**out = **in
+	}
+	if in.ClusterStatuses != nil {
+		in, out := &in.ClusterStatuses, &out.ClusterStatuses
+		*out = make([]ClusterStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.GenerationChangedTime != nil {
+		in, out := &in.GenerationChangedTime, &out.GenerationChangedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastReadyTime != nil {
+		in, out := &in.LastReadyTime, &out.LastReadyTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceGroupStatus.
@@ -286,3 +412,18 @@ func (in *ResourceStatus) DeepCopy() *ResourceStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StatusShard) DeepCopyInto(out *StatusShard) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StatusShard.
+func (in *StatusShard) DeepCopy() *StatusShard {
+	if in == nil {
+		return nil
+	}
+	out := new(StatusShard)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,135 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command rgctl inspects and waits for ResourceGroups, and generates the
+// least-privilege ClusterRole of the controller.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+)
+
+// command is a subcommand of rgctl.
+type command struct {
+	name  string
+	usage string
+	run   func(args []string, stdout io.Writer) error
+}
+
+var commands = []command{
+	{
+		name:  "wait",
+		usage: "wait [--for=ready] [--timeout=DURATION] [--namespace=NAMESPACE] NAME",
+		run:   runWait,
+	},
+	{
+		name:  "role",
+		usage: "role [--name=NAME] [--namespace=NAMESPACE]",
+		run:   runRole,
+	},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs the subcommand in args and returns the exit code.
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		printUsage(stderr)
+		return 2
+	}
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		if err := cmd.run(args[1:], stdout); err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	fmt.Fprintf(stderr, "unknown command %q\n", args[0])
+	printUsage(stderr)
+	return 2
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  rgctl %s\n", cmd.usage)
+	}
+}
+
+// clientFlags are the flags to connect to the cluster shared by the subcommands.
+type clientFlags struct {
+	kubeconfig string
+	context    string
+	namespace  string
+}
+
+// register registers the flags into fs.
+func (f *clientFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.kubeconfig, "kubeconfig", "", "Path to the kubeconfig file. Defaults to $KUBECONFIG or ~/.kube/config.")
+	fs.StringVar(&f.context, "context", "", "The kubeconfig context to use. Defaults to the current context.")
+	fs.StringVar(&f.namespace, "namespace", "", "The namespace of the ResourceGroup. Defaults to the namespace of the kubeconfig context.")
+}
+
+// restConfig returns the config of the cluster, and the namespace to use.
+func (f *clientFlags) restConfig() (*rest.Config, string, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = f.kubeconfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: f.context}
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
+
+	cfg, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load the kubeconfig: %w", err)
+	}
+	namespace := f.namespace
+	if namespace == "" {
+		namespace, _, err = clientConfig.Namespace()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get the namespace: %w", err)
+		}
+	}
+	return cfg, namespace, nil
+}
+
+// newClient returns a client for the cluster, and the namespace to use.
+func (f *clientFlags) newClient() (client.WithWatch, string, error) {
+	cfg, namespace, err := f.restConfig()
+	if err != nil {
+		return nil, "", err
+	}
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = v1alpha1.AddToScheme(scheme)
+	c, err := client.NewWithWatch(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create the client: %w", err)
+	}
+	return c, namespace, nil
+}
@@ -0,0 +1,144 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/go-logr/logr"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/controllers/rbac"
+	"kpt.dev/resourcegroup/controllers/resourcemap"
+	"kpt.dev/resourcegroup/controllers/typeresolver"
+)
+
+// defaultRoleName is the default name of the generated ClusterRole.
+const defaultRoleName = "resource-group-members"
+
+// roleHelp describes the role subcommand.
+const roleHelp = `Print the least-privilege ClusterRole which grants the ResourceGroup controller
+access to the kinds of the resources of the ResourceGroups in the cluster.
+
+The ClusterRole only covers the resources in the cluster itself: the resources
+of member clusters (spec.clusters) are left out, since the controller watches
+them with the credentials of the kubeconfig of each member cluster.
+`
+
+func runRole(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("role", flag.ContinueOnError)
+	var cf clientFlags
+	cf.register(fs)
+	fs.Lookup("namespace").Usage = "Only include the ResourceGroups of this namespace. Defaults to all the namespaces, " +
+		"since the controller watches the resources of all the ResourceGroups."
+	name := fs.String("name", defaultRoleName, "The name of the generated ClusterRole.")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), roleHelp+"\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("expected no arguments, got %d", fs.NArg())
+	}
+
+	cfg, _, err := cf.restConfig()
+	if err != nil {
+		return err
+	}
+	c, _, err := cf.newClient()
+	if err != nil {
+		return err
+	}
+	resolver, err := typeresolver.NewTypeResolverForConfig(cfg, logr.Discard())
+	if err != nil {
+		return fmt.Errorf("failed to create the type resolver: %w", err)
+	}
+
+	ctx := context.Background()
+	opts := []client.ListOption{}
+	if cf.namespace != "" {
+		opts = append(opts, client.InNamespace(cf.namespace))
+	}
+	groups := &v1alpha1.ResourceGroupList{}
+	if err := c.List(ctx, groups, opts...); err != nil {
+		return fmt.Errorf("failed to list the ResourceGroups: %w", err)
+	}
+
+	role, unresolved := clusterRoleFor(ctx, *name, groups.Items, resolver)
+	out, err := yaml.Marshal(role)
+	if err != nil {
+		return err
+	}
+	if n := countWithMemberClusters(groups.Items); n > 0 {
+		fmt.Fprintf(stdout, "# The resources of the member clusters of %d ResourceGroups are not included\n", n)
+	}
+	for _, gk := range unresolved {
+		// The kinds which are not served yet, e.g. whose CRD is not applied,
+		// cannot be mapped to their resource.
+		fmt.Fprintf(stdout, "# %s is not served by the API server and is not included\n", gk)
+	}
+	_, err = stdout.Write(out)
+	return err
+}
+
+// countWithMemberClusters returns the number of groups which include
+// resources of member clusters.
+func countWithMemberClusters(groups []v1alpha1.ResourceGroup) int {
+	var n int
+	for _, group := range groups {
+		if len(group.Spec.Clusters) > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// clusterRoleFor returns the ClusterRole which grants the controller access to
+// exactly the kinds of the resources of the given groups, and the kinds which
+// the resolver cannot map to their resource. The kinds are collected the same
+// way as the root controller collects the kinds to watch, so the kinds of the
+// resources of member clusters are not included.
+func clusterRoleFor(ctx context.Context, name string, groups []v1alpha1.ResourceGroup, resolver *typeresolver.TypeResolver) (*rbacv1.ClusterRole, []schema.GroupKind) {
+	resMap := resourcemap.NewResourceMap()
+	var gks []schema.GroupKind
+	for _, group := range groups {
+		resources := append(append([]v1alpha1.ObjMetadata(nil), group.Spec.Resources...), v1alpha1.ToObjMetadata(group.Spec.Subgroups)...)
+		gks = resMap.Reconcile(ctx, types.NamespacedName{Namespace: group.Namespace, Name: group.Name}, resources, false)
+	}
+	sort.Slice(gks, func(i, j int) bool { return gks[i].String() < gks[j].String() })
+
+	var resources []schema.GroupResource
+	var unresolved []schema.GroupKind
+	for _, gk := range gks {
+		gvr, found := resolver.ResolveResource(gk)
+		if !found {
+			unresolved = append(unresolved, gk)
+			continue
+		}
+		resources = append(resources, gvr.GroupResource())
+	}
+	return rbac.NewClusterRole(name, resources), unresolved
+}
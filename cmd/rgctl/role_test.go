@@ -0,0 +1,86 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/controllers/typeresolver"
+)
+
+func TestClusterRoleFor(t *testing.T) {
+	deploymentGK := schema.GroupKind{Group: "apps", Kind: "Deployment"}
+	configMapGK := schema.GroupKind{Kind: "ConfigMap"}
+	widgetGK := schema.GroupKind{Group: "example.com", Kind: "Widget"}
+	resolver := typeresolver.NewFakeTypeResolver(map[schema.GroupKind]schema.GroupVersionKind{
+		deploymentGK: deploymentGK.WithVersion("v1"),
+		configMapGK:  configMapGK.WithVersion("v1"),
+		{Group: "kpt.dev", Kind: "ResourceGroup"}: {Group: "kpt.dev", Version: "v1alpha1", Kind: "ResourceGroup"},
+	})
+	groups := []v1alpha1.ResourceGroup{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "group1"},
+			Spec: v1alpha1.ResourceGroupSpec{
+				Resources: []v1alpha1.ObjMetadata{
+					{Namespace: "ns1", Name: "app", GroupKind: v1alpha1.GroupKind(deploymentGK)},
+					{Namespace: "ns1", Name: "cm", GroupKind: v1alpha1.GroupKind(configMapGK)},
+				},
+				Subgroups: []v1alpha1.GroupMetadata{{Namespace: "ns1", Name: "group2"}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "group2"},
+			Spec: v1alpha1.ResourceGroupSpec{
+				Resources: []v1alpha1.ObjMetadata{
+					{Namespace: "ns1", Name: "widget", GroupKind: v1alpha1.GroupKind(widgetGK)},
+					{Namespace: "ns1", Name: "cm2", GroupKind: v1alpha1.GroupKind(configMapGK)},
+				},
+				// The resources of the member clusters are watched with
+				// the kubeconfig of each member cluster.
+				Clusters: []v1alpha1.ClusterResources{{
+					Cluster: "member",
+					Resources: []v1alpha1.ObjMetadata{
+						{Namespace: "ns1", Name: "secret", GroupKind: v1alpha1.GroupKind{Kind: "Secret"}},
+					},
+				}},
+			},
+		},
+	}
+
+	role, unresolved := clusterRoleFor(context.TODO(), "members", groups, resolver)
+	assert.Equal(t, "members", role.Name)
+	verbs := []string{"get", "list", "watch"}
+	assert.Equal(t, []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: verbs},
+		{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: verbs},
+		{APIGroups: []string{"kpt.dev"}, Resources: []string{"resourcegroups"}, Verbs: verbs},
+	}, role.Rules)
+	assert.Equal(t, []schema.GroupKind{widgetGK}, unresolved)
+	assert.Equal(t, 1, countWithMemberClusters(groups))
+}
+
+func TestRunRoleInvalidArgs(t *testing.T) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	assert.Equal(t, 1, run([]string{"role", "extra"}, stdout, stderr))
+	assert.Contains(t, stderr.String(), "expected no arguments")
+}
@@ -0,0 +1,99 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/pkg/wait"
+)
+
+// forReady is the value of --for waiting until the ResourceGroup is ready.
+const forReady = "ready"
+
+func runWait(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("wait", flag.ContinueOnError)
+	var cf clientFlags
+	cf.register(fs)
+	forCondition := fs.String("for", forReady, "The condition to wait for. Only \"ready\" is supported.")
+	timeout := fs.Duration("timeout", 5*time.Minute, "The maximum duration to wait. 0 waits forever.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *forCondition != forReady {
+		return fmt.Errorf("unsupported condition %q, only %q is supported", *forCondition, forReady)
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("expected exactly one ResourceGroup name, got %d", fs.NArg())
+	}
+
+	c, namespace, err := cf.newClient()
+	if err != nil {
+		return err
+	}
+	key := types.NamespacedName{Namespace: namespace, Name: fs.Arg(0)}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	p := &progressPrinter{out: stdout}
+	if _, err := wait.ForReady(ctx, c, key, wait.Options{Timeout: *timeout, OnProgress: p.print}); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "ResourceGroup %s is ready\n", key)
+	return nil
+}
+
+// progressPrinter prints the changes of the resource statuses of a ResourceGroup.
+type progressPrinter struct {
+	out  io.Writer
+	last map[v1alpha1.ObjMetadata]v1alpha1.Status
+}
+
+// print prints the resources whose status changed since the last call,
+// followed by a summary if any status changed.
+func (p *progressPrinter) print(_ *v1alpha1.ResourceGroup, statuses []v1alpha1.ResourceStatus) {
+	current := make(map[v1alpha1.ObjMetadata]v1alpha1.Status, len(statuses))
+	changed := p.last == nil
+	ready := 0
+	for _, status := range statuses {
+		current[status.ObjMetadata] = status.Status
+		if status.Status == v1alpha1.Current {
+			ready++
+		}
+		if old, found := p.last[status.ObjMetadata]; p.last != nil && (!found || old != status.Status) {
+			res := status.ObjMetadata
+			fmt.Fprintf(p.out, "%s/%s/%s/%s: %s\n", res.Group, res.Kind, res.Namespace, res.Name, status.Status)
+			changed = true
+		}
+	}
+	if len(current) != len(p.last) {
+		changed = true
+	}
+	p.last = current
+	if changed {
+		fmt.Fprintf(p.out, "%d/%d resources are Current\n", ready, len(statuses))
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+)
+
+func TestProgressPrinter(t *testing.T) {
+	res1 := v1alpha1.ObjMetadata{Name: "cm1", Namespace: "ns1", GroupKind: v1alpha1.GroupKind{Kind: "ConfigMap"}}
+	res2 := v1alpha1.ObjMetadata{Name: "app", Namespace: "ns1", GroupKind: v1alpha1.GroupKind{Group: "apps", Kind: "Deployment"}}
+	out := &bytes.Buffer{}
+	p := &progressPrinter{out: out}
+
+	p.print(nil, []v1alpha1.ResourceStatus{
+		{ObjMetadata: res1, Status: v1alpha1.Current},
+		{ObjMetadata: res2, Status: v1alpha1.InProgress},
+	})
+	assert.Equal(t, "1/2 resources are Current\n", out.String())
+
+	// Nothing is printed if no status changed.
+	out.Reset()
+	p.print(nil, []v1alpha1.ResourceStatus{
+		{ObjMetadata: res1, Status: v1alpha1.Current},
+		{ObjMetadata: res2, Status: v1alpha1.InProgress},
+	})
+	assert.Empty(t, out.String())
+
+	out.Reset()
+	p.print(nil, []v1alpha1.ResourceStatus{
+		{ObjMetadata: res1, Status: v1alpha1.Current},
+		{ObjMetadata: res2, Status: v1alpha1.Current},
+	})
+	assert.Equal(t, "apps/Deployment/ns1/app: Current\n2/2 resources are Current\n", out.String())
+}
+
+func TestRunUnknownCommand(t *testing.T) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	assert.Equal(t, 2, run([]string{"unknown"}, stdout, stderr))
+	assert.Contains(t, stderr.String(), "rgctl wait")
+	assert.Equal(t, 2, run(nil, stdout, stderr))
+}
+
+func TestRunWaitInvalidFlags(t *testing.T) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	assert.Equal(t, 1, run([]string{"wait", "--for=deleted", "group"}, stdout, stderr))
+	assert.Contains(t, stderr.String(), "unsupported condition")
+	assert.Equal(t, 1, run([]string{"wait"}, stdout, stderr))
+}
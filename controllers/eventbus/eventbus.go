@@ -0,0 +1,191 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/controllers/metrics"
+)
+
+// DefaultCapacity is the default number of distinct ResourceGroups
+// that can be pending delivery in a Bus.
+const DefaultCapacity = 1024
+
+// Bus carries the events for ResourceGroup objects from the watchers,
+// the CRD event handler and the Root controller to the ResourceGroup controller.
+//
+// Publish never blocks. The events are deduplicated by the NamespacedName of
+// the ResourceGroup while they are pending, and the events for new
+// ResourceGroups are dropped when capacity ResourceGroups are already pending.
+//
+// Bus implements source.Source so that the ResourceGroup controller can watch it.
+type Bus struct {
+	capacity int
+
+	// notify wakes up the delivery loop after an event is published.
+	notify chan struct{}
+	// done is closed when the delivery loop returns.
+	done chan struct{}
+
+	// The following fields are guarded by the mutex.
+	mux sync.Mutex
+	// pending is the FIFO queue of ResourceGroups waiting to be delivered.
+	pending []types.NamespacedName
+	// queued includes the ResourceGroups in pending.
+	queued map[types.NamespacedName]struct{}
+	// started indicates whether the delivery loop was started.
+	started bool
+}
+
+// Bus implements the source.Source interface.
+var _ source.Source = &Bus{}
+
+// New creates a Bus which holds at most capacity pending ResourceGroups.
+// A capacity less than 1 means DefaultCapacity.
+func New(capacity int) *Bus {
+	if capacity < 1 {
+		capacity = DefaultCapacity
+	}
+	return &Bus{
+		capacity: capacity,
+		notify:   make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		queued:   make(map[types.NamespacedName]struct{}),
+	}
+}
+
+// Publish enqueues an event for the given ResourceGroup without blocking.
+// It returns false if the event was dropped because the Bus is full.
+// Publishing an event for a ResourceGroup which is already pending is a no-op.
+func (b *Bus) Publish(ctx context.Context, group types.NamespacedName) bool {
+	b.mux.Lock()
+	if _, found := b.queued[group]; found {
+		b.mux.Unlock()
+		return true
+	}
+	if len(b.pending) >= b.capacity {
+		b.mux.Unlock()
+		klog.Warningf("dropping the event for ResourceGroup %v since %d ResourceGroups are pending", group, b.capacity)
+		metrics.RecordEventDropped(ctx)
+		return false
+	}
+	b.pending = append(b.pending, group)
+	b.queued[group] = struct{}{}
+	depth := len(b.pending)
+	b.mux.Unlock()
+
+	metrics.RecordEventQueueDepth(ctx, int64(depth))
+	select {
+	case b.notify <- struct{}{}:
+	default:
+		// The delivery loop has already been notified.
+	}
+	return true
+}
+
+// Len returns the number of pending ResourceGroups.
+func (b *Bus) Len() int {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return len(b.pending)
+}
+
+// Pending returns the pending ResourceGroups in the order they will be delivered.
+func (b *Bus) Pending() []types.NamespacedName {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	result := make([]types.NamespacedName, len(b.pending))
+	copy(result, b.pending)
+	return result
+}
+
+// Start implements source.Source. It delivers the pending events to the
+// handler until ctx is done. Start can be called only once.
+func (b *Bus) Start(ctx context.Context, h handler.EventHandler, q workqueue.RateLimitingInterface, prct ...predicate.Predicate) error {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	if b.started {
+		return fmt.Errorf("the event bus has already been started")
+	}
+	b.started = true
+	go b.deliver(ctx, h, q, prct)
+	return nil
+}
+
+// deliver hands the pending events to the handler one at a time until ctx is done.
+func (b *Bus) deliver(ctx context.Context, h handler.EventHandler, q workqueue.RateLimitingInterface, prct []predicate.Predicate) {
+	defer close(b.done)
+	for {
+		group, found := b.next(ctx)
+		if !found {
+			select {
+			case <-ctx.Done():
+				return
+			case <-b.notify:
+				continue
+			}
+		}
+
+		resgroup := &v1alpha1.ResourceGroup{}
+		resgroup.SetNamespace(group.Namespace)
+		resgroup.SetName(group.Name)
+		evt := event.GenericEvent{Object: resgroup}
+		shouldHandle := true
+		for _, p := range prct {
+			if !p.Generic(evt) {
+				shouldHandle = false
+				break
+			}
+		}
+		if shouldHandle {
+			h.Generic(evt, q)
+		}
+	}
+}
+
+// next removes the first pending ResourceGroup from the queue and returns it.
+// It returns false if there is no pending ResourceGroup.
+func (b *Bus) next(ctx context.Context) (types.NamespacedName, bool) {
+	b.mux.Lock()
+	if len(b.pending) == 0 {
+		b.mux.Unlock()
+		return types.NamespacedName{}, false
+	}
+	group := b.pending[0]
+	b.pending[0] = types.NamespacedName{}
+	b.pending = b.pending[1:]
+	delete(b.queued, group)
+	depth := len(b.pending)
+	b.mux.Unlock()
+
+	metrics.RecordEventQueueDepth(ctx, int64(depth))
+	return group, true
+}
+
+func (b *Bus) String() string {
+	return fmt.Sprintf("event bus (capacity %d)", b.capacity)
+}
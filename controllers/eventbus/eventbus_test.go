@@ -0,0 +1,96 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eventbus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/goleak"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+var (
+	group1 = types.NamespacedName{Namespace: "ns", Name: "group1"}
+	group2 = types.NamespacedName{Namespace: "ns", Name: "group2"}
+	group3 = types.NamespacedName{Namespace: "ns", Name: "group3"}
+)
+
+func TestPublishDeduplicates(t *testing.T) {
+	b := New(0)
+	assert.True(t, b.Publish(context.Background(), group1))
+	assert.True(t, b.Publish(context.Background(), group2))
+	assert.True(t, b.Publish(context.Background(), group1))
+	assert.Equal(t, []types.NamespacedName{group1, group2}, b.Pending())
+}
+
+func TestPublishDropsWhenFull(t *testing.T) {
+	b := New(2)
+	assert.True(t, b.Publish(context.Background(), group1))
+	assert.True(t, b.Publish(context.Background(), group2))
+	// A new ResourceGroup is dropped, a pending one is still accepted.
+	assert.False(t, b.Publish(context.Background(), group3))
+	assert.True(t, b.Publish(context.Background(), group2))
+	assert.Equal(t, 2, b.Len())
+}
+
+func TestStartDeliversEvents(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	delivered := make(chan types.NamespacedName, 3)
+	h := handler.Funcs{
+		GenericFunc: func(e event.GenericEvent, _ workqueue.RateLimitingInterface) {
+			delivered <- types.NamespacedName{Namespace: e.Object.GetNamespace(), Name: e.Object.GetName()}
+		},
+	}
+	skipGroup2 := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetName() != group2.Name
+	})
+
+	b := New(0)
+	b.Publish(ctx, group1)
+	assert.NoError(t, b.Start(ctx, h, nil, skipGroup2))
+	assert.Error(t, b.Start(ctx, h, nil))
+	b.Publish(ctx, group2)
+	b.Publish(ctx, group3)
+
+	var got []types.NamespacedName
+	for len(got) < 2 {
+		select {
+		case nn := <-delivered:
+			got = append(got, nn)
+		case <-time.After(10 * time.Second):
+			t.Fatalf("timed out waiting for events, got %v", got)
+		}
+	}
+	assert.Equal(t, []types.NamespacedName{group1, group3}, got)
+	assert.Equal(t, 0, b.Len())
+	cancel()
+	select {
+	case <-b.done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the delivery loop to return")
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/go-logr/logr"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// accessResetter forgets the kinds which the controller was not allowed to watch.
+type accessResetter interface {
+	// ResetForbidden forgets the kinds which the controller was not allowed
+	// to watch, and returns them.
+	ResetForbidden() []schema.GroupKind
+}
+
+// groupMapping is a resourceMap which also lists the ResourceGroup CRs.
+type groupMapping interface {
+	resourceMap
+	// ResourceGroups returns the identifiers of all the ResourceGroup CRs.
+	ResourceGroups() []types.NamespacedName
+}
+
+// AccessEventHandler enqueues the ResourceGroup CRs including resources of
+// the kinds which the controller was not allowed to watch when an RBAC object
+// granting access to the controller changes, so that the access to these
+// kinds is checked again. All the ResourceGroup CRs are enqueued when the
+// least-privilege ClusterRole changes.
+//
+// When ServiceAccount is set, only the bindings with the ServiceAccount as a
+// subject, and the roles which these bindings reference, are considered. The
+// other RBAC objects do not change the access of the controller.
+type AccessEventHandler struct {
+	Watches accessResetter
+	Mapping groupMapping
+	// Role is the name of the least-privilege ClusterRole, or empty.
+	Role string
+	// ServiceAccount is the ServiceAccount of the controller, or empty to
+	// consider all the RBAC objects.
+	ServiceAccount types.NamespacedName
+	Log            logr.Logger
+
+	mux sync.Mutex
+	// boundRoles maps the bindings of ServiceAccount to the roles they reference.
+	boundRoles map[rbacKey]rbacKey
+}
+
+var _ handler.EventHandler = &AccessEventHandler{}
+
+// rbacKey identifies an RBAC object. The namespace of the cluster-scoped
+// objects is empty.
+type rbacKey struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// Create implements EventHandler
+func (h *AccessEventHandler) Create(e event.CreateEvent, q workqueue.RateLimitingInterface) {
+	h.Log.V(5).Info("received a create event")
+	h.enqueue(e.Object, false, q)
+}
+
+// Update implements EventHandler
+func (h *AccessEventHandler) Update(e event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	h.Log.V(5).Info("received an update event")
+	h.enqueue(e.ObjectNew, false, q)
+}
+
+// Delete implements EventHandler
+func (h *AccessEventHandler) Delete(e event.DeleteEvent, q workqueue.RateLimitingInterface) {
+	h.Log.V(5).Info("received a delete event")
+	h.enqueue(e.Object, true, q)
+}
+
+// Generic implements EventHandler
+func (h *AccessEventHandler) Generic(e event.GenericEvent, q workqueue.RateLimitingInterface) {
+	h.Log.V(5).Info("received a generic event")
+	h.enqueue(e.Object, false, q)
+}
+
+func (h *AccessEventHandler) enqueue(obj client.Object, deleted bool, q workqueue.RateLimitingInterface) {
+	groups := make(map[types.NamespacedName]bool)
+	if _, isClusterRole := obj.(*rbacv1.ClusterRole); isClusterRole && h.Role != "" && obj.GetName() == h.Role {
+		h.Log.Info("the least-privilege ClusterRole changed, checking the kinds of all the groups", "clusterrole", h.Role)
+		for _, r := range h.Mapping.ResourceGroups() {
+			groups[r] = true
+		}
+	}
+	if h.grantsAccess(obj, deleted) {
+		for _, gk := range h.Watches.ResetForbidden() {
+			h.Log.Info("the RBAC rules changed, checking the access to the forbidden kind again", "kind", gk)
+			for _, gknn := range h.Mapping.GetResources(gk) {
+				for _, r := range h.Mapping.Get(gknn) {
+					groups[r] = true
+				}
+			}
+		}
+	}
+	for r := range groups {
+		h.Log.V(5).Info("enqueue a request for", "resourcegroup", r)
+		q.Add(reconcile.Request{NamespacedName: r})
+	}
+}
+
+// grantsAccess checks whether the change of obj may grant access to the
+// ServiceAccount of the controller. The deletion of an RBAC object never does.
+// It keeps track of the roles bound to the ServiceAccount.
+func (h *AccessEventHandler) grantsAccess(obj client.Object, deleted bool) bool {
+	if h.ServiceAccount.Name == "" {
+		return !deleted
+	}
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	if h.boundRoles == nil {
+		h.boundRoles = make(map[rbacKey]rbacKey)
+	}
+
+	var binding rbacKey
+	var roleRef rbacv1.RoleRef
+	var subjects []rbacv1.Subject
+	switch o := obj.(type) {
+	case *rbacv1.ClusterRole:
+		return !deleted && h.isBound(rbacKey{Kind: "ClusterRole", Name: o.Name})
+	case *rbacv1.Role:
+		return !deleted && h.isBound(rbacKey{Kind: "Role", Namespace: o.Namespace, Name: o.Name})
+	case *rbacv1.ClusterRoleBinding:
+		binding = rbacKey{Kind: "ClusterRoleBinding", Name: o.Name}
+		roleRef, subjects = o.RoleRef, o.Subjects
+	case *rbacv1.RoleBinding:
+		binding = rbacKey{Kind: "RoleBinding", Namespace: o.Namespace, Name: o.Name}
+		roleRef, subjects = o.RoleRef, o.Subjects
+	default:
+		return false
+	}
+
+	if deleted || !h.isSubject(subjects) {
+		delete(h.boundRoles, binding)
+		return false
+	}
+	role := rbacKey{Kind: roleRef.Kind, Name: roleRef.Name}
+	if role.Kind == "Role" {
+		role.Namespace = binding.Namespace
+	}
+	h.boundRoles[binding] = role
+	return true
+}
+
+// isBound checks whether a binding of the ServiceAccount references the role.
+// The caller must hold the lock.
+func (h *AccessEventHandler) isBound(role rbacKey) bool {
+	for _, bound := range h.boundRoles {
+		if bound == role {
+			return true
+		}
+	}
+	return false
+}
+
+// isSubject checks whether the ServiceAccount of the controller is one of the
+// subjects of a binding, directly or through one of its groups.
+func (h *AccessEventHandler) isSubject(subjects []rbacv1.Subject) bool {
+	sa := h.ServiceAccount
+	for _, subject := range subjects {
+		switch subject.Kind {
+		case rbacv1.ServiceAccountKind:
+			if subject.Namespace == sa.Namespace && subject.Name == sa.Name {
+				return true
+			}
+		case rbacv1.UserKind:
+			if subject.Name == fmt.Sprintf("system:serviceaccount:%s:%s", sa.Namespace, sa.Name) {
+				return true
+			}
+		case rbacv1.GroupKind:
+			switch subject.Name {
+			case "system:serviceaccounts", "system:serviceaccounts:" + sa.Namespace, "system:authenticated":
+				return true
+			}
+		}
+	}
+	return false
+}
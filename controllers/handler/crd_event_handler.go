@@ -15,21 +15,23 @@
 package handler
 
 import (
+	"context"
+
 	"github.com/go-logr/logr"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/util/workqueue"
-	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/controllers/eventbus"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 )
 
-// CRDEventHandler pushes an event to ResourceGroup event channel
+// CRDEventHandler pushes an event to ResourceGroup event bus
 // when the CRD or its CRs are contained in some ResourceGroup CRs.
 type CRDEventHandler struct {
 	Mapping resourceMap
-	Channel chan event.GenericEvent
+	Events  *eventbus.Bus
 	Log     logr.Logger
 }
 
@@ -75,11 +77,8 @@ func (h *CRDEventHandler) enqueueEvent(obj client.Object) {
 		h.Log.V(5).Info("reset the cached resource status", "resource", gknn)
 		h.Mapping.SetStatus(gknn, nil)
 		for _, r := range h.Mapping.Get(gknn) {
-			var resgroup = &v1alpha1.ResourceGroup{}
-			resgroup.SetNamespace(r.Namespace)
-			resgroup.SetName(r.Name)
-			h.Log.V(5).Info("send a generic event for", "resourcegroup", resgroup.GetObjectMeta())
-			h.Channel <- event.GenericEvent{Object: resgroup}
+			h.Log.V(5).Info("send a generic event for", "resourcegroup", r)
+			h.Events.Publish(context.Background(), r)
 		}
 	}
 }
@@ -15,6 +15,7 @@
 package handler
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/go-logr/logr"
@@ -22,9 +23,9 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/cache"
-	"sigs.k8s.io/controller-runtime/pkg/event"
 
 	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/controllers/eventbus"
 	"kpt.dev/resourcegroup/controllers/resourcemap"
 )
 
@@ -40,11 +41,11 @@ type resourceMap interface {
 	SetStatus(res v1alpha1.ObjMetadata, resStatus *resourcemap.CachedStatus)
 }
 
-// EnqueueEventToChannel pushes an event to ResourceGroup event channel
+// EnqueueEventToChannel pushes an event to ResourceGroup event bus
 // instead of enqueue a Reqeust for ResourceGroup.
 type EnqueueEventToChannel struct {
 	Mapping resourceMap
-	Channel chan event.GenericEvent
+	Events  *eventbus.Bus
 	Log     logr.Logger
 	GVK     schema.GroupVersionKind
 }
@@ -76,11 +77,8 @@ func (e *EnqueueEventToChannel) enqueueEvent(obj interface{}) {
 		return
 	}
 	for _, r := range e.Mapping.Get(gknn) {
-		var resgroup = &v1alpha1.ResourceGroup{}
-		resgroup.SetNamespace(r.Namespace)
-		resgroup.SetName(r.Name)
-		e.Log.V(5).Info("send a generic event for", "resourcegroup", resgroup.GetObjectMeta())
-		e.Channel <- event.GenericEvent{Object: resgroup}
+		e.Log.V(5).Info("send a generic event for", "resourcegroup", r)
+		e.Events.Publish(context.Background(), r)
 	}
 }
 
@@ -15,17 +15,23 @@
 package handler
 
 import (
+	"context"
+	"sync"
 	"testing"
-	"time"
 
 	"github.com/stretchr/testify/assert"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2/klogr"
 	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/controllers/eventbus"
 	"kpt.dev/resourcegroup/controllers/resourcemap"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 type fakeMapping struct{}
@@ -63,39 +69,35 @@ func (m fakeMapping) SetStatus(_ v1alpha1.ObjMetadata, _ *resourcemap.CachedStat
 }
 
 func TestEventHandler(t *testing.T) {
-	ch := make(chan event.GenericEvent)
+	bus := eventbus.New(0)
 	h := EnqueueEventToChannel{
 		Mapping: fakeMapping{},
-		Channel: ch,
+		Events:  bus,
 		Log:     klogr.New(),
 	}
 	u := &unstructured.Unstructured{}
 
-	// Push an event to channel
-	go func() { h.OnAdd(u) }()
+	// Push events to the bus
+	h.OnAdd(u)
 
-	// Consume an event from the channel
-	e := <-ch
-	assert.Equal(t, e.Object.GetName(), "name1")
-	assert.Equal(t, e.Object.GetNamespace(), "namespace1")
-
-	// Consume another event from the channel
-	e = <-ch
-	assert.Equal(t, e.Object.GetName(), "name2")
-	assert.Equal(t, e.Object.GetNamespace(), "namespace2")
+	// The events should be pending in the order they were pushed
+	assert.Equal(t, []types.NamespacedName{
+		{Name: "name1", Namespace: "namespace1"},
+		{Name: "name2", Namespace: "namespace2"},
+	}, bus.Pending())
 }
 
 func TestEventHandlerMultipleHandlers(t *testing.T) {
-	ch := make(chan event.GenericEvent)
+	bus := eventbus.New(0)
 	h1 := EnqueueEventToChannel{
 		Mapping: fakeMapping{},
-		Channel: ch,
+		Events:  bus,
 		Log:     klogr.New(),
 	}
 
 	h2 := EnqueueEventToChannel{
 		Mapping: fakeMapping{},
-		Channel: ch,
+		Events:  bus,
 		Log:     klogr.New(),
 		GVK:     schema.GroupVersionKind{Kind: "MyKind"},
 	}
@@ -103,29 +105,150 @@ func TestEventHandlerMultipleHandlers(t *testing.T) {
 	u1 := &unstructured.Unstructured{}
 	u2 := &unstructured.Unstructured{}
 	u2.SetGroupVersionKind(schema.GroupVersionKind{Kind: "MyKind"})
-	// Push an event to channel
-	go func() { h1.OnAdd(u1) }()
-	go func() { h2.OnDelete(u2) }()
-	time.Sleep(time.Second)
+	// Push events to the bus concurrently
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		h1.OnAdd(u1)
+	}()
+	go func() {
+		defer wg.Done()
+		h2.OnDelete(u2)
+	}()
+	wg.Wait()
 
-	// Consume four events from the channel
 	// These events should be from h1 and h2.
-	// There should be
+	// The two events for "name2" are deduplicated, so there should be
 	//   1 event for "name1"
 	//   1 event for "my-name"
-	//   2 events for "name2"
+	//   1 event for "name2"
 	names := map[string]int{
 		"name1":   0,
 		"name2":   0,
 		"my-name": 0,
 	}
-	for i := 1; i <= 4; i++ {
-		e := <-ch
-		name := e.Object.GetName()
-		names[name]++
+	for _, nn := range bus.Pending() {
+		names[nn.Name]++
 	}
 
+	assert.Equal(t, 3, bus.Len())
 	assert.Equal(t, names["name1"], 1)
-	assert.Equal(t, names["name2"], 2)
+	assert.Equal(t, names["name2"], 1)
 	assert.Equal(t, names["my-name"], 1)
 }
+
+// fakeResetter returns its forbidden kinds once.
+type fakeResetter struct {
+	forbidden []schema.GroupKind
+}
+
+func (r *fakeResetter) ResetForbidden() []schema.GroupKind {
+	gks := r.forbidden
+	r.forbidden = nil
+	return gks
+}
+
+func TestAccessEventHandler(t *testing.T) {
+	deploymentGK := schema.GroupKind{Group: "apps", Kind: "Deployment"}
+	group1 := types.NamespacedName{Namespace: "ns1", Name: "group1"}
+	group2 := types.NamespacedName{Namespace: "ns1", Name: "group2"}
+	resMap := resourcemap.NewResourceMap()
+	resMap.Reconcile(context.TODO(), group1, []v1alpha1.ObjMetadata{
+		{Namespace: "ns1", Name: "app", GroupKind: v1alpha1.GroupKind(deploymentGK)},
+	}, false)
+	resMap.Reconcile(context.TODO(), group2, []v1alpha1.ObjMetadata{
+		{Namespace: "ns1", Name: "cm", GroupKind: v1alpha1.GroupKind{Kind: "ConfigMap"}},
+	}, false)
+	resetter := &fakeResetter{forbidden: []schema.GroupKind{deploymentGK}}
+	h := &AccessEventHandler{
+		Watches: resetter,
+		Mapping: resMap,
+		Role:    "least-privilege",
+		Log:     klogr.New(),
+	}
+	q := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer q.ShutDown()
+
+	// Only the groups including the forbidden kinds are enqueued.
+	h.Update(event.UpdateEvent{ObjectNew: &rbacv1.ClusterRoleBinding{}}, q)
+	assert.Equal(t, 1, q.Len())
+	item, _ := q.Get()
+	assert.Equal(t, reconcile.Request{NamespacedName: group1}, item)
+	q.Done(item)
+
+	h.Create(event.CreateEvent{Object: &rbacv1.Role{}}, q)
+	assert.Equal(t, 0, q.Len())
+
+	// All the groups are enqueued when the least-privilege ClusterRole changes.
+	h.Update(event.UpdateEvent{ObjectNew: &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "least-privilege"}}}, q)
+	assert.Equal(t, 2, q.Len())
+}
+
+func TestAccessEventHandlerServiceAccount(t *testing.T) {
+	deploymentGK := schema.GroupKind{Group: "apps", Kind: "Deployment"}
+	group := types.NamespacedName{Namespace: "ns1", Name: "group1"}
+	resMap := resourcemap.NewResourceMap()
+	resMap.Reconcile(context.TODO(), group, []v1alpha1.ObjMetadata{
+		{Namespace: "ns1", Name: "app", GroupKind: v1alpha1.GroupKind(deploymentGK)},
+	}, false)
+	resetter := &fakeResetter{}
+	h := &AccessEventHandler{
+		Watches:        resetter,
+		Mapping:        resMap,
+		ServiceAccount: types.NamespacedName{Namespace: "system", Name: "sa"},
+		Log:            klogr.New(),
+	}
+	q := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer q.ShutDown()
+	expectEnqueued := func(enqueued bool) {
+		t.Helper()
+		if enqueued {
+			assert.Equal(t, 1, q.Len())
+			item, _ := q.Get()
+			q.Done(item)
+			q.Forget(item)
+		} else {
+			assert.Equal(t, 0, q.Len())
+		}
+		resetter.forbidden = []schema.GroupKind{deploymentGK}
+	}
+	expectEnqueued(false)
+
+	// The bindings of other subjects are ignored.
+	other := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "other"},
+		RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "other"},
+		Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Namespace: "default", Name: "sa"}},
+	}
+	h.Create(event.CreateEvent{Object: other}, q)
+	expectEnqueued(false)
+	h.Update(event.UpdateEvent{ObjectNew: &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "other"}}}, q)
+	expectEnqueued(false)
+
+	// The bindings of the ServiceAccount, and the roles they reference, are considered.
+	binding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "binding"},
+		RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "reader"},
+		Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Namespace: "system", Name: "sa"}},
+	}
+	h.Create(event.CreateEvent{Object: binding}, q)
+	expectEnqueued(true)
+	h.Update(event.UpdateEvent{ObjectNew: &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "reader"}}}, q)
+	expectEnqueued(true)
+	h.Update(event.UpdateEvent{ObjectNew: &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Namespace: "ns2", Name: "reader"}}}, q)
+	expectEnqueued(false)
+
+	// A group including the ServiceAccount is a subject too.
+	h.Create(event.CreateEvent{Object: &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "all"},
+		Subjects:   []rbacv1.Subject{{Kind: rbacv1.GroupKind, Name: "system:serviceaccounts:system"}},
+	}}, q)
+	expectEnqueued(true)
+
+	// A deletion never grants access, and the role is not bound anymore.
+	h.Delete(event.DeleteEvent{Object: binding}, q)
+	expectEnqueued(false)
+	h.Update(event.UpdateEvent{ObjectNew: &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "reader"}}}, q)
+	expectEnqueued(false)
+}
@@ -0,0 +1,462 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package hub aggregates the status of the resources of ResourceGroups which
+// live in member clusters. In hub mode, the controller reads the kubeconfig of
+// each member cluster from a Secret, and runs a ResourceMap and a watch.Manager
+// per member cluster. A ResourceGroup references the resources of the member
+// clusters in its spec.clusters field, and their statuses are reported in its
+// status.clusterStatuses field.
+package hub
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/controllers/eventbus"
+	"kpt.dev/resourcegroup/controllers/resourcemap"
+	controllerstatus "kpt.dev/resourcegroup/controllers/status"
+	"kpt.dev/resourcegroup/controllers/watch"
+)
+
+// KubeconfigKey is the key of the kubeconfig in the data of a member cluster Secret.
+const KubeconfigKey = "kubeconfig"
+
+// ClusterNotConnected is the reason of the Stalled condition of a member
+// cluster which has no valid kubeconfig Secret.
+const ClusterNotConnected = "ClusterNotConnected"
+
+// DefaultAccessRecheckInterval is the default value of Options.AccessRecheckInterval.
+const DefaultAccessRecheckInterval = 10 * time.Minute
+
+// watcher updates the watches of a member cluster.
+type watcher interface {
+	UpdateWatches(ctx context.Context, gvkMap map[schema.GroupVersionKind]struct{}) error
+	// ResetForbidden forgets the kinds which the controller was not allowed
+	// to watch, and returns them.
+	ResetForbidden() []schema.GroupKind
+}
+
+// member tracks the resources of a member cluster.
+type member struct {
+	// kubeconfig is the kubeconfig the member was created from.
+	kubeconfig []byte
+	// resMap maps the ResourceGroups of the hub to their resources in the
+	// member cluster, and caches the statuses of these resources.
+	resMap *resourcemap.ResourceMap
+	// watches watches the resources of the member cluster.
+	watches watcher
+	// mapper resolves the GroupVersionKinds in the member cluster.
+	mapper meta.RESTMapper
+	// reader gets the resources which are not in the cache.
+	reader client.Reader
+	// metadataOnlyKinds is the set of kinds of which only the metadata of the
+	// objects is read.
+	metadataOnlyKinds map[schema.GroupKind]bool
+	// annotationKeys names the annotations read when computing the statuses.
+	annotationKeys controllerstatus.AnnotationKeys
+	// ctx is the context of the watches of the member cluster, which is
+	// canceled by cancel when the member cluster is removed.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// updateWatches watches the kinds of the resources of the member cluster.
+func (m *member) updateWatches(gks []schema.GroupKind) error {
+	gvkMap := map[schema.GroupVersionKind]struct{}{}
+	for _, gk := range gks {
+		mapping, err := m.mapper.RESTMapping(gk)
+		if err != nil {
+			// The type does not exist yet in the member cluster.
+			continue
+		}
+		gvkMap[mapping.GroupVersionKind] = struct{}{}
+	}
+	return m.watches.UpdateWatches(m.ctx, gvkMap)
+}
+
+// memberFunc creates a member cluster from its rest config.
+type memberFunc func(cfg *rest.Config, resMap *resourcemap.ResourceMap, events *eventbus.Bus, opts Options) (*member, error)
+
+// newMember creates a member cluster watching the API server of cfg.
+func newMember(cfg *rest.Config, resMap *resourcemap.ResourceMap, events *eventbus.Bus, opts Options) (*member, error) {
+	options, err := watch.DefaultOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	options.ResyncInterval = opts.ResyncInterval
+	options.MetadataOnlyKinds = opts.MetadataOnlyKinds
+	options.AnnotationKeys = opts.AnnotationKeys
+	watches, err := watch.NewManager(cfg, resMap, events, options)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := client.New(cfg, client.Options{Mapper: options.Mapper})
+	if err != nil {
+		return nil, err
+	}
+	return &member{
+		resMap:  resMap,
+		watches: watches,
+		mapper:  options.Mapper,
+		reader:  reader,
+
+		metadataOnlyKinds: opts.MetadataOnlyKinds,
+		annotationKeys:    opts.AnnotationKeys,
+	}, nil
+}
+
+// Options configures the connections to the member clusters, and the watches
+// and the reads of their resources.
+type Options struct {
+	// SecretNamespace is the namespace of the Secrets holding the kubeconfigs
+	// of the member clusters. The name of a Secret is the name of its member
+	// cluster. An empty namespace disables the hub mode.
+	SecretNamespace string
+
+	// AccessRecheckInterval is the interval between two checks of the access
+	// to the kinds which the controller was not allowed to watch in a member
+	// cluster. The RBAC objects of the member clusters are not watched, so the
+	// access is checked again periodically instead. 0 disables the checks.
+	AccessRecheckInterval time.Duration
+
+	// ResyncInterval is the interval between two comparisons of the cached
+	// statuses with the live objects of each watched type.
+	// 0 disables the resync.
+	ResyncInterval time.Duration
+
+	// MetadataOnlyKinds is the set of kinds whose status is fully determined
+	// by their metadata. Only the metadata of their objects is read.
+	MetadataOnlyKinds map[schema.GroupKind]bool
+
+	// AnnotationKeys names the annotations read when computing the statuses
+	// of the resources.
+	AnnotationKeys controllerstatus.AnnotationKeys
+}
+
+// Hub tracks the member clusters and the resources of the ResourceGroups in them.
+type Hub struct {
+	lock sync.Mutex
+	// members maps the names of the member clusters to their state.
+	members map[string]*member
+	// groups maps the ResourceGroups to their resources in each member cluster.
+	groups map[types.NamespacedName]map[string][]v1alpha1.ObjMetadata
+
+	// client reads the member cluster Secrets.
+	client client.Reader
+	// events is used to trigger the reconciliation of the ResourceGroups when
+	// the status of their resources in the member clusters changes.
+	events    *eventbus.Bus
+	newMember memberFunc
+	opts      Options
+	log       logr.Logger
+}
+
+// New creates a new Hub.
+func New(events *eventbus.Bus, logger logr.Logger, opts Options) *Hub {
+	return &Hub{
+		members:   map[string]*member{},
+		groups:    map[types.NamespacedName]map[string][]v1alpha1.ObjMetadata{},
+		events:    events,
+		newMember: newMember,
+		opts:      opts,
+		log:       logger,
+	}
+}
+
+// SetupWithManager registers a controller reconciling the member cluster
+// Secrets of Options.SecretNamespace with the provided manager.
+func (h *Hub) SetupWithManager(mgr ctrl.Manager) error {
+	h.client = mgr.GetClient()
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.Secret{}).
+		Named("MemberCluster").
+		WithEventFilter(predicate.NewPredicateFuncs(func(obj client.Object) bool {
+			return obj.GetNamespace() == h.opts.SecretNamespace
+		})).
+		WithEventFilter(predicate.Funcs{GenericFunc: func(event.GenericEvent) bool { return false }}).
+		Complete(h)
+}
+
+// Reconcile implements reconcile.Reconciler. It connects to the member cluster
+// of a Secret, or disconnects from it when the Secret is deleted.
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+func (h *Hub) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	cluster := req.Name
+	secret := &corev1.Secret{}
+	if err := h.client.Get(ctx, req.NamespacedName, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			h.RemoveMember(ctx, cluster)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	kubeconfig, found := secret.Data[KubeconfigKey]
+	if !found {
+		h.log.Info("the member cluster Secret has no kubeconfig", "cluster", cluster, "key", KubeconfigKey)
+		h.RemoveMember(ctx, cluster)
+		return ctrl.Result{}, nil
+	}
+	if err := h.AddMember(ctx, cluster, kubeconfig); err != nil {
+		// A new version of the Secret is needed to fix an invalid kubeconfig.
+		h.log.Error(err, "failed to connect to the member cluster", "cluster", cluster)
+		return ctrl.Result{}, nil
+	}
+	return ctrl.Result{RequeueAfter: h.opts.AccessRecheckInterval}, nil
+}
+
+// AddMember connects to the member cluster with the given kubeconfig, and
+// starts watching the resources of the ResourceGroups in it. It reconnects if
+// the kubeconfig changed. Otherwise, it checks again the access to the kinds
+// which the controller was not allowed to watch in the member cluster.
+func (h *Hub) AddMember(ctx context.Context, cluster string, kubeconfig []byte) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if m, found := h.members[cluster]; found && bytes.Equal(m.kubeconfig, kubeconfig) {
+		return h.recheckAccess(ctx, cluster, m)
+	}
+	h.removeMember(ctx, cluster)
+
+	cfg, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return fmt.Errorf("invalid kubeconfig for member cluster %s: %w", cluster, err)
+	}
+	m, err := h.newMember(cfg, resourcemap.NewResourceMap(), h.events, h.opts)
+	if err != nil {
+		return fmt.Errorf("failed to create member cluster %s: %w", cluster, err)
+	}
+	m.kubeconfig = kubeconfig
+	m.ctx, m.cancel = context.WithCancel(ctx)
+	h.members[cluster] = m
+	h.log.Info("connected to the member cluster", "cluster", cluster)
+
+	var gks []schema.GroupKind
+	for group, clusters := range h.groups {
+		if resources, found := clusters[cluster]; found {
+			gks = m.resMap.Reconcile(ctx, group, resources, false)
+			h.events.Publish(ctx, group)
+		}
+	}
+	return m.updateWatches(gks)
+}
+
+// recheckAccess checks again the access to the kinds which the controller was
+// not allowed to watch in the member cluster, and triggers the reconciliation
+// of the ResourceGroups including resources of these kinds. The caller must
+// hold the lock.
+func (h *Hub) recheckAccess(ctx context.Context, cluster string, m *member) error {
+	forbidden := map[schema.GroupKind]bool{}
+	for _, gk := range m.watches.ResetForbidden() {
+		forbidden[gk] = true
+	}
+	if len(forbidden) == 0 {
+		return nil
+	}
+	var gks []schema.GroupKind
+	groups := map[types.NamespacedName]bool{}
+	for group, clusters := range h.groups {
+		for _, res := range clusters[cluster] {
+			gk := schema.GroupKind(res.GroupKind)
+			gks = append(gks, gk)
+			if forbidden[gk] {
+				groups[group] = true
+			}
+		}
+	}
+	h.log.Info("checking the access to the forbidden kinds of the member cluster again", "cluster", cluster, "count", len(forbidden))
+	err := m.updateWatches(gks)
+	for group := range groups {
+		h.events.Publish(ctx, group)
+	}
+	return err
+}
+
+// RemoveMember disconnects from the member cluster.
+func (h *Hub) RemoveMember(ctx context.Context, cluster string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.removeMember(ctx, cluster)
+}
+
+// removeMember stops the watches of the member cluster, and triggers the
+// reconciliation of its ResourceGroups. The caller must hold the lock.
+func (h *Hub) removeMember(ctx context.Context, cluster string) {
+	m, found := h.members[cluster]
+	if !found {
+		return
+	}
+	if err := m.watches.UpdateWatches(m.ctx, map[schema.GroupVersionKind]struct{}{}); err != nil {
+		h.log.Error(err, "failed to stop the watches of the member cluster", "cluster", cluster)
+	}
+	m.cancel()
+	delete(h.members, cluster)
+	h.log.Info("disconnected from the member cluster", "cluster", cluster)
+	for group, clusters := range h.groups {
+		if _, found := clusters[cluster]; found {
+			h.events.Publish(ctx, group)
+		}
+	}
+}
+
+// ReconcileGroup updates the resources of the ResourceGroup in the member
+// clusters, and the watches of the member clusters.
+// To delete a ResourceGroup, call ReconcileGroup(ctx, group, nil, true).
+func (h *Hub) ReconcileGroup(ctx context.Context, group types.NamespacedName, clusters []v1alpha1.ClusterResources, deleteRG bool) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	desired := map[string][]v1alpha1.ObjMetadata{}
+	if !deleteRG {
+		for _, c := range clusters {
+			desired[c.Cluster] = c.Resources
+		}
+	}
+	if len(desired) == 0 {
+		delete(h.groups, group)
+	} else {
+		h.groups[group] = desired
+	}
+
+	var errs []error
+	for cluster, m := range h.members {
+		if !m.resMap.HasResgroup(group) && len(desired[cluster]) == 0 {
+			continue
+		}
+		resources, found := desired[cluster]
+		gks := m.resMap.Reconcile(ctx, group, resources, !found)
+		if err := m.updateWatches(gks); err != nil {
+			errs = append(errs, fmt.Errorf("member cluster %s: %w", cluster, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// Statuses computes the statuses of the resources of the ResourceGroup in the
+// given member clusters. existing is the current cluster statuses of the
+// ResourceGroup, whose conditions keep their LastTransitionTime if their
+// status did not change.
+func (h *Hub) Statuses(ctx context.Context, clusters []v1alpha1.ClusterResources, existing []v1alpha1.ClusterStatus) []v1alpha1.ClusterStatus {
+	if len(clusters) == 0 {
+		return nil
+	}
+	existingConditions := map[string]map[v1alpha1.ObjMetadata][]v1alpha1.Condition{}
+	for _, s := range existing {
+		conds := map[v1alpha1.ObjMetadata][]v1alpha1.Condition{}
+		for _, rs := range s.ResourceStatuses {
+			conds[rs.ObjMetadata] = rs.Conditions
+		}
+		existingConditions[s.Cluster] = conds
+	}
+
+	result := make([]v1alpha1.ClusterStatus, 0, len(clusters))
+	for _, c := range clusters {
+		h.lock.Lock()
+		m, found := h.members[c.Cluster]
+		h.lock.Unlock()
+
+		status := v1alpha1.ClusterStatus{Cluster: c.Cluster}
+		if !found {
+			for _, res := range c.Resources {
+				status.ResourceStatuses = append(status.ResourceStatuses, v1alpha1.ResourceStatus{
+					ObjMetadata: res,
+					Status:      v1alpha1.Unknown,
+				})
+			}
+			status.Conditions = []v1alpha1.Condition{{
+				Type:               v1alpha1.Stalled,
+				Status:             v1alpha1.TrueConditionStatus,
+				Reason:             ClusterNotConnected,
+				Message:            fmt.Sprintf("No valid kubeconfig Secret %s/%s for the member cluster", h.opts.SecretNamespace, c.Cluster),
+				LastTransitionTime: clusterConditionTime(existing, c.Cluster),
+			}}
+			result = append(result, status)
+			continue
+		}
+		for _, res := range c.Resources {
+			status.ResourceStatuses = append(status.ResourceStatuses, m.resourceStatus(ctx, res, existingConditions[c.Cluster][res]))
+		}
+		result = append(result, status)
+	}
+	return result
+}
+
+// clusterConditionTime returns the LastTransitionTime of the Stalled
+// condition of the cluster if it is already not connected, or now.
+func clusterConditionTime(existing []v1alpha1.ClusterStatus, cluster string) metav1.Time {
+	for _, s := range existing {
+		if s.Cluster != cluster {
+			continue
+		}
+		for _, cond := range s.Conditions {
+			if cond.Type == v1alpha1.Stalled && cond.Reason == ClusterNotConnected {
+				return cond.LastTransitionTime
+			}
+		}
+	}
+	return metav1.Now()
+}
+
+// resourceStatus computes the status of a resource of the member cluster from
+// the cached status, or from the object on the API server of the member
+// cluster when the cache misses.
+func (m *member) resourceStatus(ctx context.Context, res v1alpha1.ObjMetadata, existing []v1alpha1.Condition) v1alpha1.ResourceStatus {
+	resStatus := v1alpha1.ResourceStatus{ObjMetadata: res}
+	cachedStatus := m.resMap.GetStatus(res)
+	if cachedStatus == nil {
+		mapping, err := m.mapper.RESTMapping(schema.GroupKind(res.GroupKind))
+		if err != nil {
+			resStatus.Status = v1alpha1.NotFound
+			return resStatus
+		}
+		obj, err := controllerstatus.GetObject(ctx, m.reader, mapping.GroupVersionKind, types.NamespacedName{Namespace: res.Namespace, Name: res.Name},
+			m.metadataOnlyKinds[mapping.GroupVersionKind.GroupKind()])
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				resStatus.Status = v1alpha1.NotFound
+			} else {
+				resStatus.Status = v1alpha1.Unknown
+				resStatus.Conditions = controllerstatus.ReadFailedConditions(err, existing)
+			}
+			return resStatus
+		}
+		cachedStatus = controllerstatus.ComputeStatus(obj, existing, m.annotationKeys)
+		cachedStatus.ResourceVersion = obj.GetResourceVersion()
+		m.resMap.SetStatus(res, cachedStatus)
+	}
+	resStatus.Status = cachedStatus.Status
+	resStatus.Conditions = controllerstatus.MergeConditions(existing, cachedStatus.Conditions)
+	resStatus.SourceHash = cachedStatus.SourceHash
+	return resStatus
+}
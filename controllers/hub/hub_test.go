@@ -0,0 +1,271 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/controllers/eventbus"
+	"kpt.dev/resourcegroup/controllers/resourcemap"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: member
+  cluster:
+    server: https://member.example.com
+contexts:
+- name: member
+  context:
+    cluster: member
+current-context: member
+`
+
+// fakeWatcher records the watched GroupVersionKinds, and returns its
+// forbidden kinds once.
+type fakeWatcher struct {
+	gvks      map[schema.GroupVersionKind]struct{}
+	updates   int
+	forbidden []schema.GroupKind
+}
+
+func (w *fakeWatcher) UpdateWatches(_ context.Context, gvkMap map[schema.GroupVersionKind]struct{}) error {
+	w.gvks = gvkMap
+	w.updates++
+	return nil
+}
+
+func (w *fakeWatcher) ResetForbidden() []schema.GroupKind {
+	gks := w.forbidden
+	w.forbidden = nil
+	return gks
+}
+
+func TestHub(t *testing.T) {
+	cmGVK := schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{cmGVK.GroupVersion()})
+	mapper.Add(cmGVK, meta.RESTScopeNamespace)
+	reader := fake.NewClientBuilder().WithObjects(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "cm1"},
+	}).Build()
+	watches := &fakeWatcher{}
+
+	events := eventbus.New(10)
+	h := New(events, log.Log, Options{})
+	var server string
+	h.newMember = func(cfg *rest.Config, resMap *resourcemap.ResourceMap, _ *eventbus.Bus, _ Options) (*member, error) {
+		server = cfg.Host
+		return &member{resMap: resMap, watches: watches, mapper: mapper, reader: reader}, nil
+	}
+
+	group := types.NamespacedName{Namespace: "ns1", Name: "group1"}
+	cm1 := v1alpha1.ObjMetadata{Namespace: "ns1", Name: "cm1", GroupKind: v1alpha1.GroupKind{Kind: "ConfigMap"}}
+	cm2 := v1alpha1.ObjMetadata{Namespace: "ns1", Name: "cm2", GroupKind: v1alpha1.GroupKind{Kind: "ConfigMap"}}
+	clusters := []v1alpha1.ClusterResources{{Cluster: "member", Resources: []v1alpha1.ObjMetadata{cm1, cm2}}}
+	assert.NoError(t, h.ReconcileGroup(context.TODO(), group, clusters, false))
+
+	// The resources of a member cluster which is not connected are Unknown.
+	statuses := h.Statuses(context.TODO(), clusters, nil)
+	assert.Len(t, statuses, 1)
+	assert.Equal(t, "member", statuses[0].Cluster)
+	assert.Equal(t, v1alpha1.Unknown, statuses[0].ResourceStatuses[0].Status)
+	assert.Equal(t, ClusterNotConnected, statuses[0].Conditions[0].Reason)
+	notConnectedTime := statuses[0].Conditions[0].LastTransitionTime
+	statuses = h.Statuses(context.TODO(), clusters, statuses)
+	assert.Equal(t, notConnectedTime, statuses[0].Conditions[0].LastTransitionTime)
+
+	// Connecting the member cluster watches its resources and triggers the
+	// reconciliation of the group.
+	assert.NoError(t, h.AddMember(context.TODO(), "member", []byte(testKubeconfig)))
+	assert.Equal(t, "https://member.example.com", server)
+	assert.Equal(t, map[schema.GroupVersionKind]struct{}{cmGVK: {}}, watches.gvks)
+	assert.Equal(t, []types.NamespacedName{group}, events.Pending())
+
+	statuses = h.Statuses(context.TODO(), clusters, statuses)
+	assert.Equal(t, []v1alpha1.ClusterStatus{{
+		Cluster: "member",
+		ResourceStatuses: []v1alpha1.ResourceStatus{
+			{ObjMetadata: cm1, Status: v1alpha1.Current},
+			{ObjMetadata: cm2, Status: v1alpha1.NotFound},
+		},
+	}}, statuses)
+	// The cached status records the resource version it was computed from,
+	// so that the resync of the member cluster does not recompute it.
+	assert.Equal(t, "999", h.members["member"].resMap.GetStatus(cm1).ResourceVersion)
+
+	// The access to the forbidden kinds is checked again when the member
+	// cluster Secret is reconciled with the same kubeconfig.
+	updates := watches.updates
+	h.events = eventbus.New(10)
+	assert.NoError(t, h.AddMember(context.TODO(), "member", []byte(testKubeconfig)))
+	assert.Equal(t, updates, watches.updates)
+	assert.Empty(t, h.events.Pending())
+	watches.forbidden = []schema.GroupKind{cmGVK.GroupKind()}
+	assert.NoError(t, h.AddMember(context.TODO(), "member", []byte(testKubeconfig)))
+	assert.Equal(t, updates+1, watches.updates)
+	assert.Equal(t, map[schema.GroupVersionKind]struct{}{cmGVK: {}}, watches.gvks)
+	assert.Equal(t, []types.NamespacedName{group}, h.events.Pending())
+
+	// An invalid kubeconfig disconnects the member cluster.
+	assert.Error(t, h.AddMember(context.TODO(), "member", []byte("invalid")))
+	assert.Empty(t, watches.gvks)
+	statuses = h.Statuses(context.TODO(), clusters, statuses)
+	assert.Equal(t, ClusterNotConnected, statuses[0].Conditions[0].Reason)
+
+	// Deleting the group stops watching its resources.
+	assert.NoError(t, h.AddMember(context.TODO(), "member", []byte(testKubeconfig)))
+	assert.NotEmpty(t, watches.gvks)
+	assert.NoError(t, h.ReconcileGroup(context.TODO(), group, nil, true))
+	assert.Empty(t, watches.gvks)
+	assert.False(t, h.members["member"].resMap.HasResgroup(group))
+	assert.Nil(t, h.Statuses(context.TODO(), nil, statuses))
+}
+
+func TestHubReconcile(t *testing.T) {
+	h := New(eventbus.New(10), log.Log, Options{SecretNamespace: "clusters", AccessRecheckInterval: time.Minute})
+	h.newMember = func(_ *rest.Config, resMap *resourcemap.ResourceMap, _ *eventbus.Bus, _ Options) (*member, error) {
+		return &member{resMap: resMap, watches: &fakeWatcher{}}, nil
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "clusters", Name: "member"},
+		Data:       map[string][]byte{KubeconfigKey: []byte(testKubeconfig)},
+	}
+	h.client = fake.NewClientBuilder().WithObjects(secret).Build()
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "clusters", Name: "member"}}
+
+	// The access of a connected member cluster is checked again periodically.
+	result, err := h.Reconcile(context.TODO(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, result.RequeueAfter)
+	assert.Contains(t, h.members, "member")
+
+	// Deleting the Secret disconnects the member cluster.
+	assert.NoError(t, h.client.(client.Client).Delete(context.TODO(), secret))
+	result, err = h.Reconcile(context.TODO(), req)
+	assert.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+	assert.NotContains(t, h.members, "member")
+	statuses := h.Statuses(context.TODO(), []v1alpha1.ClusterResources{{Cluster: "member"}}, nil)
+	assert.Equal(t, "No valid kubeconfig Secret clusters/member for the member cluster", statuses[0].Conditions[0].Message)
+}
+
+// kubeconfigFor returns a kubeconfig connecting to the API server of cfg.
+func kubeconfigFor(t *testing.T, cfg *rest.Config) []byte {
+	kubeconfig := clientcmdapi.NewConfig()
+	kubeconfig.Clusters["member"] = &clientcmdapi.Cluster{
+		Server:                   cfg.Host,
+		CertificateAuthorityData: cfg.CAData,
+	}
+	kubeconfig.AuthInfos["member"] = &clientcmdapi.AuthInfo{
+		ClientCertificateData: cfg.CertData,
+		ClientKeyData:         cfg.KeyData,
+		Token:                 cfg.BearerToken,
+	}
+	kubeconfig.Contexts["member"] = &clientcmdapi.Context{Cluster: "member", AuthInfo: "member"}
+	kubeconfig.CurrentContext = "member"
+	data, err := clientcmd.Write(*kubeconfig)
+	assert.NoError(t, err)
+	return data
+}
+
+func TestHubWithMemberCluster(t *testing.T) {
+	const secretNamespace = "member-clusters"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mgr, err := manager.New(hubCfg, manager.Options{MetricsBindAddress: "0"})
+	assert.NoError(t, err)
+	hubClient := mgr.GetClient()
+	memberClient, err := client.New(memberCfg, client.Options{})
+	assert.NoError(t, err)
+
+	events := eventbus.New(10)
+	h := New(events, log.Log, Options{SecretNamespace: secretNamespace, AccessRecheckInterval: DefaultAccessRecheckInterval})
+	assert.NoError(t, h.SetupWithManager(mgr))
+	go func() {
+		assert.NoError(t, mgr.Start(ctx))
+	}()
+
+	group := types.NamespacedName{Namespace: "default", Name: "group1"}
+	cm1 := v1alpha1.ObjMetadata{Namespace: "default", Name: "cm1", GroupKind: v1alpha1.GroupKind{Kind: "ConfigMap"}}
+	cm2 := v1alpha1.ObjMetadata{Namespace: "default", Name: "cm2", GroupKind: v1alpha1.GroupKind{Kind: "ConfigMap"}}
+	clusters := []v1alpha1.ClusterResources{{Cluster: "member", Resources: []v1alpha1.ObjMetadata{cm1, cm2}}}
+	assert.NoError(t, h.ReconcileGroup(ctx, group, clusters, false))
+	assert.NoError(t, memberClient.Create(ctx, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "cm1"},
+	}))
+
+	statusesOf := func() []v1alpha1.ResourceStatus {
+		return h.Statuses(ctx, clusters, nil)[0].ResourceStatuses
+	}
+	memberStatus := func(res v1alpha1.ObjMetadata) *resourcemap.CachedStatus {
+		h.lock.Lock()
+		m, found := h.members["member"]
+		h.lock.Unlock()
+		if !found {
+			return nil
+		}
+		return m.resMap.GetStatus(res)
+	}
+
+	// The Secret of the member cluster connects the hub to its API server.
+	assert.NoError(t, hubClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: secretNamespace}}))
+	assert.NoError(t, hubClient.Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: secretNamespace, Name: "member"},
+		Data:       map[string][]byte{KubeconfigKey: kubeconfigFor(t, memberCfg)},
+	}))
+	assert.Eventually(t, func() bool {
+		statuses := statusesOf()
+		return statuses[0].Status == v1alpha1.Current && statuses[1].Status == v1alpha1.NotFound
+	}, 30*time.Second, 100*time.Millisecond)
+
+	// The resources of the member cluster are watched.
+	assert.NoError(t, memberClient.Create(ctx, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "cm2"},
+	}))
+	assert.Eventually(t, func() bool {
+		status := memberStatus(cm2)
+		return status != nil && status.Status == v1alpha1.Current
+	}, 30*time.Second, 100*time.Millisecond)
+	assert.Contains(t, events.Pending(), group)
+
+	// Deleting the Secret disconnects the member cluster.
+	assert.NoError(t, hubClient.Delete(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: secretNamespace, Name: "member"},
+	}))
+	assert.Eventually(t, func() bool {
+		statuses := h.Statuses(ctx, clusters, nil)
+		return len(statuses[0].Conditions) == 1 && statuses[0].Conditions[0].Reason == ClusterNotConnected
+	}, 30*time.Second, 100*time.Millisecond)
+}
@@ -0,0 +1,53 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hub
+
+import (
+	"log"
+	"os"
+	"testing"
+
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+)
+
+// hubCfg and memberCfg are the configs of the API servers of the hub and of a
+// member cluster.
+var hubCfg, memberCfg *rest.Config
+
+func TestMain(m *testing.M) {
+	hubEnv := &envtest.Environment{}
+	memberEnv := &envtest.Environment{}
+
+	var err error
+	hubCfg, err = hubEnv.Start()
+	if err != nil {
+		log.Fatal(err)
+	}
+	memberCfg, err = memberEnv.Start()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	code := m.Run()
+
+	for _, env := range []*envtest.Environment{memberEnv, hubEnv} {
+		if err := env.Stop(); err != nil {
+			log.Printf("Error: Failed to stop test env: %v", err)
+		}
+	}
+
+	os.Exit(code)
+}
@@ -61,6 +61,14 @@ var (
 		"The number of KCC resources in a ResourceGroup CR",
 		stats.UnitDimensionless)
 
+	// DriftedResourceCount tracks the number of resources in a ResourceGroup CR
+	// whose source hash does not match the revision of the ResourceGroup CR.
+	// This metric should be updated in the ResourceGroup controller.
+	DriftedResourceCount = stats.Int64(
+		"drifted_resource_count",
+		"The number of resources in a ResourceGroup CR which drifted from the revision of the ResourceGroup CR",
+		stats.UnitDimensionless)
+
 	// NamespaceCount tracks the number of resource namespaces in a ResourceGroup CR.
 	// This metric should be updated in the Root controller.
 	NamespaceCount = stats.Int64(
@@ -87,4 +95,60 @@ var (
 		"pipeline_error_observed",
 		"A boolean value indicates if error happened at readiness stage when syncing a commit",
 		stats.UnitDimensionless)
+
+	// EventQueueDepth tracks the number of ResourceGroups waiting to be
+	// delivered to the ResourceGroup controller.
+	// This metric should be updated in the event bus.
+	EventQueueDepth = stats.Int64(
+		"event_queue_depth",
+		"The number of ResourceGroups with pending events for the ResourceGroup controller",
+		stats.UnitDimensionless)
+
+	// EventsDropped tracks the number of events dropped because the event bus was full.
+	// This metric should be updated in the event bus.
+	EventsDropped = stats.Int64(
+		"events_dropped",
+		"The number of ResourceGroup events dropped because the event bus was full",
+		stats.UnitDimensionless)
+
+	// OverlappingResourceCount tracks the number of resources included in more
+	// than one ResourceGroup CR.
+	// This metric should be updated with the ownership report of the resource map.
+	OverlappingResourceCount = stats.Int64(
+		"overlapping_resource_count",
+		"The number of resources included in more than one ResourceGroup CR",
+		stats.UnitDimensionless)
+
+	// OrphanedResourceCount tracks the number of existing resources included in a
+	// ResourceGroup CR which are not owned by any inventory.
+	// This metric should be updated with the ownership report of the resource map.
+	OrphanedResourceCount = stats.Int64(
+		"orphaned_resource_count",
+		"The number of existing resources included in a ResourceGroup CR without an owning inventory",
+		stats.UnitDimensionless)
+
+	// ForbiddenWatchCount tracks the number of kinds of resources which are
+	// not watched because the controller is not allowed to list or watch them.
+	// This metric should be updated in the watch manager.
+	ForbiddenWatchCount = stats.Int64(
+		"forbidden_watch_count",
+		"The number of kinds of resources which the controller is not allowed to list or watch",
+		stats.UnitDimensionless)
+
+	// ResyncCorrectionCount tracks the number of cached resource statuses
+	// which were corrected by a periodic resync.
+	// This metric should be updated in the watchers.
+	ResyncCorrectionCount = stats.Int64(
+		"resync_correction_count",
+		"The number of cached resource statuses corrected by a periodic resync",
+		stats.UnitDimensionless)
+
+	// TimeToReady tracks the time duration in seconds between the observation
+	// of a new generation of a ResourceGroup CR and the time its resources
+	// became ready for this generation.
+	// This metric should be updated in the ResourceGroup controller.
+	TimeToReady = stats.Float64(
+		"rg_time_to_ready_seconds",
+		"Time duration in seconds between the observation of a new generation of a ResourceGroup CR and its readiness",
+		stats.UnitSeconds)
 )
@@ -110,6 +110,46 @@ func RecordPipelineError(ctx context.Context, nn types.NamespacedName, component
 		PipelineErrorView.Name, component, nn.Namespace, reconcilerName, nn.Name, metricVal)
 }
 
+// RecordEventQueueDepth produces a measurement for the EventQueueDepth view.
+func RecordEventQueueDepth(ctx context.Context, depth int64) {
+	stats.Record(ctx, EventQueueDepth.M(depth))
+}
+
+// RecordEventDropped produces a measurement for the EventsDropped view.
+func RecordEventDropped(ctx context.Context) {
+	stats.Record(ctx, EventsDropped.M(1))
+}
+
+// RecordDriftedResourceCount produces a measurement for the DriftedResourceCount view.
+func RecordDriftedResourceCount(ctx context.Context, nn types.NamespacedName, count int64) {
+	tagCtx, _ := tag.New(ctx, tag.Upsert(KeyResourceGroup, nn.String()))
+	measurement := DriftedResourceCount.M(count)
+	stats.Record(tagCtx, measurement)
+}
+
+// RecordOwnershipReport produces measurements for the OverlappingResourceCount
+// and OrphanedResourceCount views.
+func RecordOwnershipReport(ctx context.Context, overlapping, orphaned int64) {
+	stats.Record(ctx, OverlappingResourceCount.M(overlapping), OrphanedResourceCount.M(orphaned))
+}
+
+// RecordForbiddenWatchCount produces a measurement for the ForbiddenWatchCount view.
+func RecordForbiddenWatchCount(ctx context.Context, count int64) {
+	stats.Record(ctx, ForbiddenWatchCount.M(count))
+}
+
+// RecordResyncCorrections produces a measurement for the ResyncCorrectionCount view.
+func RecordResyncCorrections(ctx context.Context, count int64) {
+	stats.Record(ctx, ResyncCorrectionCount.M(count))
+}
+
+// RecordTimeToReady produces a measurement for the TimeToReady view.
+func RecordTimeToReady(ctx context.Context, nn types.NamespacedName, d time.Duration) {
+	tagCtx, _ := tag.New(ctx, tag.Upsert(KeyResourceGroup, nn.String()))
+	measurement := TimeToReady.M(d.Seconds())
+	stats.Record(tagCtx, measurement)
+}
+
 // ComputeReconcilerNameType computes the reconciler name from the ResourceGroup CR name
 func ComputeReconcilerNameType(nn types.NamespacedName) (reconcilerName, reconcilerType string) {
 	if nn.Namespace == CMSNamespace {
@@ -43,6 +43,14 @@ func RegisterReconcilerMetricsViews() error {
 		ClusterScopedResourceCountView,
 		CRDCountView,
 		KCCResourceCountView,
+		DriftedResourceCountView,
 		PipelineErrorView,
+		EventQueueDepthView,
+		EventsDroppedView,
+		OverlappingResourceCountView,
+		OrphanedResourceCountView,
+		ResyncCorrectionCountView,
+		ForbiddenWatchCountView,
+		TimeToReadyView,
 	)
 }
@@ -91,6 +91,15 @@ var (
 		Aggregation: view.LastValue(),
 	}
 
+	// DriftedResourceCountView aggregates the drifted resources in a ResourceGroup
+	DriftedResourceCountView = &view.View{
+		Name:        DriftedResourceCount.Name(),
+		Measure:     DriftedResourceCount,
+		Description: "The total number of drifted resources in a ResourceGroup",
+		TagKeys:     []tag.Key{KeyResourceGroup},
+		Aggregation: view.LastValue(),
+	}
+
 	// PipelineErrorView aggregates the PipelineError by components
 	// TODO: add link to same metric in Config Sync under pkg/metrics/views.go
 	PipelineErrorView = &view.View{
@@ -100,4 +109,61 @@ var (
 		TagKeys:     []tag.Key{KeyName, KeyComponent, KeyType},
 		Aggregation: view.LastValue(),
 	}
+
+	// EventQueueDepthView aggregates the EventQueueDepth metric measurements.
+	EventQueueDepthView = &view.View{
+		Name:        EventQueueDepth.Name(),
+		Measure:     EventQueueDepth,
+		Description: "The current number of ResourceGroups with pending events",
+		Aggregation: view.LastValue(),
+	}
+
+	// EventsDroppedView counts the events dropped by the event bus.
+	EventsDroppedView = &view.View{
+		Name:        EventsDropped.Name(),
+		Measure:     EventsDropped,
+		Description: "The total number of ResourceGroup events dropped because the event bus was full",
+		Aggregation: view.Sum(),
+	}
+
+	// OverlappingResourceCountView aggregates the OverlappingResourceCount metric measurements.
+	OverlappingResourceCountView = &view.View{
+		Name:        OverlappingResourceCount.Name(),
+		Measure:     OverlappingResourceCount,
+		Description: "The current number of resources included in more than one ResourceGroup",
+		Aggregation: view.LastValue(),
+	}
+
+	// OrphanedResourceCountView aggregates the OrphanedResourceCount metric measurements.
+	OrphanedResourceCountView = &view.View{
+		Name:        OrphanedResourceCount.Name(),
+		Measure:     OrphanedResourceCount,
+		Description: "The current number of existing resources in a ResourceGroup without an owning inventory",
+		Aggregation: view.LastValue(),
+	}
+
+	// ForbiddenWatchCountView aggregates the ForbiddenWatchCount metric measurements.
+	ForbiddenWatchCountView = &view.View{
+		Name:        ForbiddenWatchCount.Name(),
+		Measure:     ForbiddenWatchCount,
+		Description: "The current number of kinds of resources which the controller is not allowed to list or watch",
+		Aggregation: view.LastValue(),
+	}
+
+	// ResyncCorrectionCountView counts the cached statuses corrected by the resyncs.
+	ResyncCorrectionCountView = &view.View{
+		Name:        ResyncCorrectionCount.Name(),
+		Measure:     ResyncCorrectionCount,
+		Description: "The total number of cached resource statuses corrected by a periodic resync",
+		Aggregation: view.Sum(),
+	}
+
+	// TimeToReadyView aggregates the TimeToReady metric measurements.
+	TimeToReadyView = &view.View{
+		Name:        TimeToReady.Name(),
+		Measure:     TimeToReady,
+		Description: "The distribution of time taken by the resources of a ResourceGroup CR to become ready after its generation changed",
+		TagKeys:     []tag.Key{KeyResourceGroup},
+		Aggregation: view.Distribution(1, 5, 10, 30, 60, 120, 300, 600, 1800, 3600),
+	}
 )
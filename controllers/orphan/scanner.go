@@ -0,0 +1,146 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package orphan finds the live objects owned by the inventory of a
+// ResourceGroup which are not included in the ResourceGroup, e.g. the
+// objects which failed to be pruned.
+package orphan
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/controllers/eventbus"
+	"kpt.dev/resourcegroup/controllers/resourcemap"
+)
+
+// resolver resolves the preferred GroupVersionKind of a GroupKind.
+type resolver interface {
+	Resolve(gk schema.GroupKind) (schema.GroupVersionKind, bool)
+}
+
+// Scanner periodically lists the live objects of the kinds included in the
+// ResourceGroups, and records the objects whose owning-inventory annotation
+// matches the inventory id of a ResourceGroup which does not include them.
+//
+// Only the kinds of the resources included in at least one ResourceGroup are
+// scanned.
+type Scanner struct {
+	// reader lists the metadata of the live objects.
+	reader client.Reader
+	// resolver finds the version to list for each kind.
+	resolver resolver
+	// resMap records the orphans of the ResourceGroups.
+	resMap *resourcemap.ResourceMap
+	// events is used to trigger the reconciliation of the ResourceGroups
+	// whose orphans changed.
+	events *eventbus.Bus
+	// interval is the interval between two scans.
+	interval time.Duration
+	// inventoryKey is the name of the annotation which contains the id of
+	// the inventory owning an object.
+	inventoryKey string
+	log          logr.Logger
+}
+
+// NewScanner creates a new Scanner which reads the owning inventory of the
+// objects from the inventoryKey annotation.
+func NewScanner(reader client.Reader, resolver resolver, resMap *resourcemap.ResourceMap,
+	events *eventbus.Bus, interval time.Duration, inventoryKey string, logger logr.Logger) *Scanner {
+	return &Scanner{
+		reader:       reader,
+		resolver:     resolver,
+		resMap:       resMap,
+		events:       events,
+		interval:     interval,
+		inventoryKey: inventoryKey,
+		log:          logger,
+	}
+}
+
+// Start implements manager.Runnable. It scans for orphans every interval
+// until ctx is done.
+func (s *Scanner) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := s.Scan(ctx); err != nil {
+			s.log.Error(err, "failed to scan for orphans")
+		}
+	}, s.interval)
+	return nil
+}
+
+// Scan lists the live objects once, records the orphans of every ResourceGroup,
+// and triggers the reconciliation of the ResourceGroups whose orphans changed.
+//
+// The previous orphans of the kinds which cannot be listed are kept, and the
+// last error is returned after the orphans of the other kinds are recorded.
+func (s *Scanner) Scan(ctx context.Context) error {
+	owners := map[v1alpha1.ObjMetadata]string{}
+	var failed []schema.GroupKind
+	var lastErr error
+	for _, gk := range s.resMap.GroupKinds() {
+		gvk, found := s.resolver.Resolve(gk)
+		if !found {
+			continue
+		}
+		if err := s.listOwners(ctx, gvk, owners); err != nil {
+			s.log.V(3).Info("failed to list objects", "gvk", gvk, "error", err)
+			failed = append(failed, gk)
+			lastErr = err
+		}
+	}
+	for _, group := range s.resMap.SetOrphans(s.resMap.FindOrphans(owners), failed) {
+		s.events.Publish(ctx, group)
+	}
+	return lastErr
+}
+
+// listPageSize is the maximum number of objects in a page of a scan.
+const listPageSize = 500
+
+// listOwners lists the objects of the given kind page by page, and adds the
+// ones with an owning inventory into owners.
+func (s *Scanner) listOwners(ctx context.Context, gvk schema.GroupVersionKind, owners map[v1alpha1.ObjMetadata]string) error {
+	var continueToken string
+	for {
+		list := &metav1.PartialObjectMetadataList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		if err := s.reader.List(ctx, list, client.Limit(listPageSize), client.Continue(continueToken)); err != nil {
+			return err
+		}
+		for _, item := range list.Items {
+			inv := item.GetAnnotations()[s.inventoryKey]
+			if inv == "" {
+				continue
+			}
+			id := v1alpha1.ObjMetadata{
+				Namespace: item.GetNamespace(),
+				Name:      item.GetName(),
+				GroupKind: v1alpha1.GroupKind{Group: gvk.Group, Kind: gvk.Kind},
+			}
+			owners[id] = inv
+		}
+		continueToken = list.GetContinue()
+		if continueToken == "" {
+			return nil
+		}
+	}
+}
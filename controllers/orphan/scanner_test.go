@@ -0,0 +1,151 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orphan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/controllers/eventbus"
+	"kpt.dev/resourcegroup/controllers/resourcemap"
+	controllerstatus "kpt.dev/resourcegroup/controllers/status"
+	"kpt.dev/resourcegroup/controllers/typeresolver"
+)
+
+// fakeReader lists the metadata of objects by kind, page by page. The continue
+// token is the index of the first object of the next page.
+type fakeReader struct {
+	client.Reader
+	objects map[string][]metav1.PartialObjectMetadata
+	err     error
+	pages   int
+}
+
+func (r *fakeReader) List(_ context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if r.err != nil {
+		return r.err
+	}
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	l := list.(*metav1.PartialObjectMetadataList)
+	items := r.objects[l.GetObjectKind().GroupVersionKind().Kind]
+	start := 0
+	if listOpts.Continue != "" {
+		start, _ = strconv.Atoi(listOpts.Continue)
+	}
+	end := len(items)
+	if listOpts.Limit > 0 && start+int(listOpts.Limit) < end {
+		end = start + int(listOpts.Limit)
+		l.SetContinue(strconv.Itoa(end))
+	}
+	l.Items = items[start:end]
+	r.pages++
+	return nil
+}
+
+func newObject(namespace, name, inv string) metav1.PartialObjectMetadata {
+	obj := metav1.PartialObjectMetadata{}
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	if inv != "" {
+		obj.SetAnnotations(map[string]string{controllerstatus.DefaultOwningInventoryKey: inv})
+	}
+	return obj
+}
+
+func TestScan(t *testing.T) {
+	cmGK := schema.GroupKind{Kind: "ConfigMap"}
+	resolver := typeresolver.NewFakeTypeResolver(map[schema.GroupKind]schema.GroupVersionKind{
+		cmGK: cmGK.WithVersion("v1"),
+	})
+	newRes := func(name string) v1alpha1.ObjMetadata {
+		return v1alpha1.ObjMetadata{Namespace: "ns1", Name: name, GroupKind: v1alpha1.GroupKind{Kind: "ConfigMap"}}
+	}
+	group1 := types.NamespacedName{Namespace: "ns1", Name: "group1"}
+	group2 := types.NamespacedName{Namespace: "ns1", Name: "group2"}
+
+	resMap := resourcemap.NewResourceMap()
+	resMap.Reconcile(context.TODO(), group1, []v1alpha1.ObjMetadata{newRes("cm1")}, false)
+	resMap.Reconcile(context.TODO(), group2, []v1alpha1.ObjMetadata{newRes("cm2")}, false)
+	resMap.SetInventoryID(group1, "inv1")
+	resMap.SetInventoryID(group2, "inv2")
+
+	reader := &fakeReader{objects: map[string][]metav1.PartialObjectMetadata{
+		"ConfigMapList": {
+			newObject("ns1", "cm1", "inv1"),
+			newObject("ns1", "cm2", "inv2"),
+			newObject("ns1", "leftover", "inv1"),
+			newObject("ns1", "unmanaged", ""),
+		},
+	}}
+	events := eventbus.New(10)
+	scanner := NewScanner(reader, resolver, resMap, events, time.Minute, controllerstatus.DefaultOwningInventoryKey, log.Log)
+
+	assert.NoError(t, scanner.Scan(context.TODO()))
+	assert.Equal(t, []v1alpha1.ObjMetadata{newRes("leftover")}, resMap.GetOrphans(group1))
+	assert.Nil(t, resMap.GetOrphans(group2))
+	assert.Equal(t, []types.NamespacedName{group1}, events.Pending())
+
+	// The orphans of the kinds which cannot be listed are kept.
+	reader.err = errors.New("forbidden")
+	assert.Error(t, scanner.Scan(context.TODO()))
+	assert.Equal(t, []v1alpha1.ObjMetadata{newRes("leftover")}, resMap.GetOrphans(group1))
+
+	// The orphan is removed once it is pruned.
+	reader.err = nil
+	reader.objects["ConfigMapList"] = reader.objects["ConfigMapList"][:2]
+	assert.NoError(t, scanner.Scan(context.TODO()))
+	assert.Nil(t, resMap.GetOrphans(group1))
+}
+
+func TestScanPages(t *testing.T) {
+	cmGK := schema.GroupKind{Kind: "ConfigMap"}
+	resolver := typeresolver.NewFakeTypeResolver(map[schema.GroupKind]schema.GroupVersionKind{
+		cmGK: cmGK.WithVersion("v1"),
+	})
+	group := types.NamespacedName{Namespace: "ns1", Name: "group"}
+	resMap := resourcemap.NewResourceMap()
+	resMap.Reconcile(context.TODO(), group, []v1alpha1.ObjMetadata{
+		{Namespace: "ns1", Name: "cm", GroupKind: v1alpha1.GroupKind{Kind: "ConfigMap"}},
+	}, false)
+	resMap.SetInventoryID(group, "inv")
+
+	// The orphan is on the last page.
+	var objects []metav1.PartialObjectMetadata
+	for i := 0; i < 2*listPageSize; i++ {
+		objects = append(objects, newObject("ns1", fmt.Sprintf("unmanaged-%d", i), ""))
+	}
+	objects = append(objects, newObject("ns1", "leftover", "inv"))
+	reader := &fakeReader{objects: map[string][]metav1.PartialObjectMetadata{"ConfigMapList": objects}}
+	scanner := NewScanner(reader, resolver, resMap, eventbus.New(10), time.Minute, controllerstatus.DefaultOwningInventoryKey, log.Log)
+
+	assert.NoError(t, scanner.Scan(context.TODO()))
+	assert.Equal(t, 3, reader.pages)
+	assert.Equal(t, []v1alpha1.ObjMetadata{
+		{Namespace: "ns1", Name: "leftover", GroupKind: v1alpha1.GroupKind{Kind: "ConfigMap"}},
+	}, resMap.GetOrphans(group))
+}
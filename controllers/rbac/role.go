@@ -0,0 +1,102 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rbac builds and checks the least-privilege ClusterRole which grants
+// the controller the access to the kinds of the resources of the ResourceGroups.
+package rbac
+
+import (
+	"sort"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Verbs are the verbs which the controller needs on the resources of the
+// ResourceGroups to compute their statuses.
+var Verbs = []string{"get", "list", "watch"}
+
+// NewClusterRole returns a ClusterRole which grants Verbs on exactly the given
+// resources, with one rule per API group. It replaces the rule granting these
+// verbs on all the resources of the cluster.
+func NewClusterRole(name string, resources []schema.GroupResource) *rbacv1.ClusterRole {
+	byGroup := make(map[string]map[string]bool)
+	for _, gr := range resources {
+		if byGroup[gr.Group] == nil {
+			byGroup[gr.Group] = make(map[string]bool)
+		}
+		byGroup[gr.Group][gr.Resource] = true
+	}
+	groups := make([]string, 0, len(byGroup))
+	for group := range byGroup {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	role := &rbacv1.ClusterRole{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: rbacv1.SchemeGroupVersion.String(),
+			Kind:       "ClusterRole",
+		},
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+	}
+	for _, group := range groups {
+		names := make([]string, 0, len(byGroup[group]))
+		for resource := range byGroup[group] {
+			names = append(names, resource)
+		}
+		sort.Strings(names)
+		role.Rules = append(role.Rules, rbacv1.PolicyRule{
+			APIGroups: []string{group},
+			Resources: names,
+			Verbs:     append([]string(nil), Verbs...),
+		})
+	}
+	return role
+}
+
+// Allows checks whether the rules grant all the Verbs on all the objects of
+// the given resource. The rules restricted to some resource names do not
+// allow listing or watching the resource, so they are ignored.
+func Allows(rules []rbacv1.PolicyRule, gr schema.GroupResource) bool {
+	granted := make(map[string]bool)
+	for _, rule := range rules {
+		if len(rule.ResourceNames) > 0 || !matches(rule.APIGroups, gr.Group) || !matches(rule.Resources, gr.Resource) {
+			continue
+		}
+		for _, verb := range rule.Verbs {
+			granted[verb] = true
+		}
+	}
+	if granted[rbacv1.VerbAll] {
+		return true
+	}
+	for _, verb := range Verbs {
+		if !granted[verb] {
+			return false
+		}
+	}
+	return true
+}
+
+// matches checks whether values include value or the wildcard.
+func matches(values []string, value string) bool {
+	for _, v := range values {
+		if v == value || v == rbacv1.ResourceAll {
+			return true
+		}
+	}
+	return false
+}
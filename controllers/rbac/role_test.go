@@ -0,0 +1,82 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rbac
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestNewClusterRole(t *testing.T) {
+	role := NewClusterRole("resource-group-members", []schema.GroupResource{
+		{Group: "apps", Resource: "statefulsets"},
+		{Resource: "services"},
+		{Group: "apps", Resource: "deployments"},
+		{Resource: "configmaps"},
+	})
+	assert.Equal(t, "resource-group-members", role.Name)
+	assert.Equal(t, "ClusterRole", role.Kind)
+	assert.Equal(t, []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"configmaps", "services"}, Verbs: []string{"get", "list", "watch"}},
+		{APIGroups: []string{"apps"}, Resources: []string{"deployments", "statefulsets"}, Verbs: []string{"get", "list", "watch"}},
+	}, role.Rules)
+}
+
+func TestAllows(t *testing.T) {
+	deployments := schema.GroupResource{Group: "apps", Resource: "deployments"}
+	tests := map[string]struct {
+		rules []rbacv1.PolicyRule
+		want  bool
+	}{
+		"no rules": {
+			want: false,
+		},
+		"generated role": {
+			rules: NewClusterRole("role", []schema.GroupResource{deployments}).Rules,
+			want:  true,
+		},
+		"wildcards": {
+			rules: []rbacv1.PolicyRule{{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}}},
+			want:  true,
+		},
+		"verbs split across rules": {
+			rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"get"}},
+				{APIGroups: []string{"apps"}, Resources: []string{"*"}, Verbs: []string{"list", "watch"}},
+			},
+			want: true,
+		},
+		"missing verb": {
+			rules: []rbacv1.PolicyRule{{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"get", "list"}}},
+			want:  false,
+		},
+		"other group": {
+			rules: []rbacv1.PolicyRule{{APIGroups: []string{"extensions"}, Resources: []string{"deployments"}, Verbs: []string{"*"}}},
+			want:  false,
+		},
+		"resource names": {
+			rules: []rbacv1.PolicyRule{{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, ResourceNames: []string{"app"}, Verbs: []string{"*"}}},
+			want:  false,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, Allows(tc.rules, deployments))
+		})
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroup
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+)
+
+//nolint:revive // TODO: add comments for public constants and enable linting
+const (
+	WatchesForbidden  = "WatchesForbidden"
+	WatchesAllowed    = "WatchesAllowed"
+	watchesAllowedMsg = "the controller is allowed to watch the kinds of all the resources"
+)
+
+func newWatchForbiddenCondition(status v1alpha1.ConditionStatus, reason, message string) v1alpha1.Condition {
+	return v1alpha1.Condition{
+		Type:               v1alpha1.WatchForbidden,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: metav1.Time{Time: time.Now().UTC()},
+	}
+}
+
+// watchForbiddenCondition returns the WatchForbidden condition of a group
+// with the given spec. It lists the kinds of its members which the controller
+// is not allowed to watch, together with the forbidden verbs, as recorded by
+// the watch manager.
+func (r *reconciler) watchForbiddenCondition(spec v1alpha1.ResourceGroupSpec) v1alpha1.Condition {
+	var forbidden []string
+	for _, gk := range memberGroupKinds(spec) {
+		if verbs := r.resMap.GetForbiddenVerbs(gk); len(verbs) > 0 {
+			forbidden = append(forbidden, fmt.Sprintf("%s (%s)", gk, strings.Join(verbs, ", ")))
+		}
+	}
+	if len(forbidden) == 0 {
+		return newWatchForbiddenCondition(v1alpha1.FalseConditionStatus, WatchesAllowed, watchesAllowedMsg)
+	}
+	return newWatchForbiddenCondition(v1alpha1.TrueConditionStatus, WatchesForbidden,
+		fmt.Sprintf("the controller is not allowed to watch %d kinds of resources, whose statuses are not updated when they change: %s",
+			len(forbidden), truncatedList(forbidden)))
+}
+
+// memberGroupKinds returns the distinct GroupKinds of the resources and the
+// subgroups of a group with the given spec, in the order of the spec.
+func memberGroupKinds(spec v1alpha1.ResourceGroupSpec) []schema.GroupKind {
+	members := append(append([]v1alpha1.ObjMetadata(nil), spec.Resources...), v1alpha1.ToObjMetadata(spec.Subgroups)...)
+	seen := make(map[schema.GroupKind]bool)
+	var gks []schema.GroupKind
+	for _, res := range members {
+		gk := schema.GroupKind(res.GroupKind)
+		if !seen[gk] {
+			seen[gk] = true
+			gks = append(gks, gk)
+		}
+	}
+	return gks
+}
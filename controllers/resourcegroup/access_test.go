@@ -0,0 +1,54 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/controllers/resourcemap"
+)
+
+func TestWatchForbiddenCondition(t *testing.T) {
+	deploymentGK := schema.GroupKind{Group: "apps", Kind: "Deployment"}
+	serviceGK := schema.GroupKind{Kind: "Service"}
+	spec := v1alpha1.ResourceGroupSpec{
+		Resources: []v1alpha1.ObjMetadata{
+			{Namespace: "ns1", Name: "deploy1", GroupKind: v1alpha1.GroupKind(deploymentGK)},
+			{Namespace: "ns1", Name: "deploy2", GroupKind: v1alpha1.GroupKind(deploymentGK)},
+			{Namespace: "ns1", Name: "svc", GroupKind: v1alpha1.GroupKind(serviceGK)},
+		},
+	}
+	r := &reconciler{resMap: resourcemap.NewResourceMap()}
+
+	cond := r.watchForbiddenCondition(spec)
+	assert.Equal(t, v1alpha1.FalseConditionStatus, cond.Status)
+	assert.Equal(t, WatchesAllowed, cond.Reason)
+
+	r.resMap.SetForbiddenVerbs(deploymentGK, []string{"list", "watch"})
+	r.resMap.SetForbiddenVerbs(schema.GroupKind{Kind: "Secret"}, []string{"watch"})
+	cond = r.watchForbiddenCondition(spec)
+	assert.Equal(t, v1alpha1.TrueConditionStatus, cond.Status)
+	assert.Equal(t, WatchesForbidden, cond.Reason)
+	assert.Equal(t, "the controller is not allowed to watch 1 kinds of resources, whose statuses are not updated when they change: "+
+		"Deployment.apps (list, watch)", cond.Message)
+
+	r.resMap.SetForbiddenVerbs(deploymentGK, nil)
+	cond = r.watchForbiddenCondition(spec)
+	assert.Equal(t, v1alpha1.FalseConditionStatus, cond.Status)
+}
@@ -0,0 +1,56 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroup
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+)
+
+// FieldManager is the field manager used by the ResourceGroup controller
+// to apply the status of ResourceGroup objects.
+const FieldManager = "resourcegroup-controller"
+
+// statusApplyConfiguration returns the object to server-side apply for setting
+// the status of resgroup to newStatus.
+//
+// The object only includes the fields owned by the ResourceGroup controller:
+// the observedGeneration and the conditions of the group, and the status,
+// sourceHash and conditions of each resource and subgroup. The strategy,
+// actuation and reconcile fields of the resources are owned by the applier
+// (kpt or Config Sync) and are left out, so that they are never overwritten
+// by the controller.
+func statusApplyConfiguration(resgroup *v1alpha1.ResourceGroup, newStatus v1alpha1.ResourceGroupStatus) (*unstructured.Unstructured, error) {
+	status := newStatus.DeepCopy()
+	for i := range status.ResourceStatuses {
+		status.ResourceStatuses[i].Strategy = ""
+		status.ResourceStatuses[i].Actuation = ""
+		status.ResourceStatuses[i].Reconcile = ""
+	}
+	statusObj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(status)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": statusObj,
+	}}
+	u.SetGroupVersionKind(v1alpha1.SchemeGroupVersion.WithKind(v1alpha1.ResourceGroupKind))
+	u.SetNamespace(resgroup.Namespace)
+	u.SetName(resgroup.Name)
+	return u, nil
+}
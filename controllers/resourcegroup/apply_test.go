@@ -0,0 +1,112 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+)
+
+func TestStatusApplyConfiguration(t *testing.T) {
+	resgroup := &v1alpha1.ResourceGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "group", Namespace: "ns1", Generation: 2},
+		Spec: v1alpha1.ResourceGroupSpec{
+			Descriptor: v1alpha1.Descriptor{Revision: "v1"},
+		},
+	}
+	newStatus := v1alpha1.ResourceGroupStatus{
+		ObservedGeneration: 2,
+		ResourceStatuses: []v1alpha1.ResourceStatus{
+			{
+				ObjMetadata: v1alpha1.ObjMetadata{
+					Name:      "res1",
+					Namespace: "ns1",
+					GroupKind: v1alpha1.GroupKind{Group: "apps", Kind: "Deployment"},
+				},
+				Status:     v1alpha1.Current,
+				SourceHash: "1234567",
+				Strategy:   v1alpha1.Apply,
+				Actuation:  v1alpha1.ActuationSucceeded,
+				Reconcile:  v1alpha1.ReconcileSucceeded,
+			},
+			{
+				ObjMetadata: v1alpha1.ObjMetadata{
+					Name:      "res2",
+					GroupKind: v1alpha1.GroupKind{Group: "", Kind: "Namespace"},
+				},
+				Status: v1alpha1.InProgress,
+			},
+		},
+		SubgroupStatuses: []v1alpha1.GroupStatus{
+			{
+				GroupMetadata: v1alpha1.GroupMetadata{Name: "subgroup", Namespace: "ns1"},
+				Status:        v1alpha1.Current,
+			},
+		},
+		Conditions: []v1alpha1.Condition{
+			{Type: v1alpha1.Reconciling, Status: v1alpha1.FalseConditionStatus, Reason: FinishReconciling},
+		},
+	}
+
+	u, err := statusApplyConfiguration(resgroup, newStatus)
+	assert.NoError(t, err)
+	assert.Equal(t, v1alpha1.SchemeGroupVersion.WithKind(v1alpha1.ResourceGroupKind), u.GroupVersionKind())
+	assert.Equal(t, "ns1", u.GetNamespace())
+	assert.Equal(t, "group", u.GetName())
+
+	// The spec is not applied.
+	_, found, err := unstructured.NestedFieldNoCopy(u.Object, "spec")
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	// The fields owned by the applier are not applied.
+	resourceStatuses, found, err := unstructured.NestedSlice(u.Object, "status", "resourceStatuses")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, map[string]interface{}{
+		"group":      "apps",
+		"kind":       "Deployment",
+		"namespace":  "ns1",
+		"name":       "res1",
+		"status":     string(v1alpha1.Current),
+		"sourceHash": "1234567",
+	}, resourceStatuses[0])
+	// The list map keys are always set, even if they are empty.
+	assert.Equal(t, map[string]interface{}{
+		"group":     "",
+		"kind":      "Namespace",
+		"namespace": "",
+		"name":      "res2",
+		"status":    string(v1alpha1.InProgress),
+	}, resourceStatuses[1])
+
+	// The other fields round trip.
+	got := &v1alpha1.ResourceGroup{}
+	assert.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, got))
+	expected := newStatus.DeepCopy()
+	expected.ResourceStatuses[0].Strategy = ""
+	expected.ResourceStatuses[0].Actuation = ""
+	expected.ResourceStatuses[0].Reconcile = ""
+	assert.Equal(t, *expected, got.Status)
+
+	// The status to apply is not modified.
+	assert.Equal(t, v1alpha1.ActuationSucceeded, newStatus.ResourceStatuses[0].Actuation)
+}
@@ -20,6 +20,7 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	controllerstatus "kpt.dev/resourcegroup/controllers/status"
 )
 
 func newReconcilingCondition(status v1alpha1.ConditionStatus, reason, message string) v1alpha1.Condition {
@@ -47,12 +48,15 @@ func newStalledCondition(status v1alpha1.ConditionStatus, reason, message string
 // the second condition in the slice is Stalled;
 // the remaining conditions are sorted alphabetically according their types.
 //
+// The LastTransitionTime of the existing conditions is preserved for the
+// conditions whose status did not change.
+//
 // Returns:
 //   - a new slice of conditions including the ordered conditions.
 //
 // The +kubebuilder:printcolumn markers on the ResourceGroup struct expect the type of the first
 // Condition in the slice to be Reconciling, and the type of the second Condition to be Stalled.
-func adjustConditionOrder(conditions []v1alpha1.Condition) []v1alpha1.Condition {
+func adjustConditionOrder(conditions, existing []v1alpha1.Condition) []v1alpha1.Condition {
 	var reconciling, stalled v1alpha1.Condition
 	var others []v1alpha1.Condition
 	for _, cond := range conditions {
@@ -81,5 +85,5 @@ func adjustConditionOrder(conditions []v1alpha1.Condition) []v1alpha1.Condition
 	var result []v1alpha1.Condition
 	result = append(result, reconciling, stalled)
 	result = append(result, others...)
-	return result
+	return controllerstatus.MergeConditions(existing, result)
 }
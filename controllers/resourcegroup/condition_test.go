@@ -17,8 +17,10 @@ package resourcegroup
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
 )
 
@@ -57,7 +59,7 @@ func TestAdjustConditionOrder(t *testing.T) {
 	}
 	for name, tc := range tests {
 		t.Run(fmt.Sprintf("adjustConditionOrder %s", name), func(t *testing.T) {
-			gotConditions := adjustConditionOrder(tc.conditions)
+			gotConditions := adjustConditionOrder(tc.conditions, nil)
 			assert.Equal(t, len(tc.expectedConditions), len(gotConditions))
 			for i := range gotConditions {
 				assert.Equal(t, tc.expectedConditions[i].Type, gotConditions[i].Type)
@@ -67,6 +69,27 @@ func TestAdjustConditionOrder(t *testing.T) {
 	}
 }
 
+func TestAdjustConditionOrderPreservesTransitionTime(t *testing.T) {
+	before := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	now := metav1.NewTime(time.Now().Truncate(time.Second))
+	existing := []v1alpha1.Condition{
+		{Type: v1alpha1.Reconciling, Status: v1alpha1.FalseConditionStatus, LastTransitionTime: before},
+		{Type: v1alpha1.Stalled, Status: v1alpha1.FalseConditionStatus, LastTransitionTime: before},
+	}
+	conditions := []v1alpha1.Condition{
+		{Type: v1alpha1.Stalled, Status: v1alpha1.TrueConditionStatus, LastTransitionTime: now},
+		{Type: v1alpha1.Reconciling, Status: v1alpha1.FalseConditionStatus, LastTransitionTime: now},
+	}
+	got := adjustConditionOrder(conditions, existing)
+	assert.Equal(t, 2, len(got))
+	// The status of the Reconciling condition did not change.
+	assert.Equal(t, v1alpha1.Reconciling, got[0].Type)
+	assert.Equal(t, before, got[0].LastTransitionTime)
+	// The status of the Stalled condition changed.
+	assert.Equal(t, v1alpha1.Stalled, got[1].Type)
+	assert.Equal(t, now, got[1].LastTransitionTime)
+}
+
 func TestOwnershipCondition(t *testing.T) {
 	tests := map[string]struct {
 		id                string
@@ -104,7 +127,7 @@ func TestOwnershipCondition(t *testing.T) {
 	}
 	for name, tc := range tests {
 		t.Run(fmt.Sprintf("ownershipCondition %s", name), func(t *testing.T) {
-			c := ownershipCondition(tc.id, tc.inv)
+			c := ownershipCondition(tc.id, tc.inv, nil)
 			if tc.expectedCondition == nil {
 				assert.Nil(t, c)
 			} else {
@@ -116,3 +139,19 @@ func TestOwnershipCondition(t *testing.T) {
 		})
 	}
 }
+
+func TestOwnershipConditionPreservesTransitionTime(t *testing.T) {
+	before := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	existing := []v1alpha1.Condition{
+		{Type: v1alpha1.Ownership, Status: v1alpha1.TrueConditionStatus, LastTransitionTime: before},
+	}
+
+	c := ownershipCondition("id", "unmatched", existing)
+	assert.NotNil(t, c)
+	assert.Equal(t, before, c.LastTransitionTime)
+
+	c = ownershipCondition("id", "", existing)
+	assert.NotNil(t, c)
+	assert.Equal(t, v1alpha1.UnknownConditionStatus, c.Status)
+	assert.NotEqual(t, before, c.LastTransitionTime)
+}
@@ -0,0 +1,111 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroup
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/pkg/groupstatus"
+)
+
+//nolint:revive // TODO: add comments for public constants and enable linting
+const (
+	ProgressDeadlineExceeded          = "ProgressDeadlineExceeded"
+	progressDeadlineExceededMsgPrefix = "The following components exceeded their progress deadline:"
+	anyKind                           = "*"
+)
+
+// ParseProgressDeadlines parses a comma-separated list of Kind.group=duration
+// pairs, e.g. "Deployment.apps=30m,*=2h". The kinds of the core group have no
+// group suffix, and * sets the deadline of all the other kinds.
+func ParseProgressDeadlines(s string) (map[schema.GroupKind]time.Duration, error) {
+	deadlines := make(map[schema.GroupKind]time.Duration)
+	for _, value := range strings.Split(s, ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		kind, duration, found := strings.Cut(value, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid progress deadline %q: must be in the Kind.group=duration format", value)
+		}
+		var gk schema.GroupKind
+		if kind = strings.TrimSpace(kind); kind != anyKind {
+			gk = schema.ParseGroupKind(kind)
+			if gk.Kind == "" {
+				return nil, fmt.Errorf("invalid progress deadline %q: must be in the Kind.group=duration format", value)
+			}
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(duration))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid progress deadline %q: the duration must be positive", value)
+		}
+		deadlines[gk] = d
+	}
+	return deadlines, nil
+}
+
+// progressDeadline returns the progress deadline of a GroupKind, or 0 if its
+// resources have no deadline.
+func (r *reconciler) progressDeadline(gk schema.GroupKind) time.Duration {
+	if d, found := r.opts.ProgressDeadlines[gk]; found {
+		return d
+	}
+	return r.opts.ProgressDeadlines[schema.GroupKind{}]
+}
+
+// progressDeadlines returns the resources among the given statuses which are
+// InProgress for longer than the progress deadline of their kind, and the
+// duration until the next deadline of the other InProgress resources, or 0 if
+// none of them has a deadline. The time a resource entered the InProgress
+// status is read from its cached status, which is seeded from the
+// LastTransitionTime of the Reconciling condition of the resource, so that the
+// deadline holds across restarts of the controller.
+func (r *reconciler) progressDeadlines(statuses []v1alpha1.ResourceStatus, now time.Time) ([]string, time.Duration) {
+	var exceeded []string
+	var next time.Duration
+	for _, status := range statuses {
+		if status.Status != v1alpha1.InProgress {
+			continue
+		}
+		deadline := r.progressDeadline(schema.GroupKind(status.GroupKind))
+		if deadline == 0 {
+			continue
+		}
+		var reconcilingSince time.Time
+		if cond, found := groupstatus.GetCondition(status.Conditions, v1alpha1.Reconciling); found && cond.Status == v1alpha1.TrueConditionStatus {
+			reconcilingSince = cond.LastTransitionTime.Time
+		}
+		since := r.resMap.SeedInProgressSince(status.ObjMetadata, reconcilingSince)
+		if since.IsZero() {
+			continue
+		}
+		remaining := deadline - now.Sub(since)
+		if remaining <= 0 {
+			res := status.ObjMetadata
+			exceeded = append(exceeded, fmt.Sprintf("%s/%s/%s/%s", res.Group, res.Kind, res.Namespace, res.Name))
+			continue
+		}
+		if next == 0 || remaining < next {
+			next = remaining
+		}
+	}
+	return exceeded, next
+}
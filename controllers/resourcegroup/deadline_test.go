@@ -0,0 +1,135 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/controllers/resourcemap"
+)
+
+func TestParseProgressDeadlines(t *testing.T) {
+	deadlines, err := ParseProgressDeadlines("Deployment.apps=30m, ConfigMap=1m,*=2h")
+	assert.NoError(t, err)
+	assert.Equal(t, map[schema.GroupKind]time.Duration{
+		{Group: "apps", Kind: "Deployment"}: 30 * time.Minute,
+		{Kind: "ConfigMap"}:                 time.Minute,
+		{}:                                  2 * time.Hour,
+	}, deadlines)
+
+	deadlines, err = ParseProgressDeadlines("")
+	assert.NoError(t, err)
+	assert.Empty(t, deadlines)
+
+	for _, value := range []string{"Deployment.apps", "=30m", "Deployment.apps=soon", "Deployment.apps=-1m"} {
+		_, err := ParseProgressDeadlines(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestProgressDeadlines(t *testing.T) {
+	deploymentGK := v1alpha1.GroupKind{Group: "apps", Kind: "Deployment"}
+	statefulSetGK := v1alpha1.GroupKind{Group: "apps", Kind: "StatefulSet"}
+	stuck := v1alpha1.ObjMetadata{Namespace: "ns1", Name: "stuck", GroupKind: deploymentGK}
+	progressing := v1alpha1.ObjMetadata{Namespace: "ns1", Name: "progressing", GroupKind: deploymentGK}
+	noDeadline := v1alpha1.ObjMetadata{Namespace: "ns1", Name: "db", GroupKind: statefulSetGK}
+	current := v1alpha1.ObjMetadata{Namespace: "ns1", Name: "current", GroupKind: deploymentGK}
+
+	now := time.Now()
+	resMap := resourcemap.NewResourceMap()
+	resMap.Reconcile(context.TODO(), types.NamespacedName{Namespace: "ns1", Name: "group"}, []v1alpha1.ObjMetadata{stuck, progressing, noDeadline, current}, false)
+	resMap.SetStatus(stuck, &resourcemap.CachedStatus{Status: v1alpha1.InProgress, InProgressSince: now.Add(-time.Hour)})
+	resMap.SetStatus(progressing, &resourcemap.CachedStatus{Status: v1alpha1.InProgress, InProgressSince: now.Add(-20 * time.Minute)})
+	resMap.SetStatus(noDeadline, &resourcemap.CachedStatus{Status: v1alpha1.InProgress, InProgressSince: now.Add(-time.Hour)})
+	resMap.SetStatus(current, &resourcemap.CachedStatus{Status: v1alpha1.Current})
+	r := &reconciler{resMap: resMap}
+	statuses := []v1alpha1.ResourceStatus{
+		{ObjMetadata: stuck, Status: v1alpha1.InProgress},
+		{ObjMetadata: progressing, Status: v1alpha1.InProgress},
+		{ObjMetadata: noDeadline, Status: v1alpha1.InProgress},
+		{ObjMetadata: current, Status: v1alpha1.Current},
+	}
+
+	r.opts.ProgressDeadlines = map[schema.GroupKind]time.Duration{}
+	exceeded, next := r.progressDeadlines(statuses, now)
+	assert.Empty(t, exceeded)
+	assert.Zero(t, next)
+
+	r.opts.ProgressDeadlines = map[schema.GroupKind]time.Duration{schema.GroupKind(deploymentGK): 30 * time.Minute}
+	exceeded, next = r.progressDeadlines(statuses, now)
+	assert.Equal(t, []string{"apps/Deployment/ns1/stuck"}, exceeded)
+	assert.Equal(t, 10*time.Minute, next)
+
+	// The default deadline applies to the kinds without their own deadline.
+	r.opts.ProgressDeadlines[schema.GroupKind{}] = 2 * time.Hour
+	exceeded, next = r.progressDeadlines(statuses, now)
+	assert.Equal(t, []string{"apps/Deployment/ns1/stuck"}, exceeded)
+	assert.Equal(t, 10*time.Minute, next)
+
+	cond := aggregateResourceStatuses(statuses, exceeded)
+	assert.Equal(t, v1alpha1.TrueConditionStatus, cond.Status)
+	assert.Equal(t, ProgressDeadlineExceeded, cond.Reason)
+}
+
+func TestProgressDeadlinesAfterRestart(t *testing.T) {
+	deploymentGK := v1alpha1.GroupKind{Group: "apps", Kind: "Deployment"}
+	stuck := v1alpha1.ObjMetadata{Namespace: "ns1", Name: "stuck", GroupKind: deploymentGK}
+	group := types.NamespacedName{Namespace: "ns1", Name: "group"}
+	now := time.Now()
+	// The status of the group was computed before the restart, when the
+	// Deployment was InProgress for an hour already.
+	statuses := []v1alpha1.ResourceStatus{{
+		ObjMetadata: stuck,
+		Status:      v1alpha1.InProgress,
+		Conditions: []v1alpha1.Condition{{
+			Type:               v1alpha1.Reconciling,
+			Status:             v1alpha1.TrueConditionStatus,
+			LastTransitionTime: metav1.NewTime(now.Add(-time.Hour)),
+		}},
+	}}
+
+	// The rebuilt resource map observes the Deployment InProgress from now on.
+	resMap := resourcemap.NewResourceMap()
+	resMap.Reconcile(context.TODO(), group, []v1alpha1.ObjMetadata{stuck}, false)
+	resMap.SetStatus(stuck, &resourcemap.CachedStatus{Status: v1alpha1.InProgress})
+	r := &reconciler{resMap: resMap}
+	r.opts.ProgressDeadlines = map[schema.GroupKind]time.Duration{schema.GroupKind(deploymentGK): 30 * time.Minute}
+
+	exceeded, _ := r.progressDeadlines(statuses, now)
+	assert.Equal(t, []string{"apps/Deployment/ns1/stuck"}, exceeded)
+	assert.True(t, resMap.GetStatus(stuck).InProgressSince.Equal(now.Add(-time.Hour)))
+
+	// The seeded time is kept once the condition is no longer available.
+	statuses[0].Conditions = nil
+	exceeded, _ = r.progressDeadlines(statuses, now)
+	assert.Equal(t, []string{"apps/Deployment/ns1/stuck"}, exceeded)
+
+	// A transition time later than the cached one does not postpone the deadline.
+	statuses[0].Conditions = []v1alpha1.Condition{{
+		Type:               v1alpha1.Reconciling,
+		Status:             v1alpha1.TrueConditionStatus,
+		LastTransitionTime: metav1.NewTime(now),
+	}}
+	exceeded, _ = r.progressDeadlines(statuses, now)
+	assert.Equal(t, []string{"apps/Deployment/ns1/stuck"}, exceeded)
+}
@@ -0,0 +1,130 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroup
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	controllerstatus "kpt.dev/resourcegroup/controllers/status"
+	"kpt.dev/resourcegroup/pkg/groupstatus"
+)
+
+//nolint:revive // TODO: add comments for public constants and enable linting
+const (
+	DependencyNotReady   = "DependencyNotReady"
+	DependenciesReady    = "DependenciesReady"
+	dependenciesReadyMsg = "no resource is blocked on a dependency"
+)
+
+func newBlockedCondition(status v1alpha1.ConditionStatus, reason, message string) v1alpha1.Condition {
+	return v1alpha1.Condition{
+		Type:               v1alpha1.Blocked,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: metav1.Time{Time: time.Now().UTC()},
+	}
+}
+
+// formatResource returns the string identifying a resource in condition messages.
+func formatResource(res v1alpha1.ObjMetadata) string {
+	return fmt.Sprintf("%s/%s/%s/%s", res.Group, res.Kind, res.Namespace, res.Name)
+}
+
+// dependencyConditions sets the Blocked condition of the resources which depend
+// on resources that are not Current, according to the dependencies cached
+// from their depends-on annotation, and returns the new resource statuses
+// together with the Blocked condition of the group, which lists the blocking
+// edges of the dependency graph.
+//
+// The status of a dependency is taken from statuses if it is in the group, or
+// from the cache otherwise. A dependency whose status is unknown to the
+// controller is not considered blocking.
+//
+// The LastTransitionTime of the Blocked conditions in the previous resource
+// statuses is preserved. statuses is not modified.
+func (r *reconciler) dependencyConditions(statuses, previous []v1alpha1.ResourceStatus) ([]v1alpha1.ResourceStatus, v1alpha1.Condition) {
+	statusByRes := make(map[v1alpha1.ObjMetadata]v1alpha1.Status, len(statuses))
+	for _, res := range statuses {
+		statusByRes[res.ObjMetadata] = res.Status
+	}
+	previousBlocked := make(map[v1alpha1.ObjMetadata]v1alpha1.Condition)
+	for _, res := range previous {
+		if cond, found := groupstatus.GetCondition(res.Conditions, v1alpha1.Blocked); found {
+			previousBlocked[res.ObjMetadata] = cond
+		}
+	}
+
+	result := make([]v1alpha1.ResourceStatus, len(statuses))
+	var edges []string
+	for i, res := range statuses {
+		var blocking []string
+		if cached := r.resMap.GetStatus(res.ObjMetadata); cached != nil {
+			for _, dep := range cached.Dependencies {
+				depStatus, found := statusByRes[dep]
+				if !found {
+					depCached := r.resMap.GetStatus(dep)
+					if depCached == nil {
+						continue
+					}
+					depStatus = depCached.Status
+				}
+				if depStatus != v1alpha1.Current {
+					blocking = append(blocking, fmt.Sprintf("%s (%s)", formatResource(dep), depStatus))
+					edges = append(edges, fmt.Sprintf("%s -> %s (%s)", formatResource(res.ObjMetadata), formatResource(dep), depStatus))
+				}
+			}
+		}
+		var existing []v1alpha1.Condition
+		if cond, found := previousBlocked[res.ObjMetadata]; found {
+			existing = []v1alpha1.Condition{cond}
+		}
+		result[i] = withBlockedCondition(res, blocking, existing)
+	}
+
+	if len(edges) > 0 {
+		return result, newBlockedCondition(v1alpha1.TrueConditionStatus, DependencyNotReady,
+			fmt.Sprintf("%d dependencies are not Current: %s", len(edges), truncatedList(edges)))
+	}
+	return result, newBlockedCondition(v1alpha1.FalseConditionStatus, DependenciesReady, dependenciesReadyMsg)
+}
+
+// withBlockedCondition returns a copy of res whose Blocked condition reflects
+// the given blocking dependencies. The resource has no Blocked condition if
+// it is not blocked. The LastTransitionTime of the Blocked condition in res or
+// in previous is preserved if the condition did not change.
+func withBlockedCondition(res v1alpha1.ResourceStatus, blocking []string, previous []v1alpha1.Condition) v1alpha1.ResourceStatus {
+	if _, found := groupstatus.GetCondition(res.Conditions, v1alpha1.Blocked); !found && len(blocking) == 0 {
+		return res
+	}
+	existing := append(append([]v1alpha1.Condition{}, res.Conditions...), previous...)
+	var conditions []v1alpha1.Condition
+	for _, cond := range res.Conditions {
+		if cond.Type != v1alpha1.Blocked {
+			conditions = append(conditions, cond)
+		}
+	}
+	if len(blocking) > 0 {
+		cond := newBlockedCondition(v1alpha1.TrueConditionStatus, DependencyNotReady,
+			"The resource depends on resources which are not Current: "+truncatedList(blocking))
+		conditions = append(conditions, controllerstatus.MergeConditions(existing, []v1alpha1.Condition{cond})...)
+	}
+	res.Conditions = conditions
+	return res
+}
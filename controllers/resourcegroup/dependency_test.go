@@ -0,0 +1,87 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/controllers/resourcemap"
+)
+
+func TestDependencyConditions(t *testing.T) {
+	crd := v1alpha1.ObjMetadata{Name: "crontabs.stable.example.com", GroupKind: v1alpha1.GroupKind{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}}
+	ns := v1alpha1.ObjMetadata{Name: "ns1", GroupKind: v1alpha1.GroupKind{Kind: "Namespace"}}
+	cr := v1alpha1.ObjMetadata{Name: "cron", Namespace: "ns1", GroupKind: v1alpha1.GroupKind{Group: "stable.example.com", Kind: "CronTab"}}
+	external := v1alpha1.ObjMetadata{Name: "external", Namespace: "ns2", GroupKind: v1alpha1.GroupKind{Kind: "ConfigMap"}}
+	unknown := v1alpha1.ObjMetadata{Name: "unknown", Namespace: "ns2", GroupKind: v1alpha1.GroupKind{Kind: "ConfigMap"}}
+
+	resMap := resourcemap.NewResourceMap()
+	resMap.Reconcile(context.TODO(), types.NamespacedName{Namespace: "ns1", Name: "group"}, []v1alpha1.ObjMetadata{crd, ns, cr}, false)
+	resMap.Reconcile(context.TODO(), types.NamespacedName{Namespace: "ns2", Name: "other"}, []v1alpha1.ObjMetadata{external}, false)
+	resMap.SetStatus(crd, &resourcemap.CachedStatus{Status: v1alpha1.Current})
+	resMap.SetStatus(ns, &resourcemap.CachedStatus{Status: v1alpha1.InProgress})
+	resMap.SetStatus(external, &resourcemap.CachedStatus{Status: v1alpha1.InProgress})
+	resMap.SetStatus(cr, &resourcemap.CachedStatus{
+		Status:       v1alpha1.InProgress,
+		Dependencies: []v1alpha1.ObjMetadata{crd, ns, external, unknown},
+	})
+	r := &reconciler{resMap: resMap}
+
+	ownership := v1alpha1.Condition{Type: v1alpha1.Ownership, Status: v1alpha1.TrueConditionStatus}
+	statuses := []v1alpha1.ResourceStatus{
+		{ObjMetadata: crd, Status: v1alpha1.Current},
+		{ObjMetadata: ns, Status: v1alpha1.InProgress},
+		{ObjMetadata: cr, Status: v1alpha1.InProgress, Conditions: []v1alpha1.Condition{ownership}},
+	}
+	got, blocked := r.dependencyConditions(statuses, nil)
+	assert.Equal(t, v1alpha1.Blocked, blocked.Type)
+	assert.Equal(t, v1alpha1.TrueConditionStatus, blocked.Status)
+	assert.Equal(t, DependencyNotReady, blocked.Reason)
+	assert.Equal(t, "2 dependencies are not Current: "+
+		"stable.example.com/CronTab/ns1/cron -> /Namespace//ns1 (InProgress), "+
+		"stable.example.com/CronTab/ns1/cron -> /ConfigMap/ns2/external (InProgress)", blocked.Message)
+
+	assert.Equal(t, statuses[:2], got[:2])
+	assert.Len(t, got[2].Conditions, 2)
+	assert.Equal(t, ownership, got[2].Conditions[0])
+	assert.Equal(t, v1alpha1.Blocked, got[2].Conditions[1].Type)
+	assert.Equal(t, v1alpha1.TrueConditionStatus, got[2].Conditions[1].Status)
+	assert.Contains(t, got[2].Conditions[1].Message, "/Namespace//ns1 (InProgress)")
+	// The input statuses are not modified.
+	assert.Len(t, statuses[2].Conditions, 1)
+
+	// The LastTransitionTime of the previous Blocked condition is preserved.
+	before := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	previous := []v1alpha1.ResourceStatus{got[0], got[1], got[2]}
+	previous[2].Conditions = []v1alpha1.Condition{ownership, got[2].Conditions[1]}
+	previous[2].Conditions[1].LastTransitionTime = before
+	again, _ := r.dependencyConditions(statuses, previous)
+	assert.Equal(t, before, again[2].Conditions[1].LastTransitionTime)
+
+	// The Blocked condition is removed once the dependencies are Current.
+	got[1].Status = v1alpha1.Current
+	resMap.SetStatus(external, &resourcemap.CachedStatus{Status: v1alpha1.Current})
+	got, blocked = r.dependencyConditions(got, nil)
+	assert.Equal(t, v1alpha1.FalseConditionStatus, blocked.Status)
+	assert.Equal(t, DependenciesReady, blocked.Reason)
+	assert.Equal(t, []v1alpha1.Condition{ownership}, got[2].Conditions)
+}
@@ -0,0 +1,110 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroup
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	controllerstatus "kpt.dev/resourcegroup/controllers/status"
+)
+
+//nolint:revive // TODO: add comments for public constants and enable linting
+const (
+	ResourcesDrifted   = "ResourcesDrifted"
+	ResourcesInSync    = "ResourcesInSync"
+	resourcesInSyncMsg = "all the resources were applied from the revision of the group"
+	RevisionUnknown    = "RevisionUnknown"
+	revisionUnknownMsg = "the group does not have a revision"
+)
+
+func newDriftedCondition(status v1alpha1.ConditionStatus, reason, message string) v1alpha1.Condition {
+	return v1alpha1.Condition{
+		Type:               v1alpha1.Drifted,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: metav1.Time{Time: time.Now().UTC()},
+	}
+}
+
+// matchesRevision checks whether the source hash of a resource, which is
+// truncated by status.GetSourceHash, matches the revision of the group
+// truncated to the same length.
+func matchesRevision(hash, revision string) bool {
+	if len(revision) > controllerstatus.SourceHashLength {
+		revision = revision[:controllerstatus.SourceHashLength]
+	}
+	return strings.EqualFold(hash, revision)
+}
+
+// driftCondition returns the Drifted condition of a group with the given
+// revision whose resources have the given statuses, and the counts of the
+// drifted resources, from which the message of the condition is built. The
+// counts are nil when the group does not have a revision.
+//
+// A resource drifted when it was applied from a different revision than the
+// revision of the group, or when hashRemoved reports that its source hash
+// annotation was removed, e.g. by an out-of-band edit. The resources which
+// never had a source hash or do not exist are not drifted.
+// The condition is Unknown when the group does not have a revision.
+func driftCondition(revision string, statuses []v1alpha1.ResourceStatus, hashRemoved func(v1alpha1.ObjMetadata) bool) (v1alpha1.Condition, *v1alpha1.DriftCounts) {
+	if revision == "" {
+		return newDriftedCondition(v1alpha1.UnknownConditionStatus, RevisionUnknown, revisionUnknownMsg), nil
+	}
+
+	var otherRevision, missingHash []string
+	for _, status := range statuses {
+		if status.Status == v1alpha1.NotFound {
+			continue
+		}
+		switch {
+		case status.SourceHash == "":
+			if hashRemoved(status.ObjMetadata) {
+				missingHash = append(missingHash, formatResource(status.ObjMetadata))
+			}
+		case !matchesRevision(status.SourceHash, revision):
+			otherRevision = append(otherRevision, fmt.Sprintf("%s (%s)", formatResource(status.ObjMetadata), status.SourceHash))
+		}
+	}
+
+	counts := &v1alpha1.DriftCounts{
+		OtherRevision:     len(otherRevision),
+		SourceHashRemoved: len(missingHash),
+	}
+	if counts.Drifted() == 0 {
+		return newDriftedCondition(v1alpha1.FalseConditionStatus, ResourcesInSync, resourcesInSyncMsg), counts
+	}
+	var details []string
+	if counts.OtherRevision > 0 {
+		details = append(details, fmt.Sprintf("%d applied from another revision: %s", counts.OtherRevision, truncatedList(otherRevision)))
+	}
+	if counts.SourceHashRemoved > 0 {
+		details = append(details, fmt.Sprintf("%d whose source hash was removed: %s", counts.SourceHashRemoved, truncatedList(missingHash)))
+	}
+	return newDriftedCondition(v1alpha1.TrueConditionStatus, ResourcesDrifted,
+		fmt.Sprintf("%d resources drifted from revision %s; %s", counts.Drifted(), revision, strings.Join(details, "; "))), counts
+}
+
+// sourceHashRemoved returns true if the source hash annotation of the cached
+// status of res was removed.
+func (r *reconciler) sourceHashRemoved(res v1alpha1.ObjMetadata) bool {
+	cached := r.resMap.GetStatus(res)
+	return cached != nil && cached.SourceHashRemoved
+}
@@ -0,0 +1,133 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroup
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+)
+
+func driftTestStatuses(hashes ...string) []v1alpha1.ResourceStatus {
+	result := make([]v1alpha1.ResourceStatus, len(hashes))
+	for i, hash := range hashes {
+		result[i] = v1alpha1.ResourceStatus{
+			ObjMetadata: v1alpha1.ObjMetadata{
+				Name:      fmt.Sprintf("cm%d", i),
+				Namespace: "ns1",
+				GroupKind: v1alpha1.GroupKind{Kind: "ConfigMap"},
+			},
+			Status:     v1alpha1.Current,
+			SourceHash: hash,
+		}
+	}
+	return result
+}
+
+func TestDriftCondition(t *testing.T) {
+	const revision = "1234567890abcdef"
+	tests := map[string]struct {
+		revision        string
+		statuses        []v1alpha1.ResourceStatus
+		removed         string
+		expectedStatus  v1alpha1.ConditionStatus
+		expectedReason  string
+		expectedCounts  *v1alpha1.DriftCounts
+		expectedMessage string
+	}{
+		"should be unknown without a revision": {
+			statuses:       driftTestStatuses("1234567"),
+			expectedStatus: v1alpha1.UnknownConditionStatus,
+			expectedReason: RevisionUnknown,
+		},
+		"should not be drifted when the source hashes match the revision": {
+			revision:       revision,
+			statuses:       driftTestStatuses("1234567", "1234567"),
+			expectedStatus: v1alpha1.FalseConditionStatus,
+			expectedReason: ResourcesInSync,
+			expectedCounts: &v1alpha1.DriftCounts{},
+		},
+		"should be drifted with a resource applied from another revision": {
+			revision:        revision,
+			statuses:        driftTestStatuses("1234567", "abcdef0"),
+			expectedStatus:  v1alpha1.TrueConditionStatus,
+			expectedReason:  ResourcesDrifted,
+			expectedCounts:  &v1alpha1.DriftCounts{OtherRevision: 1},
+			expectedMessage: "1 resources drifted from revision 1234567890abcdef; 1 applied from another revision: /ConfigMap/ns1/cm1 (abcdef0)",
+		},
+		"should be drifted with a resource whose source hash was removed": {
+			revision:       revision,
+			statuses:       driftTestStatuses("", "abcdef0", ""),
+			removed:        "cm0",
+			expectedStatus: v1alpha1.TrueConditionStatus,
+			expectedReason: ResourcesDrifted,
+			expectedCounts: &v1alpha1.DriftCounts{OtherRevision: 1, SourceHashRemoved: 1},
+			expectedMessage: "2 resources drifted from revision 1234567890abcdef; " +
+				"1 applied from another revision: /ConfigMap/ns1/cm1 (abcdef0); " +
+				"1 whose source hash was removed: /ConfigMap/ns1/cm0",
+		},
+		"should not be drifted with a resource which never had a source hash": {
+			revision:       revision,
+			statuses:       driftTestStatuses("", "1234567"),
+			expectedStatus: v1alpha1.FalseConditionStatus,
+			expectedReason: ResourcesInSync,
+			expectedCounts: &v1alpha1.DriftCounts{},
+		},
+		"should match a revision as short as the source hash": {
+			revision:       "v1",
+			statuses:       driftTestStatuses("v1"),
+			expectedStatus: v1alpha1.FalseConditionStatus,
+			expectedReason: ResourcesInSync,
+			expectedCounts: &v1alpha1.DriftCounts{},
+		},
+		"should not match a revision which is a prefix of the source hash": {
+			revision:       "v1",
+			statuses:       driftTestStatuses("v1abcde"),
+			expectedStatus: v1alpha1.TrueConditionStatus,
+			expectedReason: ResourcesDrifted,
+			expectedCounts: &v1alpha1.DriftCounts{OtherRevision: 1},
+		},
+		"should not match a source hash which is a prefix of the revision": {
+			revision:       revision,
+			statuses:       driftTestStatuses("12345"),
+			expectedStatus: v1alpha1.TrueConditionStatus,
+			expectedReason: ResourcesDrifted,
+			expectedCounts: &v1alpha1.DriftCounts{OtherRevision: 1},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(fmt.Sprintf("driftCondition %s", name), func(t *testing.T) {
+			hashRemoved := func(res v1alpha1.ObjMetadata) bool { return res.Name == tc.removed }
+			c, counts := driftCondition(tc.revision, tc.statuses, hashRemoved)
+			assert.Equal(t, v1alpha1.Drifted, c.Type)
+			assert.Equal(t, tc.expectedStatus, c.Status)
+			assert.Equal(t, tc.expectedReason, c.Reason)
+			assert.Equal(t, tc.expectedCounts, counts)
+			if tc.expectedMessage != "" {
+				assert.Equal(t, tc.expectedMessage, c.Message)
+			}
+		})
+	}
+
+	// The resources which do not exist are not drifted.
+	statuses := driftTestStatuses("")
+	statuses[0].Status = v1alpha1.NotFound
+	c, counts := driftCondition(revision, statuses, func(v1alpha1.ObjMetadata) bool { return true })
+	assert.Equal(t, v1alpha1.FalseConditionStatus, c.Status)
+	assert.Equal(t, 0, counts.Drifted())
+}
@@ -0,0 +1,116 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/controllers/resourcemap"
+)
+
+// historyDataKey is the key of the transitions in the data of a history ConfigMap.
+const historyDataKey = "transitions"
+
+// historyName returns the name of the history ConfigMap of a ResourceGroup.
+func historyName(group string) string {
+	return ownedObjectName(group, "-history")
+}
+
+// historyConfigMap returns the ConfigMap to apply for persisting the given
+// transitions of resgroup.
+func historyConfigMap(resgroup *v1alpha1.ResourceGroup, transitions []resourcemap.Transition) (*corev1.ConfigMap, error) {
+	data, err := json.Marshal(transitions)
+	if err != nil {
+		return nil, err
+	}
+	return &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       resgroup.Namespace,
+			Name:            historyName(resgroup.Name),
+			OwnerReferences: []metav1.OwnerReference{ownerReference(resgroup)},
+		},
+		Data: map[string]string{
+			historyDataKey: string(data),
+		},
+	}, nil
+}
+
+// loadHistory merges the transitions persisted in the history ConfigMap of
+// resgroup into the ResourceMap, once per ResourceGroup.
+func (r *reconciler) loadHistory(ctx context.Context, resgroup *v1alpha1.ResourceGroup) error {
+	nn := types.NamespacedName{Namespace: resgroup.Namespace, Name: resgroup.Name}
+	if r.resMap.HistoryLoaded(nn) {
+		return nil
+	}
+	cm := &corev1.ConfigMap{}
+	key := types.NamespacedName{Namespace: resgroup.Namespace, Name: historyName(resgroup.Name)}
+	if err := r.apiReader.Get(ctx, key, cm); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to read the status history %s: %w", key.Name, err)
+		}
+		r.resMap.LoadHistory(nn, nil)
+		return nil
+	}
+	var transitions []resourcemap.Transition
+	if data, ok := cm.Data[historyDataKey]; ok {
+		if err := json.Unmarshal([]byte(data), &transitions); err != nil {
+			// A corrupted history is overwritten rather than blocking the reconciliation.
+			r.log.Error(err, "ignoring the invalid status history", "namespace", key.Namespace, "name", key.Name)
+			transitions = nil
+		}
+	}
+	r.log.V(4).Info("loaded the status history", "namespace", key.Namespace, "name", key.Name, "count", len(transitions))
+	r.resMap.LoadHistory(nn, transitions)
+	return nil
+}
+
+// persistHistory writes the status transitions of resgroup into its history
+// ConfigMap if new transitions were recorded since it was last written.
+func (r *reconciler) persistHistory(ctx context.Context, resgroup *v1alpha1.ResourceGroup) error {
+	if !r.opts.PersistHistory {
+		return nil
+	}
+	if err := r.loadHistory(ctx, resgroup); err != nil {
+		return err
+	}
+	nn := types.NamespacedName{Namespace: resgroup.Namespace, Name: resgroup.Name}
+	transitions, version, pending := r.resMap.UnpersistedHistory(nn)
+	if !pending {
+		return nil
+	}
+	cm, err := historyConfigMap(resgroup, transitions)
+	if err != nil {
+		return err
+	}
+	r.log.V(4).Info("writing the status history", "namespace", cm.Namespace, "name", cm.Name, "count", len(transitions))
+	if err := r.Patch(ctx, cm, client.Apply, client.FieldOwner(FieldManager), client.ForceOwnership); err != nil {
+		return fmt.Errorf("failed to write the status history %s: %w", cm.Name, err)
+	}
+	r.resMap.MarkHistoryPersisted(nn, version)
+	return nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroup
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/controllers/resourcemap"
+)
+
+func TestHistoryConfigMap(t *testing.T) {
+	resgroup := &v1alpha1.ResourceGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "group", Namespace: "ns1", UID: "uid"},
+	}
+	transitions := []resourcemap.Transition{
+		{
+			Resource:  shardTestStatuses(1)[0].ObjMetadata,
+			OldStatus: v1alpha1.Current,
+			NewStatus: v1alpha1.Failed,
+		},
+	}
+	cm, err := historyConfigMap(resgroup, transitions)
+	assert.NoError(t, err)
+	assert.Equal(t, "ns1", cm.Namespace)
+	assert.Equal(t, "group-history", cm.Name)
+	assert.Len(t, cm.OwnerReferences, 1)
+	assert.Equal(t, resgroup.UID, cm.OwnerReferences[0].UID)
+
+	var got []resourcemap.Transition
+	assert.NoError(t, json.Unmarshal([]byte(cm.Data[historyDataKey]), &got))
+	assert.Equal(t, transitions[0].Resource, got[0].Resource)
+	assert.Equal(t, v1alpha1.Failed, got[0].NewStatus)
+}
+
+func TestLoadHistory(t *testing.T) {
+	resgroup := &v1alpha1.ResourceGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "group", Namespace: "ns1", UID: "uid"},
+	}
+	res := shardTestStatuses(1)[0].ObjMetadata
+	persisted := []resourcemap.Transition{
+		{Resource: res, OldStatus: v1alpha1.InProgress, NewStatus: v1alpha1.Current},
+	}
+	cm, err := historyConfigMap(resgroup, persisted)
+	assert.NoError(t, err)
+
+	nn := types.NamespacedName{Namespace: "ns1", Name: "group"}
+	resMap := resourcemap.NewResourceMap()
+	resMap.Reconcile(context.TODO(), nn, []v1alpha1.ObjMetadata{res}, false)
+	resMap.SetStatus(res, &resourcemap.CachedStatus{Status: v1alpha1.Failed})
+	r := &reconciler{
+		apiReader: fake.NewClientBuilder().WithObjects(cm).Build(),
+		log:       logr.Discard(),
+		resMap:    resMap,
+	}
+
+	assert.NoError(t, r.loadHistory(context.TODO(), resgroup))
+	assert.True(t, resMap.HistoryLoaded(nn))
+	history, _, pending := resMap.UnpersistedHistory(nn)
+	assert.True(t, pending)
+	assert.Len(t, history, 2)
+	// The persisted transitions come before the ones recorded since the start.
+	assert.Equal(t, v1alpha1.Current, history[0].NewStatus)
+	assert.Equal(t, v1alpha1.Unknown, history[1].OldStatus)
+	assert.Equal(t, v1alpha1.Failed, history[1].NewStatus)
+
+	// A group without a history ConfigMap is only marked as loaded.
+	other := resgroup.DeepCopy()
+	other.Name = "other"
+	assert.NoError(t, r.loadHistory(context.TODO(), other))
+	assert.True(t, resMap.HistoryLoaded(types.NamespacedName{Namespace: "ns1", Name: "other"}))
+	assert.Empty(t, resMap.History(types.NamespacedName{Namespace: "ns1", Name: "other"}))
+}
@@ -0,0 +1,120 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroup
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/controllers/resourcemap"
+	controllerstatus "kpt.dev/resourcegroup/controllers/status"
+)
+
+// DefaultListThreshold is the default value of Options.ListThreshold.
+const DefaultListThreshold = 20
+
+// listPageSize is the maximum number of objects in a page of a LIST.
+const listPageSize = 500
+
+// listKey identifies the members which are read by the same LIST.
+type listKey struct {
+	gk        schema.GroupKind
+	namespace string
+}
+
+// prefetchStatuses computes and caches the statuses of the members missing
+// from the resource map, with one paginated LIST per kind and namespace with
+// at least Options.ListThreshold missing members. The members which are not listed
+// are cached as NotFound. The statuses of the other missing members are
+// computed with a GET per member by computeResourceStatus.
+func (r *reconciler) prefetchStatuses(ctx context.Context, metas []v1alpha1.ObjMetadata) {
+	if r.opts.ListThreshold <= 0 {
+		return
+	}
+	misses := make(map[listKey]map[v1alpha1.ObjMetadata]bool)
+	for _, res := range metas {
+		if r.resMap.GetStatus(res) != nil {
+			continue
+		}
+		key := listKey{gk: schema.GroupKind(res.GroupKind), namespace: res.Namespace}
+		if misses[key] == nil {
+			misses[key] = make(map[v1alpha1.ObjMetadata]bool)
+		}
+		misses[key][res] = true
+	}
+	for key, members := range misses {
+		if len(members) < r.opts.ListThreshold {
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		gvk, found := r.resolver.Resolve(key.gk)
+		if !found {
+			// The members are reported as NotFound by computeResourceStatus.
+			continue
+		}
+		if err := r.listStatuses(ctx, gvk, key.namespace, members); err != nil {
+			// The statuses of the members which were not listed yet are
+			// computed with a GET per member.
+			r.log.Error(err, "unable to list objects from API server to compute status, falling back to get",
+				"kind", gvk.Kind, "namespace", key.namespace, "members", len(members))
+		}
+	}
+}
+
+// listStatuses lists the objects of the given kind in the namespace page by
+// page, and caches the statuses of the given members. The listed members are
+// removed from members.
+func (r *reconciler) listStatuses(ctx context.Context, gvk schema.GroupVersionKind, namespace string, members map[v1alpha1.ObjMetadata]bool) error {
+	r.log.Info("list the objects from API server to compute status for", "kind", gvk.Kind, "namespace", namespace, "members", len(members))
+	var continueToken string
+	for {
+		opts := []client.ListOption{client.Limit(listPageSize), client.Continue(continueToken)}
+		if namespace != "" {
+			opts = append(opts, client.InNamespace(namespace))
+		}
+		items, next, err := controllerstatus.ListObjects(ctx, r.reader(gvk.GroupKind()), gvk, r.opts.MetadataOnlyKinds[gvk.GroupKind()], opts...)
+		if err != nil {
+			return err
+		}
+		for i := range items {
+			obj := &items[i]
+			res := v1alpha1.ObjMetadata{
+				Namespace: obj.GetNamespace(),
+				Name:      obj.GetName(),
+				GroupKind: v1alpha1.GroupKind(gvk.GroupKind()),
+			}
+			if !members[res] {
+				continue
+			}
+			cachedStatus := controllerstatus.ComputeStatus(obj, nil, r.opts.AnnotationKeys)
+			cachedStatus.ResourceVersion = obj.GetResourceVersion()
+			r.resMap.SetStatus(res, cachedStatus)
+			delete(members, res)
+		}
+		continueToken = next
+		if continueToken == "" {
+			break
+		}
+	}
+	for res := range members {
+		r.resMap.SetStatus(res, &resourcemap.CachedStatus{Status: v1alpha1.NotFound})
+	}
+	return nil
+}
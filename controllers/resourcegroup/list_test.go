@@ -0,0 +1,99 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroup
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/controllers/resourcemap"
+	"kpt.dev/resourcegroup/controllers/typeresolver"
+)
+
+// countingClient counts the GET and LIST requests.
+type countingClient struct {
+	client.Client
+	gets, lists int32
+}
+
+func (c *countingClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	atomic.AddInt32(&c.gets, 1)
+	return c.Client.Get(ctx, key, obj, opts...)
+}
+
+func (c *countingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	atomic.AddInt32(&c.lists, 1)
+	return c.Client.List(ctx, list, opts...)
+}
+
+func TestComputeStatusLists(t *testing.T) {
+	cmGK := schema.GroupKind{Kind: "ConfigMap"}
+	newRes := func(namespace, name string) v1alpha1.ObjMetadata {
+		return v1alpha1.ObjMetadata{Namespace: namespace, Name: name, GroupKind: v1alpha1.GroupKind(cmGK)}
+	}
+	builder := fake.NewClientBuilder()
+	var metas []v1alpha1.ObjMetadata
+	for i := 0; i < DefaultListThreshold; i++ {
+		name := fmt.Sprintf("cm%d", i)
+		builder.WithObjects(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: name}})
+		metas = append(metas, newRes("ns1", name))
+	}
+	builder.WithObjects(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "ns2", Name: "cm"}})
+	// The deleted member is in the namespace which is listed, and the other
+	// member is below the threshold for its namespace.
+	deleted := newRes("ns1", "deleted")
+	other := newRes("ns2", "cm")
+	metas = append(metas, deleted, other)
+
+	c := &countingClient{Client: builder.Build()}
+	r := &reconciler{
+		Client:    c,
+		apiReader: c,
+		log:       logr.Discard(),
+		resolver:  typeresolver.NewFakeTypeResolver(map[schema.GroupKind]schema.GroupVersionKind{cmGK: cmGK.WithVersion("v1")}),
+		resMap:    resourcemap.NewResourceMap(),
+		opts:      DefaultOptions(),
+	}
+	statuses := r.computeStatus(context.TODO(), "", v1alpha1.ResourceGroupStatus{}, metas, types.NamespacedName{}, true)
+	assert.Len(t, statuses, len(metas))
+	for _, s := range statuses[:DefaultListThreshold] {
+		assert.Equal(t, v1alpha1.Current, s.Status)
+	}
+	assert.Equal(t, v1alpha1.NotFound, statuses[DefaultListThreshold].Status)
+	assert.Equal(t, v1alpha1.Current, statuses[DefaultListThreshold+1].Status)
+	assert.Equal(t, int32(1), c.lists)
+	assert.Equal(t, int32(1), c.gets)
+	assert.NotNil(t, r.resMap.GetStatus(deleted))
+
+	// Disabling the LISTs gets every missing member.
+	r.opts.ListThreshold = 0
+	c.gets, c.lists = 0, 0
+	r.resMap = resourcemap.NewResourceMap()
+	r.computeStatus(context.TODO(), "", v1alpha1.ResourceGroupStatus{}, metas, types.NamespacedName{}, true)
+	assert.Equal(t, int32(0), c.lists)
+	assert.Equal(t, int32(len(metas)), c.gets)
+}
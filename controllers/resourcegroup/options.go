@@ -0,0 +1,88 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroup
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	controllerstatus "kpt.dev/resourcegroup/controllers/status"
+)
+
+// Options configures the ResourceGroup controller.
+type Options struct {
+	// StatusShardSize is the maximum number of resource statuses stored in a
+	// ResourceGroup object. The resource statuses of a ResourceGroup with more
+	// resources are stored in ConfigMaps owned by the ResourceGroup, called
+	// status shards, each of which holds at most StatusShardSize resource
+	// statuses. The size should be small enough for a shard to stay below the
+	// 1MiB limit of a ConfigMap. 0 disables the sharding.
+	StatusShardSize int
+
+	// PersistHistory enables persisting the status transitions of the
+	// resources of each ResourceGroup into a ConfigMap owned by the
+	// ResourceGroup, so that the history survives a restart of the controller
+	// and can be read with kubectl. The ConfigMap is read back when the
+	// ResourceGroup is first reconciled.
+	PersistHistory bool
+
+	// ListThreshold is the minimum number of members of the same kind and
+	// namespace missing from the resource map for which their statuses are
+	// computed from a paginated LIST instead of a GET per member.
+	// 0 disables the LISTs.
+	ListThreshold int
+
+	// StatusWorkers is the maximum number of member statuses of a
+	// ResourceGroup computed concurrently. The requests to the API server for
+	// the members missing from the cache are still throttled by the rate
+	// limiter of the client, so more workers than the client burst do not
+	// speed them up. 1 computes the statuses sequentially.
+	StatusWorkers int
+
+	// LeastPrivilegeRole is the name of the least-privilege ClusterRole of the
+	// controller, e.g. generated by `rgctl role`. When it is set, the groups
+	// including resources of kinds which the ClusterRole does not grant access
+	// to are reported with an OutsideRole condition.
+	LeastPrivilegeRole string
+
+	// ProgressDeadlines maps a GroupKind to the maximum duration its resources
+	// may stay InProgress, after which they are treated as failed in the
+	// Stalled condition of their groups. The empty GroupKind holds the
+	// deadline of the kinds without their own deadline. The resources of the
+	// kinds without a deadline may stay InProgress forever.
+	ProgressDeadlines map[schema.GroupKind]time.Duration
+
+	// MetadataOnlyKinds is the set of kinds whose status is fully determined
+	// by their metadata. Only the metadata of their objects is read from the
+	// API server.
+	MetadataOnlyKinds map[schema.GroupKind]bool
+
+	// AnnotationKeys names the annotations read when computing the statuses
+	// of the resources.
+	AnnotationKeys controllerstatus.AnnotationKeys
+}
+
+// DefaultOptions returns the default options of the ResourceGroup controller.
+func DefaultOptions() Options {
+	kinds, _ := controllerstatus.ParseMetadataOnlyKinds(controllerstatus.DefaultMetadataOnlyKinds)
+	return Options{
+		ListThreshold:     DefaultListThreshold,
+		StatusWorkers:     DefaultStatusWorkers,
+		ProgressDeadlines: map[schema.GroupKind]time.Duration{},
+		MetadataOnlyKinds: kinds,
+		AnnotationKeys:    controllerstatus.DefaultAnnotationKeys(),
+	}
+}
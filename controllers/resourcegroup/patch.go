@@ -0,0 +1,181 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroup
+
+import (
+	"encoding/json"
+	"fmt"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+)
+
+// jsonPatchOperation is an operation of a JSON patch (RFC 6902).
+type jsonPatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// statusPatch builds a JSON patch which updates oldStatus to newStatus by only
+// replacing the fields computed by the controller and the entries of
+// .status.resourceStatuses and .status.subgroupStatuses which differ.
+//
+// Each replaced entry is guarded by test operations on its identity, so the
+// patch fails instead of updating the wrong entry if the list was modified
+// concurrently. The actuation, strategy and reconcile fields of the entries are
+// set by cli-utils, and are never included in the patch.
+//
+// The returned patch is nil if no field computed by the controller differs.
+// The second return value is false if the lists in oldStatus and newStatus do
+// not contain the same objects in the same order, the status shards, orphans
+// or cluster statuses differ, or oldStatus is empty. In this case, the whole
+// status needs to be applied.
+func statusPatch(oldStatus, newStatus v1alpha1.ResourceGroupStatus) ([]byte, bool, error) {
+	if apiequality.Semantic.DeepEqual(oldStatus, v1alpha1.ResourceGroupStatus{}) ||
+		len(oldStatus.ResourceStatuses) != len(newStatus.ResourceStatuses) ||
+		len(oldStatus.SubgroupStatuses) != len(newStatus.SubgroupStatuses) ||
+		!apiequality.Semantic.DeepEqual(oldStatus.StatusShards, newStatus.StatusShards) ||
+		!apiequality.Semantic.DeepEqual(oldStatus.Orphans, newStatus.Orphans) ||
+		oldStatus.OrphanCount != newStatus.OrphanCount ||
+		!apiequality.Semantic.DeepEqual(oldStatus.ClusterStatuses, newStatus.ClusterStatuses) {
+		return nil, false, nil
+	}
+
+	var ops []jsonPatchOperation
+	if oldStatus.ObservedGeneration != newStatus.ObservedGeneration {
+		ops = append(ops, jsonPatchOperation{Op: "replace", Path: "/status/observedGeneration", Value: newStatus.ObservedGeneration})
+	}
+	ops = appendConditionsPatch(ops, "/status/conditions", oldStatus.Conditions, newStatus.Conditions)
+	ops = appendTimePatch(ops, "/status/generationChangedTime", oldStatus.GenerationChangedTime, newStatus.GenerationChangedTime)
+	if oldStatus.ReadyGeneration != newStatus.ReadyGeneration {
+		if newStatus.ReadyGeneration == 0 {
+			ops = append(ops, jsonPatchOperation{Op: "remove", Path: "/status/readyGeneration"})
+		} else {
+			ops = append(ops, jsonPatchOperation{Op: "add", Path: "/status/readyGeneration", Value: newStatus.ReadyGeneration})
+		}
+	}
+	ops = appendTimePatch(ops, "/status/lastReadyTime", oldStatus.LastReadyTime, newStatus.LastReadyTime)
+	if !apiequality.Semantic.DeepEqual(oldStatus.DriftCounts, newStatus.DriftCounts) {
+		if newStatus.DriftCounts == nil {
+			ops = append(ops, jsonPatchOperation{Op: "remove", Path: "/status/driftCounts"})
+		} else {
+			ops = append(ops, jsonPatchOperation{Op: "add", Path: "/status/driftCounts", Value: newStatus.DriftCounts})
+		}
+	}
+
+	for i := range newStatus.ResourceStatuses {
+		oldRes, newRes := oldStatus.ResourceStatuses[i], newStatus.ResourceStatuses[i]
+		if oldRes.ObjMetadata != newRes.ObjMetadata {
+			return nil, false, nil
+		}
+		if apiequality.Semantic.DeepEqual(oldRes, newRes) {
+			continue
+		}
+		path := fmt.Sprintf("/status/resourceStatuses/%d", i)
+		var entryOps []jsonPatchOperation
+		if oldRes.Status != newRes.Status {
+			entryOps = append(entryOps, jsonPatchOperation{Op: "replace", Path: path + "/status", Value: newRes.Status})
+		}
+		entryOps = appendStringPatch(entryOps, path+"/sourceHash", oldRes.SourceHash, newRes.SourceHash)
+		entryOps = appendConditionsPatch(entryOps, path+"/conditions", oldRes.Conditions, newRes.Conditions)
+		if len(entryOps) > 0 {
+			ops = append(ops,
+				jsonPatchOperation{Op: "test", Path: path + "/group", Value: newRes.Group},
+				jsonPatchOperation{Op: "test", Path: path + "/kind", Value: newRes.Kind},
+				jsonPatchOperation{Op: "test", Path: path + "/namespace", Value: newRes.Namespace},
+				jsonPatchOperation{Op: "test", Path: path + "/name", Value: newRes.Name},
+			)
+			ops = append(ops, entryOps...)
+		}
+	}
+
+	for i := range newStatus.SubgroupStatuses {
+		oldGroup, newGroup := oldStatus.SubgroupStatuses[i], newStatus.SubgroupStatuses[i]
+		if oldGroup.GroupMetadata != newGroup.GroupMetadata {
+			return nil, false, nil
+		}
+		if apiequality.Semantic.DeepEqual(oldGroup, newGroup) {
+			continue
+		}
+		path := fmt.Sprintf("/status/subgroupStatuses/%d", i)
+		var entryOps []jsonPatchOperation
+		if oldGroup.Status != newGroup.Status {
+			entryOps = append(entryOps, jsonPatchOperation{Op: "replace", Path: path + "/status", Value: newGroup.Status})
+		}
+		entryOps = appendConditionsPatch(entryOps, path+"/conditions", oldGroup.Conditions, newGroup.Conditions)
+		if len(entryOps) > 0 {
+			ops = append(ops,
+				jsonPatchOperation{Op: "test", Path: path + "/namespace", Value: newGroup.Namespace},
+				jsonPatchOperation{Op: "test", Path: path + "/name", Value: newGroup.Name},
+			)
+			ops = append(ops, entryOps...)
+		}
+	}
+
+	if len(ops) == 0 {
+		return nil, true, nil
+	}
+	patch, err := json.Marshal(ops)
+	if err != nil {
+		return nil, false, err
+	}
+	return patch, true, nil
+}
+
+// appendConditionsPatch appends the operation which sets the conditions at path
+// to newConditions. The conditions field is omitted when it is empty.
+func appendConditionsPatch(ops []jsonPatchOperation, path string, oldConditions, newConditions []v1alpha1.Condition) []jsonPatchOperation {
+	switch {
+	case apiequality.Semantic.DeepEqual(oldConditions, newConditions):
+		return ops
+	case len(newConditions) == 0:
+		if len(oldConditions) == 0 {
+			return ops
+		}
+		return append(ops, jsonPatchOperation{Op: "remove", Path: path})
+	default:
+		// The add operation replaces the value if the field exists.
+		return append(ops, jsonPatchOperation{Op: "add", Path: path, Value: newConditions})
+	}
+}
+
+// appendTimePatch appends the operation which sets the time field at path to
+// newValue. The field is omitted when it is nil.
+func appendTimePatch(ops []jsonPatchOperation, path string, oldValue, newValue *metav1.Time) []jsonPatchOperation {
+	switch {
+	case apiequality.Semantic.DeepEqual(oldValue, newValue):
+		return ops
+	case newValue == nil:
+		return append(ops, jsonPatchOperation{Op: "remove", Path: path})
+	default:
+		return append(ops, jsonPatchOperation{Op: "add", Path: path, Value: newValue})
+	}
+}
+
+// appendStringPatch appends the operation which sets the string field at path
+// to newValue. The field is omitted when it is empty.
+func appendStringPatch(ops []jsonPatchOperation, path, oldValue, newValue string) []jsonPatchOperation {
+	switch {
+	case oldValue == newValue:
+		return ops
+	case newValue == "":
+		return append(ops, jsonPatchOperation{Op: "remove", Path: path})
+	default:
+		return append(ops, jsonPatchOperation{Op: "add", Path: path, Value: newValue})
+	}
+}
@@ -0,0 +1,252 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+)
+
+var (
+	patchRes1 = v1alpha1.ObjMetadata{
+		Name:      "res1",
+		Namespace: "ns1",
+		GroupKind: v1alpha1.GroupKind{Group: "apps", Kind: "Deployment"},
+	}
+	patchRes2 = v1alpha1.ObjMetadata{
+		Name:      "res2",
+		GroupKind: v1alpha1.GroupKind{Group: "", Kind: "Namespace"},
+	}
+	patchGroup = v1alpha1.GroupMetadata{Name: "subgroup", Namespace: "ns1"}
+)
+
+func testStatus() v1alpha1.ResourceGroupStatus {
+	return v1alpha1.ResourceGroupStatus{
+		ObservedGeneration: 1,
+		ResourceStatuses: []v1alpha1.ResourceStatus{
+			{
+				ObjMetadata: patchRes1,
+				Status:      v1alpha1.InProgress,
+				SourceHash:  "1234567",
+				Actuation:   v1alpha1.ActuationSucceeded,
+			},
+			{
+				ObjMetadata: patchRes2,
+				Status:      v1alpha1.Current,
+			},
+		},
+		SubgroupStatuses: []v1alpha1.GroupStatus{
+			{
+				GroupMetadata: patchGroup,
+				Status:        v1alpha1.Current,
+			},
+		},
+		Conditions: []v1alpha1.Condition{
+			{Type: v1alpha1.Reconciling, Status: v1alpha1.FalseConditionStatus, Reason: FinishReconciling},
+		},
+	}
+}
+
+func TestStatusPatchFallsBackToUpdate(t *testing.T) {
+	tests := map[string]func(status *v1alpha1.ResourceGroupStatus){
+		"a resource is added": func(status *v1alpha1.ResourceGroupStatus) {
+			status.ResourceStatuses = append(status.ResourceStatuses, v1alpha1.ResourceStatus{ObjMetadata: patchRes1})
+		},
+		"the resources are reordered": func(status *v1alpha1.ResourceGroupStatus) {
+			status.ResourceStatuses[0], status.ResourceStatuses[1] = status.ResourceStatuses[1], status.ResourceStatuses[0]
+		},
+		"a subgroup is removed": func(status *v1alpha1.ResourceGroupStatus) {
+			status.SubgroupStatuses = nil
+		},
+	}
+	for name, mutate := range tests {
+		t.Run(name, func(t *testing.T) {
+			newStatus := testStatus()
+			mutate(&newStatus)
+			_, ok, err := statusPatch(testStatus(), newStatus)
+			assert.NoError(t, err)
+			assert.False(t, ok)
+		})
+	}
+
+	_, ok, err := statusPatch(v1alpha1.ResourceGroupStatus{}, testStatus())
+	assert.NoError(t, err)
+	assert.False(t, ok, "an empty status should be updated")
+}
+
+func TestStatusPatch(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, v1alpha1.AddToScheme(scheme))
+	resgroup := &v1alpha1.ResourceGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "group", Namespace: "ns1", Generation: 2},
+		Status:     testStatus(),
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(resgroup).Build()
+
+	newStatus := testStatus()
+	newStatus.ObservedGeneration = 2
+	newStatus.ResourceStatuses[0].Status = v1alpha1.Current
+	newStatus.ResourceStatuses[0].SourceHash = ""
+	newStatus.ResourceStatuses[1].Conditions = []v1alpha1.Condition{
+		{Type: v1alpha1.Ownership, Status: v1alpha1.UnknownConditionStatus, Reason: v1alpha1.OwnershipEmpty},
+	}
+	newStatus.SubgroupStatuses[0].Status = v1alpha1.NotFound
+
+	patch, ok, err := statusPatch(resgroup.Status, newStatus)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.NotNil(t, patch)
+
+	// The actuation status is updated concurrently by cli-utils.
+	updated := resgroup.DeepCopy()
+	assert.NoError(t, c.Get(context.TODO(), client.ObjectKeyFromObject(resgroup), updated))
+	updated.Status.ResourceStatuses[0].Actuation = v1alpha1.ActuationPending
+	assert.NoError(t, c.Status().Update(context.TODO(), updated))
+
+	assert.NoError(t, c.Status().Patch(context.TODO(), resgroup, client.RawPatch(types.JSONPatchType, patch)))
+	got := &v1alpha1.ResourceGroup{}
+	assert.NoError(t, c.Get(context.TODO(), client.ObjectKeyFromObject(resgroup), got))
+	newStatus.ResourceStatuses[0].Actuation = v1alpha1.ActuationPending
+	assert.Equal(t, newStatus, got.Status)
+
+	// The patch fails instead of updating the wrong entry after the
+	// resources were reordered.
+	got.Status.ResourceStatuses[0], got.Status.ResourceStatuses[1] = got.Status.ResourceStatuses[1], got.Status.ResourceStatuses[0]
+	assert.NoError(t, c.Status().Update(context.TODO(), got))
+	assert.Error(t, c.Status().Patch(context.TODO(), resgroup, client.RawPatch(types.JSONPatchType, patch)))
+
+	// No patch is needed when only the fields set by cli-utils differ.
+	newStatus = testStatus()
+	newStatus.ResourceStatuses[0].Actuation = v1alpha1.ActuationPending
+	patch, ok, err = statusPatch(testStatus(), newStatus)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Nil(t, patch)
+}
+
+func TestStatusPatchRolloutFields(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, v1alpha1.AddToScheme(scheme))
+	resgroup := &v1alpha1.ResourceGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "group", Namespace: "ns1", Generation: 2},
+		Status:     testStatus(),
+	}
+	resgroup.Status.GenerationChangedTime = &metav1.Time{Time: metav1.Now().Add(-time.Minute).Truncate(time.Second)}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(resgroup).Build()
+
+	newStatus := *resgroup.Status.DeepCopy()
+	newStatus.GenerationChangedTime = nil
+	newStatus.ReadyGeneration = 2
+	newStatus.LastReadyTime = &metav1.Time{Time: metav1.Now().Truncate(time.Second)}
+
+	patch, ok, err := statusPatch(resgroup.Status, newStatus)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.NoError(t, c.Status().Patch(context.TODO(), resgroup, client.RawPatch(types.JSONPatchType, patch)))
+	got := &v1alpha1.ResourceGroup{}
+	assert.NoError(t, c.Get(context.TODO(), client.ObjectKeyFromObject(resgroup), got))
+	assert.Nil(t, got.Status.GenerationChangedTime)
+	assert.Equal(t, int64(2), got.Status.ReadyGeneration)
+	assert.True(t, newStatus.LastReadyTime.Equal(got.Status.LastReadyTime))
+
+	// The drift counts are patched as a whole.
+	driftStatus := *got.Status.DeepCopy()
+	driftStatus.DriftCounts = &v1alpha1.DriftCounts{OtherRevision: 2}
+	patch, ok, err = statusPatch(got.Status, driftStatus)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.NoError(t, c.Status().Patch(context.TODO(), got, client.RawPatch(types.JSONPatchType, patch)))
+	assert.Equal(t, &v1alpha1.DriftCounts{OtherRevision: 2}, got.Status.DriftCounts)
+	patch, ok, err = statusPatch(got.Status, *resgroup.Status.DeepCopy())
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.NoError(t, c.Status().Patch(context.TODO(), got, client.RawPatch(types.JSONPatchType, patch)))
+	assert.Nil(t, got.Status.DriftCounts)
+
+	// A change of the status shards needs the whole status to be applied.
+	newStatus.StatusShards = []v1alpha1.StatusShard{{Name: "group-status-0", Count: 2}}
+	_, ok, err = statusPatch(resgroup.Status, newStatus)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// So does a change of the number of orphans, even if the listed orphans
+	// are the same.
+	newStatus.StatusShards = resgroup.Status.StatusShards
+	newStatus.OrphanCount = resgroup.Status.OrphanCount + 1
+	_, ok, err = statusPatch(resgroup.Status, newStatus)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+// patchRecorder records the patches of the status subresource.
+type patchRecorder struct {
+	client.Client
+	patches []client.Patch
+}
+
+func (c *patchRecorder) Status() client.SubResourceWriter {
+	return &patchRecorderStatus{SubResourceWriter: c.Client.Status(), recorder: c}
+}
+
+type patchRecorderStatus struct {
+	client.SubResourceWriter
+	recorder *patchRecorder
+}
+
+func (w *patchRecorderStatus) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+	w.recorder.patches = append(w.recorder.patches, patch)
+	return w.SubResourceWriter.Patch(ctx, obj, patch, opts...)
+}
+
+func TestUpdateStatusPatchesChangedEntries(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, v1alpha1.AddToScheme(scheme))
+	resgroup := &v1alpha1.ResourceGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "group", Namespace: "ns1", Generation: 1},
+		Status: v1alpha1.ResourceGroupStatus{
+			ObservedGeneration: 1,
+			ResourceStatuses:   shardTestStatuses(1000),
+		},
+	}
+	c := &patchRecorder{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(resgroup).Build()}
+	r := &reconciler{Client: c, log: logr.Discard()}
+
+	newStatus := *resgroup.Status.DeepCopy()
+	newStatus.ResourceStatuses[500].Status = v1alpha1.Failed
+	assert.NoError(t, r.updateStatusKptGroup(context.TODO(), resgroup, newStatus))
+
+	// The write only includes the changed entry.
+	assert.Len(t, c.patches, 1)
+	assert.Equal(t, types.JSONPatchType, c.patches[0].Type())
+	data, err := c.patches[0].Data(resgroup)
+	assert.NoError(t, err)
+	assert.Less(t, len(data), 1024)
+
+	got := &v1alpha1.ResourceGroup{}
+	assert.NoError(t, c.Get(context.TODO(), client.ObjectKeyFromObject(resgroup), got))
+	assert.Equal(t, v1alpha1.Failed, got.Status.ResourceStatuses[500].Status)
+	assert.Equal(t, v1alpha1.Current, got.Status.ResourceStatuses[499].Status)
+}
@@ -0,0 +1,74 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroup
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/pkg/groupstatus"
+)
+
+//nolint:revive // TODO: add comments for public constants and enable linting
+const (
+	ResourcesNotRead = "ResourcesNotRead"
+	ResourcesRead    = "ResourcesRead"
+	resourcesReadMsg = "all the resources were read from the API server"
+)
+
+func newReadFailedCondition(status v1alpha1.ConditionStatus, reason, message string) v1alpha1.Condition {
+	return v1alpha1.Condition{
+		Type:               v1alpha1.ReadFailed,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: metav1.Time{Time: time.Now().UTC()},
+	}
+}
+
+// readFailedCondition returns the ReadFailed condition of a group whose
+// resources have the given statuses. It lists the resources which the
+// controller failed to read by the reason of their ReadFailed condition,
+// e.g. Forbidden, so that their Unknown status is not mistaken for an
+// unhealthy resource.
+func readFailedCondition(statuses []v1alpha1.ResourceStatus) v1alpha1.Condition {
+	var reasons []string
+	failed := make(map[string][]string)
+	count := 0
+	for _, status := range statuses {
+		cond, found := groupstatus.GetCondition(status.Conditions, v1alpha1.ReadFailed)
+		if !found || cond.Status != v1alpha1.TrueConditionStatus {
+			continue
+		}
+		if _, ok := failed[cond.Reason]; !ok {
+			reasons = append(reasons, cond.Reason)
+		}
+		failed[cond.Reason] = append(failed[cond.Reason], formatResource(status.ObjMetadata))
+		count++
+	}
+	if count == 0 {
+		return newReadFailedCondition(v1alpha1.FalseConditionStatus, ResourcesRead, resourcesReadMsg)
+	}
+	details := make([]string, len(reasons))
+	for i, reason := range reasons {
+		details[i] = fmt.Sprintf("%s: %s", reason, truncatedList(failed[reason]))
+	}
+	return newReadFailedCondition(v1alpha1.TrueConditionStatus, ResourcesNotRead,
+		fmt.Sprintf("%d resources could not be read, so their status is Unknown; %s", count, strings.Join(details, "; ")))
+}
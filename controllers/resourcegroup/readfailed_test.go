@@ -0,0 +1,84 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroup
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/controllers/resourcemap"
+	controllerstatus "kpt.dev/resourcegroup/controllers/status"
+	"kpt.dev/resourcegroup/controllers/typeresolver"
+	"kpt.dev/resourcegroup/pkg/groupstatus"
+)
+
+// errorClient fails to get the objects with the error of their name.
+type errorClient struct {
+	client.Client
+	errs map[string]error
+}
+
+func (c errorClient) Get(_ context.Context, key client.ObjectKey, _ client.Object, _ ...client.GetOption) error {
+	return c.errs[key.Name]
+}
+
+func TestReadFailedCondition(t *testing.T) {
+	deploymentGK := schema.GroupKind{Group: "apps", Kind: "Deployment"}
+	newRes := func(name string) v1alpha1.ObjMetadata {
+		return v1alpha1.ObjMetadata{Namespace: "ns1", Name: name, GroupKind: v1alpha1.GroupKind(deploymentGK)}
+	}
+	gr := schema.GroupResource{Group: "apps", Resource: "deployments"}
+	c := errorClient{errs: map[string]error{
+		"forbidden":    apierrors.NewForbidden(gr, "forbidden", nil),
+		"throttled":    apierrors.NewTooManyRequests("slow down", 1),
+		"notfound":     apierrors.NewNotFound(gr, "notfound"),
+		"unauthorized": apierrors.NewUnauthorized("invalid token"),
+	}}
+	r := &reconciler{
+		Client:   c,
+		log:      logr.Discard(),
+		resolver: typeresolver.NewFakeTypeResolver(map[schema.GroupKind]schema.GroupVersionKind{deploymentGK: deploymentGK.WithVersion("v1")}),
+		resMap:   resourcemap.NewResourceMap(),
+	}
+	metas := []v1alpha1.ObjMetadata{newRes("forbidden"), newRes("throttled"), newRes("notfound"), newRes("unauthorized")}
+	statuses := r.computeStatus(context.TODO(), "", v1alpha1.ResourceGroupStatus{}, metas, types.NamespacedName{}, true)
+
+	for i, reason := range []string{controllerstatus.ReadForbidden, controllerstatus.ReadThrottled, "", controllerstatus.ReadUnauthorized} {
+		cond, found := groupstatus.GetCondition(statuses[i].Conditions, v1alpha1.ReadFailed)
+		assert.Equal(t, reason != "", found, metas[i].Name)
+		assert.Equal(t, reason, cond.Reason, metas[i].Name)
+	}
+	assert.Equal(t, v1alpha1.Unknown, statuses[0].Status)
+	assert.Equal(t, v1alpha1.NotFound, statuses[2].Status)
+
+	cond := readFailedCondition(statuses)
+	assert.Equal(t, v1alpha1.TrueConditionStatus, cond.Status)
+	assert.Equal(t, ResourcesNotRead, cond.Reason)
+	assert.Equal(t, "3 resources could not be read, so their status is Unknown; "+
+		"Forbidden: apps/Deployment/ns1/forbidden; Throttled: apps/Deployment/ns1/throttled; "+
+		"Unauthorized: apps/Deployment/ns1/unauthorized", cond.Message)
+
+	cond = readFailedCondition(statuses[2:3])
+	assert.Equal(t, v1alpha1.FalseConditionStatus, cond.Status)
+	assert.Equal(t, ResourcesRead, cond.Reason)
+}
@@ -0,0 +1,101 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroup
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+)
+
+//nolint:revive // TODO: add comments for public constants and enable linting
+const (
+	ResourcesReady      = "ResourcesReady"
+	resourcesReadyMsg   = "all the resources are ready"
+	ResourcesNotReady   = "ResourcesNotReady"
+	ResourcesInProgress = "ResourcesInProgress"
+	// maxNotReadyInMessage is the maximum number of resources listed in the
+	// message of the Ready condition.
+	maxNotReadyInMessage = 10
+)
+
+func newReadyCondition(status v1alpha1.ConditionStatus, reason, message string) v1alpha1.Condition {
+	return v1alpha1.Condition{
+		Type:               v1alpha1.Ready,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: metav1.Time{Time: time.Now().UTC()},
+	}
+}
+
+// readinessCondition returns the Ready condition of a group whose resources
+// have the given statuses, according to the readiness policy of the group.
+//
+// Without a policy, the group is Ready when all its resources are Current.
+// Otherwise, the resources of the ignored kinds, and the NotFound resources if
+// ignoreNotFound is set, are skipped; the group is Ready when none of the
+// remaining resources is Failed, Unknown or NotFound, and at most
+// maxInProgressPercent of them are InProgress or Terminating.
+func readinessCondition(policy *v1alpha1.ReadinessPolicy, statuses []v1alpha1.ResourceStatus) v1alpha1.Condition {
+	if policy == nil {
+		policy = &v1alpha1.ReadinessPolicy{}
+	}
+	ignoredKinds := make(map[v1alpha1.GroupKind]bool, len(policy.IgnoredKinds))
+	for _, gk := range policy.IgnoredKinds {
+		ignoredKinds[gk] = true
+	}
+
+	total := 0
+	var notReady, inProgress []string
+	for _, status := range statuses {
+		if ignoredKinds[status.GroupKind] || (policy.IgnoreNotFound && status.Status == v1alpha1.NotFound) {
+			continue
+		}
+		total++
+		res := status.ObjMetadata
+		resStr := fmt.Sprintf("%s/%s/%s/%s", res.Group, res.Kind, res.Namespace, res.Name)
+		switch status.Status {
+		case v1alpha1.Current:
+		case v1alpha1.InProgress, v1alpha1.Terminating:
+			inProgress = append(inProgress, resStr)
+		default:
+			notReady = append(notReady, resStr)
+		}
+	}
+
+	if len(notReady) > 0 {
+		return newReadyCondition(v1alpha1.FalseConditionStatus, ResourcesNotReady,
+			fmt.Sprintf("%d of %d resources are not ready: %s", len(notReady), total, truncatedList(notReady)))
+	}
+	if len(inProgress)*100 > int(policy.MaxInProgressPercent)*total {
+		return newReadyCondition(v1alpha1.FalseConditionStatus, ResourcesInProgress,
+			fmt.Sprintf("%d of %d resources are in progress, more than the maximum of %d%%: %s",
+				len(inProgress), total, policy.MaxInProgressPercent, truncatedList(inProgress)))
+	}
+	return newReadyCondition(v1alpha1.TrueConditionStatus, ResourcesReady, resourcesReadyMsg)
+}
+
+// truncatedList joins the first maxNotReadyInMessage items.
+func truncatedList(items []string) string {
+	if len(items) <= maxNotReadyInMessage {
+		return strings.Join(items, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(items[:maxNotReadyInMessage], ", "), len(items)-maxNotReadyInMessage)
+}
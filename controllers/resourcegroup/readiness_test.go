@@ -0,0 +1,135 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroup
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+)
+
+func readinessTestStatuses(statuses ...v1alpha1.Status) []v1alpha1.ResourceStatus {
+	result := make([]v1alpha1.ResourceStatus, len(statuses))
+	for i, s := range statuses {
+		kind := "ConfigMap"
+		if i == 0 {
+			kind = "Job"
+		}
+		result[i] = v1alpha1.ResourceStatus{
+			ObjMetadata: v1alpha1.ObjMetadata{
+				Name:      fmt.Sprintf("res%d", i),
+				Namespace: "ns1",
+				GroupKind: v1alpha1.GroupKind{Kind: kind},
+			},
+			Status: s,
+		}
+	}
+	return result
+}
+
+func TestReadinessCondition(t *testing.T) {
+	tests := map[string]struct {
+		policy         *v1alpha1.ReadinessPolicy
+		statuses       []v1alpha1.ResourceStatus
+		expectedStatus v1alpha1.ConditionStatus
+		expectedReason string
+	}{
+		"should be ready without resources": {
+			expectedStatus: v1alpha1.TrueConditionStatus,
+			expectedReason: ResourcesReady,
+		},
+		"should be ready when all the resources are current": {
+			statuses:       readinessTestStatuses(v1alpha1.Current, v1alpha1.Current),
+			expectedStatus: v1alpha1.TrueConditionStatus,
+			expectedReason: ResourcesReady,
+		},
+		"should not be ready with a failed resource": {
+			statuses:       readinessTestStatuses(v1alpha1.Current, v1alpha1.Failed),
+			expectedStatus: v1alpha1.FalseConditionStatus,
+			expectedReason: ResourcesNotReady,
+		},
+		"should not be ready with an in progress resource by default": {
+			statuses:       readinessTestStatuses(v1alpha1.Current, v1alpha1.InProgress),
+			expectedStatus: v1alpha1.FalseConditionStatus,
+			expectedReason: ResourcesInProgress,
+		},
+		"should be ready with in progress resources below the maximum": {
+			policy:         &v1alpha1.ReadinessPolicy{MaxInProgressPercent: 50},
+			statuses:       readinessTestStatuses(v1alpha1.Current, v1alpha1.InProgress),
+			expectedStatus: v1alpha1.TrueConditionStatus,
+			expectedReason: ResourcesReady,
+		},
+		"should not be ready with in progress resources above the maximum": {
+			policy:         &v1alpha1.ReadinessPolicy{MaxInProgressPercent: 50},
+			statuses:       readinessTestStatuses(v1alpha1.Current, v1alpha1.InProgress, v1alpha1.Terminating),
+			expectedStatus: v1alpha1.FalseConditionStatus,
+			expectedReason: ResourcesInProgress,
+		},
+		"should not be ready with a not found resource by default": {
+			statuses:       readinessTestStatuses(v1alpha1.Current, v1alpha1.NotFound),
+			expectedStatus: v1alpha1.FalseConditionStatus,
+			expectedReason: ResourcesNotReady,
+		},
+		"should ignore not found resources": {
+			policy:         &v1alpha1.ReadinessPolicy{IgnoreNotFound: true},
+			statuses:       readinessTestStatuses(v1alpha1.Current, v1alpha1.NotFound),
+			expectedStatus: v1alpha1.TrueConditionStatus,
+			expectedReason: ResourcesReady,
+		},
+		"should ignore the resources of the ignored kinds": {
+			policy: &v1alpha1.ReadinessPolicy{
+				IgnoredKinds: []v1alpha1.GroupKind{{Kind: "Job"}},
+			},
+			statuses:       readinessTestStatuses(v1alpha1.Failed, v1alpha1.Current),
+			expectedStatus: v1alpha1.TrueConditionStatus,
+			expectedReason: ResourcesReady,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(fmt.Sprintf("readinessCondition %s", name), func(t *testing.T) {
+			c := readinessCondition(tc.policy, tc.statuses)
+			assert.Equal(t, v1alpha1.Ready, c.Type)
+			assert.Equal(t, tc.expectedStatus, c.Status)
+			assert.Equal(t, tc.expectedReason, c.Reason)
+		})
+	}
+}
+
+func TestReadinessConditionMessage(t *testing.T) {
+	statuses := readinessTestStatuses(make([]v1alpha1.Status, 12)...)
+	for i := range statuses {
+		statuses[i].Status = v1alpha1.Failed
+	}
+	c := readinessCondition(nil, statuses)
+	assert.Contains(t, c.Message, "12 of 12 resources are not ready: /Job/ns1/res0, /ConfigMap/ns1/res1")
+	assert.Contains(t, c.Message, "and 2 more")
+}
+
+func TestStartReconcilingStatusKeepsReady(t *testing.T) {
+	r := &reconciler{}
+	ready := newReadyCondition(v1alpha1.TrueConditionStatus, ResourcesReady, resourcesReadyMsg)
+	status := r.startReconcilingStatus(v1alpha1.ResourceGroupStatus{
+		Conditions: []v1alpha1.Condition{
+			newReconcilingCondition(v1alpha1.FalseConditionStatus, FinishReconciling, finishReconcilingMsg),
+			newStalledCondition(v1alpha1.FalseConditionStatus, FinishReconciling, finishReconcilingMsg),
+			ready,
+		},
+	})
+	assert.Len(t, status.Conditions, 3)
+	assert.Equal(t, ready, status.Conditions[2])
+}
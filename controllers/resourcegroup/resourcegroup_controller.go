@@ -25,25 +25,24 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/cli-utils/pkg/common"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
-	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
-	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/controllers/eventbus"
 	"kpt.dev/resourcegroup/controllers/handler"
+	"kpt.dev/resourcegroup/controllers/hub"
 	"kpt.dev/resourcegroup/controllers/metrics"
 	"kpt.dev/resourcegroup/controllers/resourcemap"
 	controllerstatus "kpt.dev/resourcegroup/controllers/status"
 	"kpt.dev/resourcegroup/controllers/typeresolver"
+	"kpt.dev/resourcegroup/pkg/groupstatus"
 )
 
 //nolint:revive // TODO: add comments for public constants and enable linting
@@ -54,12 +53,17 @@ const (
 	finishReconcilingMsg     = "finish reconciling"
 	ComponentFailed          = "ComponentFailed"
 	componentFailedMsgPrefix = "The following components failed:"
-	ExceedTimeout            = "ExceedTimeout"
+	clusterStalledMsgPrefix  = "The following member clusters are stalled:"
+	ExceedTimeout            = groupstatus.ExceedTimeoutReason
 	exceedTimeoutMsg         = "Exceed timeout, the .status.observedGeneration and .status.resourceStatuses fields are old."
-	owningInventoryKey       = "config.k8s.io/owning-inventory"
 	readinessComponent       = "readiness"
 )
 
+// maxOrphans is the maximum number of orphans listed in the status of a
+// ResourceGroup, so that a large number of leftover objects does not
+// exceed the size limit of the object.
+const maxOrphans = 100
+
 // contextKey is a custom type for wrapping context values to make them unique
 // to this package
 type contextKey string
@@ -86,11 +90,23 @@ type reconciler struct {
 
 	// resMap is the resourcemap for storing the resource status and conditions.
 	resMap *resourcemap.ResourceMap
+
+	// apiReader reads the status shards and the history from the API server without caching
+	// all the ConfigMaps in the cluster.
+	apiReader client.Reader
+
+	// hub computes the statuses of the resources in the member clusters in
+	// hub mode, or is nil.
+	hub *hub.Hub
+
+	// opts configures the computation and the storage of the statuses.
+	opts Options
 }
 
 // +kubebuilder:rbac:groups=kpt.dev,resources=resourcegroups,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=kpt.dev,resources=resourcegroups/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=*,resources=*,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;create;patch;delete
 
 func (r *reconciler) Reconcile(c context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := r.log
@@ -115,9 +131,21 @@ func (r *reconciler) reconcileKpt(ctx context.Context, req ctrl.Request, logger
 		return ctrl.Result{}, nil
 	}
 
+	// Read the resource statuses from the status shards, so that the status is
+	// computed and compared with the full view of the group.
+	if len(resgroup.Status.StatusShards) > 0 {
+		statuses, err := groupstatus.GetResourceStatuses(ctx, r.apiReader, resgroup)
+		if err != nil {
+			logger.Error(err, "failed to read the status shards")
+			return ctrl.Result{Requeue: true}, err
+		}
+		resgroup.Status.ResourceStatuses = statuses
+	}
+
 	newStatus := r.startReconcilingStatus(resgroup.Status)
 	if err := r.updateStatusKptGroup(ctx, resgroup, newStatus); err != nil {
 		logger.Error(err, "failed to update")
+		r.resMap.RequestFullRecompute(req.NamespacedName)
 		return ctrl.Result{Requeue: true}, err
 	}
 
@@ -125,11 +153,22 @@ func (r *reconciler) reconcileKpt(ctx context.Context, req ctrl.Request, logger
 	newStatus = r.endReconcilingStatus(ctx, id, req.NamespacedName, resgroup.Spec, resgroup.Status, resgroup.Generation)
 	if err := r.updateStatusKptGroup(ctx, resgroup, newStatus); err != nil {
 		logger.Error(err, "failed to update")
+		// The changes taken for computing newStatus are lost, so the next
+		// reconciliation needs to recompute the whole status.
+		r.resMap.RequestFullRecompute(req.NamespacedName)
+		return ctrl.Result{Requeue: true}, err
+	}
+
+	if err := r.persistHistory(ctx, resgroup); err != nil {
+		logger.Error(err, "failed to persist the status history")
 		return ctrl.Result{Requeue: true}, err
 	}
 
 	logger.Info("finished reconciling")
-	return ctrl.Result{}, nil
+	// The Stalled condition is recomputed when the next progress deadline of
+	// the InProgress resources is exceeded, even if none of them changes.
+	_, next := r.progressDeadlines(newStatus.ResourceStatuses, time.Now())
+	return ctrl.Result{RequeueAfter: next}, nil
 }
 
 // currentStatusCount counts the number of `Current` statuses.
@@ -170,15 +209,69 @@ func updateResourceMetrics(ctx context.Context, nn types.NamespacedName, statuse
 }
 
 func (r *reconciler) updateStatusKptGroup(ctx context.Context, resgroup *v1alpha1.ResourceGroup, newStatus v1alpha1.ResourceGroupStatus) error {
-	newStatus.Conditions = adjustConditionOrder(newStatus.Conditions)
-	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
-		if apiequality.Semantic.DeepEqual(resgroup.Status, newStatus) {
+	newStatus.Conditions = adjustConditionOrder(newStatus.Conditions, resgroup.Status.Conditions)
+	newStatus.StatusShards = statusShards(resgroup.Name, newStatus.ResourceStatuses, r.opts.StatusShardSize)
+	if apiequality.Semantic.DeepEqual(resgroup.Status, newStatus) {
+		return nil
+	}
+
+	// Patch only the changed entries when possible, since applying the whole
+	// status of a large ResourceGroup is slow. The patch only includes the
+	// fields owned by the controller, so it does not conflict with the applier.
+	if len(newStatus.StatusShards) == 0 {
+		patch, ok, err := statusPatch(resgroup.Status, newStatus)
+		if err != nil {
+			return err
+		}
+		if ok && patch == nil {
 			return nil
 		}
-		resgroup.Status = newStatus
-		// Use `r.Status().Update()` here instead of `r.Update()` to update only resgroup.Status.
-		return r.Status().Update(ctx, resgroup)
-	})
+		if ok {
+			r.log.V(4).Info("patching the status", "namespace", resgroup.Namespace, "name", resgroup.Name)
+			err := r.Status().Patch(ctx, resgroup, client.RawPatch(types.JSONPatchType, patch), &client.SubResourcePatchOptions{
+				PatchOptions: client.PatchOptions{FieldManager: FieldManager},
+			})
+			if err == nil {
+				return nil
+			}
+			// The entries were modified concurrently, so the whole status is applied.
+			r.log.V(4).Info("failed to patch the status, applying it", "namespace", resgroup.Namespace, "name", resgroup.Name, "error", err.Error())
+		}
+	}
+
+	// The resource statuses of a sharded ResourceGroup are written into the
+	// status shards before the ResourceGroup object references them.
+	appliedStatus := newStatus
+	if len(newStatus.StatusShards) > 0 {
+		if err := r.writeStatusShards(ctx, resgroup, newStatus); err != nil {
+			return err
+		}
+		appliedStatus.ResourceStatuses = shardedResourceStatuses(newStatus.ResourceStatuses)
+	}
+
+	// Use server-side apply on the status subresource so that the controller only
+	// owns the fields it computes, and never overwrites or conflicts with the
+	// actuation fields written concurrently by the applier.
+	u, err := statusApplyConfiguration(resgroup, appliedStatus)
+	if err != nil {
+		return err
+	}
+	force := true
+	if err := r.Status().Patch(ctx, u, client.Apply, &client.SubResourcePatchOptions{
+		PatchOptions: client.PatchOptions{FieldManager: FieldManager, Force: &force},
+	}); err != nil {
+		return err
+	}
+	oldShards := resgroup.Status.StatusShards
+	// Keep resgroup up to date with the applied object, including the fields
+	// owned by the applier.
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, resgroup); err != nil {
+		return err
+	}
+	if len(newStatus.StatusShards) > 0 {
+		resgroup.Status.ResourceStatuses = newStatus.ResourceStatuses
+	}
+	return r.deleteStatusShards(ctx, resgroup.Namespace, oldShards, newStatus.StatusShards)
 }
 
 func (r *reconciler) startReconcilingStatus(status v1alpha1.ResourceGroupStatus) v1alpha1.ResourceGroupStatus {
@@ -186,11 +279,23 @@ func (r *reconciler) startReconcilingStatus(status v1alpha1.ResourceGroupStatus)
 		ObservedGeneration: status.ObservedGeneration,
 		ResourceStatuses:   status.ResourceStatuses,
 		SubgroupStatuses:   status.SubgroupStatuses,
+		Orphans:            status.Orphans,
+		OrphanCount:        status.OrphanCount,
+		DriftCounts:        status.DriftCounts,
+		ClusterStatuses:    status.ClusterStatuses,
 		Conditions: []v1alpha1.Condition{
 			newReconcilingCondition(v1alpha1.TrueConditionStatus, StartReconciling, startReconcilingMsg),
 			newStalledCondition(v1alpha1.FalseConditionStatus, "", ""),
 		},
 	}
+	carryOverRolloutTimes(&newStatus, status)
+	// The dependencies, the drift, the read failures, the readiness and the
+	// access of the group are unchanged until the end of the reconciliation.
+	for _, condType := range []v1alpha1.ConditionType{v1alpha1.Blocked, v1alpha1.Drifted, v1alpha1.ReadFailed, v1alpha1.Ready, v1alpha1.WatchForbidden, v1alpha1.OutsideRole} {
+		if cond, found := groupstatus.GetCondition(status.Conditions, condType); found {
+			newStatus.Conditions = append(newStatus.Conditions, cond)
+		}
+	}
 	return newStatus
 }
 
@@ -207,29 +312,106 @@ func (r *reconciler) endReconcilingStatus(
 	startTime := time.Now()
 	reconcileTimeout := getReconcileTimeOut(len(spec.Subgroups) + len(spec.Resources))
 
-	finish := make(chan struct{})
+	// Only recompute the entries of the members whose status changed since the
+	// last reconciliation when the existing status was computed for the current spec.
+	changes, tracked := r.resMap.TakeChanges(namespacedName)
+	incremental := tracked && statusMatchesSpec(spec, status, generation)
+
+	// computeCtx is canceled when the timeout fires or the parent context is
+	// done, which aborts the in-flight requests to the API server and lets the
+	// computing goroutine exit.
+	computeCtx, cancel := context.WithTimeout(ctx, reconcileTimeout)
+	defer cancel()
+
+	type computeResult struct {
+		resourceStatuses []v1alpha1.ResourceStatus
+		subgroupStatuses []v1alpha1.GroupStatus
+		clusterStatuses  []v1alpha1.ClusterStatus
+	}
+	// finish is buffered so that the computing goroutine never blocks
+	// on sending the result after a timeout.
+	finish := make(chan computeResult, 1)
 	go func() {
-		newStatus.ResourceStatuses = r.computeResourceStatuses(ctx, id, status, spec.Resources, namespacedName)
-		newStatus.SubgroupStatuses = r.computeSubGroupStatuses(ctx, id, status, spec.Subgroups, namespacedName)
-		close(finish)
+		var clusterStatuses []v1alpha1.ClusterStatus
+		if r.hub != nil {
+			clusterStatuses = r.hub.Statuses(computeCtx, spec.Clusters, status.ClusterStatuses)
+		}
+		if incremental {
+			r.log.V(4).Info("recomputing the changed statuses", "namespace", namespacedName.Namespace, "name", namespacedName.Name, "changes", len(changes))
+			resourceStatuses, subgroupStatuses := r.recomputeChangedStatuses(computeCtx, id, status, changes, namespacedName)
+			finish <- computeResult{
+				resourceStatuses: resourceStatuses,
+				subgroupStatuses: subgroupStatuses,
+				clusterStatuses:  clusterStatuses,
+			}
+			return
+		}
+		finish <- computeResult{
+			resourceStatuses: r.computeResourceStatuses(computeCtx, id, status, spec.Resources, namespacedName),
+			subgroupStatuses: r.computeSubGroupStatuses(computeCtx, id, status, spec.Subgroups, namespacedName),
+			clusterStatuses:  clusterStatuses,
+		}
 	}()
 	select {
-	case <-finish:
+	case result := <-finish:
+		// The Blocked conditions depend on the statuses of other resources, so
+		// they are computed once all the statuses are known.
+		resourceStatuses, blocked := r.dependencyConditions(result.resourceStatuses, status.ResourceStatuses)
+		drifted, driftCounts := driftCondition(spec.Descriptor.Revision, resourceStatuses, r.sourceHashRemoved)
+		metrics.RecordDriftedResourceCount(ctx, namespacedName, int64(driftCounts.Drifted()))
+		newStatus.DriftCounts = driftCounts
+		newStatus.ResourceStatuses = resourceStatuses
+		newStatus.SubgroupStatuses = result.subgroupStatuses
+		newStatus.ClusterStatuses = result.clusterStatuses
 		newStatus.ObservedGeneration = generation
+		// The resources of the member clusters count for the stalled and the
+		// ready conditions of the group.
+		allStatuses := withClusterStatuses(newStatus.ResourceStatuses, newStatus.ClusterStatuses)
+		exceeded, _ := r.progressDeadlines(newStatus.ResourceStatuses, time.Now())
 		newStatus.Conditions = []v1alpha1.Condition{
 			newReconcilingCondition(v1alpha1.FalseConditionStatus, FinishReconciling, finishReconcilingMsg),
-			aggregateResourceStatuses(newStatus.ResourceStatuses),
+			withClusterConditions(aggregateResourceStatuses(allStatuses, exceeded), newStatus.ClusterStatuses),
+			blocked,
+			drifted,
+			readFailedCondition(allStatuses),
+			readinessCondition(spec.ReadinessPolicy, allStatuses),
+			r.watchForbiddenCondition(spec),
 		}
-	case <-time.After(reconcileTimeout):
+		if r.opts.LeastPrivilegeRole != "" {
+			newStatus.Conditions = append(newStatus.Conditions, r.outsideRoleCondition(ctx, spec))
+		}
+		setRolloutTimes(ctx, namespacedName, &newStatus, status, generation, time.Now())
+	case <-computeCtx.Done():
+		// The status computed from the taken changes is discarded.
+		r.resMap.RequestFullRecompute(namespacedName)
 		newStatus.ObservedGeneration = status.ObservedGeneration
 		newStatus.ResourceStatuses = status.ResourceStatuses
 		newStatus.SubgroupStatuses = status.SubgroupStatuses
+		newStatus.ClusterStatuses = status.ClusterStatuses
+		carryOverRolloutTimes(&newStatus, status)
 		newStatus.Conditions = []v1alpha1.Condition{
 			newReconcilingCondition(v1alpha1.FalseConditionStatus, ExceedTimeout, exceedTimeoutMsg),
 			newStalledCondition(v1alpha1.TrueConditionStatus, ExceedTimeout, exceedTimeoutMsg),
+			newBlockedCondition(v1alpha1.UnknownConditionStatus, ExceedTimeout, exceedTimeoutMsg),
+			newDriftedCondition(v1alpha1.UnknownConditionStatus, ExceedTimeout, exceedTimeoutMsg),
+			newReadFailedCondition(v1alpha1.UnknownConditionStatus, ExceedTimeout, exceedTimeoutMsg),
+			newReadyCondition(v1alpha1.UnknownConditionStatus, ExceedTimeout, exceedTimeoutMsg),
+			newWatchForbiddenCondition(v1alpha1.UnknownConditionStatus, ExceedTimeout, exceedTimeoutMsg),
+		}
+		if r.opts.LeastPrivilegeRole != "" {
+			newStatus.Conditions = append(newStatus.Conditions,
+				newOutsideRoleCondition(v1alpha1.UnknownConditionStatus, ExceedTimeout, exceedTimeoutMsg))
 		}
 	}
 
+	// The orphans are found by the orphan scanner, if enabled.
+	orphans := r.resMap.GetOrphans(namespacedName)
+	newStatus.OrphanCount = len(orphans)
+	if len(orphans) > maxOrphans {
+		orphans = orphans[:maxOrphans]
+	}
+	newStatus.Orphans = orphans
+
 	metrics.RecordReconcileDuration(ctx, newStatus.Conditions[1].Reason, startTime)
 	updateResourceMetrics(ctx, namespacedName, newStatus.ResourceStatuses)
 	return newStatus
@@ -268,6 +450,27 @@ func (r *reconciler) getResourceStatus(status v1alpha1.ResourceGroupStatus) map[
 	return statuses
 }
 
+// getSubgroupStatus returns a map of subgroup statuses indexed on the subgroup's object meta.
+func (r *reconciler) getSubgroupStatus(status v1alpha1.ResourceGroupStatus) map[v1alpha1.ObjMetadata]v1alpha1.ResourceStatus {
+	statuses := make(map[v1alpha1.ObjMetadata]v1alpha1.ResourceStatus, len(status.SubgroupStatuses))
+
+	for _, group := range status.SubgroupStatuses {
+		res := subgroupResourceStatus(group)
+		statuses[res.ObjMetadata] = res
+	}
+
+	return statuses
+}
+
+// subgroupResourceStatus converts a subgroup status into a resource status.
+func subgroupResourceStatus(group v1alpha1.GroupStatus) v1alpha1.ResourceStatus {
+	return v1alpha1.ResourceStatus{
+		ObjMetadata: v1alpha1.ToObjMetadata([]v1alpha1.GroupMetadata{group.GroupMetadata})[0],
+		Status:      group.Status,
+		Conditions:  group.Conditions,
+	}
+}
+
 // isResource flag indicates if the compute is for resource when true. If false, the compute is for SubGroup (or others).
 func (r *reconciler) computeStatus(
 	ctx context.Context,
@@ -278,80 +481,207 @@ func (r *reconciler) computeStatus(
 	isResource bool,
 ) []v1alpha1.ResourceStatus {
 	actuationStatuses := r.getResourceStatus(existingStatus)
-	statuses := []v1alpha1.ResourceStatus{}
-	hasErr := false
-	for _, res := range metas {
-		resStatus := v1alpha1.ResourceStatus{
-			ObjMetadata: res,
+	if !isResource {
+		actuationStatuses = r.getSubgroupStatus(existingStatus)
+	}
+	// Read the members missing from the cache in batches, rather than with
+	// a GET per member below.
+	r.prefetchStatuses(ctx, metas)
+	// The statuses are computed concurrently, and stored at the index of
+	// their member to keep the order of the spec.
+	statuses := make([]v1alpha1.ResourceStatus, len(metas))
+	errs := make([]bool, len(metas))
+	forEachMember(ctx, r.opts.StatusWorkers, len(metas), func(i int) {
+		var existing *v1alpha1.ResourceStatus
+		if aStatus, exists := actuationStatuses[metas[i]]; exists {
+			existing = &aStatus
 		}
+		statuses[i], errs[i] = r.computeResourceStatus(ctx, id, metas[i], existing)
+	})
+	if ctx.Err() != nil {
+		// The reconcile timed out or the controller is shutting down.
+		// The caller discards the partial result.
+		return statuses
+	}
+	hasErr := false
+	for _, resErr := range errs {
+		hasErr = hasErr || resErr
+	}
+	// Only record pipeline_error_observed when computing status for resources
+	// Since the subgroup is always 0 and can overwrite the resource status
+	if isResource {
+		metrics.RecordPipelineError(ctx, nn, readinessComponent, hasErr)
+	}
+	return statuses
+}
 
-		cachedStatus := r.resMap.GetStatus(res)
-
-		// Add status to cache, if not present.
-		switch {
-		case cachedStatus != nil:
-			r.log.V(4).Info("found the cached resource status for", "namespace", res.Namespace, "name", res.Name)
-			setResStatus(id, &resStatus, cachedStatus)
-		default:
-			resObj := new(unstructured.Unstructured)
-			gvk, gvkFound := r.resolver.Resolve(schema.GroupKind(res.GroupKind))
-			if !gvkFound {
-				// If the resolver cache does not contain the server preferred GVK, then GVK returned
-				// will be empty resulting in a GET error. An instance of this occurring is when the
-				// resource type (CRD) does not exist.
-				r.log.V(4).Info("unable to get object from API server to compute status as resource does not exist", "namespace", res.Namespace, "name", res.Name)
-				resStatus.Status = v1alpha1.NotFound
-				break
-			}
-			resObj.SetGroupVersionKind(gvk)
-			r.log.Info("get the object from API server to compute status for", "namespace", res.Namespace, "name", res.Name)
-			err := r.Get(ctx, types.NamespacedName{
-				Namespace: res.Namespace,
-				Name:      res.Name,
-			}, resObj)
-			if err != nil {
-				if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
-					resStatus.Status = v1alpha1.NotFound
-				} else {
-					resStatus.Status = v1alpha1.Unknown
-				}
-				r.log.V(4).Error(err, "unable to get object from API server to compute status", "namespace", res.Namespace, "name", res.Name)
+// recomputeChangedStatuses recomputes the entries of the existing resource statuses
+// and subgroup statuses for the changed members, and keeps the other entries.
+// The existing status must be computed for the current spec.
+func (r *reconciler) recomputeChangedStatuses(
+	ctx context.Context,
+	id string,
+	existingStatus v1alpha1.ResourceGroupStatus,
+	changes []v1alpha1.ObjMetadata,
+	nn types.NamespacedName,
+) ([]v1alpha1.ResourceStatus, []v1alpha1.GroupStatus) {
+	changed := make(map[v1alpha1.ObjMetadata]bool, len(changes))
+	for _, res := range changes {
+		changed[res] = true
+	}
 
-				break // Breaks out of switch statement, not the loop.
-			}
-			// get the resource status using the kstatus library
-			cachedStatus = controllerstatus.ComputeStatus(resObj)
-			// save the computed status and condition in memory.
-			r.resMap.SetStatus(res, cachedStatus)
-			// Update the new resource status.
-			setResStatus(id, &resStatus, cachedStatus)
+	resourceStatuses := make([]v1alpha1.ResourceStatus, len(existingStatus.ResourceStatuses))
+	hasErr := false
+	for i, existing := range existingStatus.ResourceStatuses {
+		if ctx.Err() != nil {
+			// The caller discards the partial result.
+			return nil, nil
+		}
+		if changed[existing.ObjMetadata] {
+			existing := existing
+			resStatus, resErr := r.computeResourceStatus(ctx, id, existing.ObjMetadata, &existing)
+			resourceStatuses[i] = resStatus
+			hasErr = hasErr || resErr
+		} else {
+			resourceStatuses[i] = existing
+			hasErr = hasErr || isErrorStatus(existing)
+		}
+	}
+	metrics.RecordPipelineError(ctx, nn, readinessComponent, hasErr)
+
+	subgroupStatuses := make([]v1alpha1.GroupStatus, len(existingStatus.SubgroupStatuses))
+	for i, existing := range existingStatus.SubgroupStatuses {
+		if ctx.Err() != nil {
+			return nil, nil
 		}
+		res := subgroupResourceStatus(existing)
+		if changed[res.ObjMetadata] {
+			resStatus, _ := r.computeResourceStatus(ctx, id, res.ObjMetadata, &res)
+			subgroupStatuses[i] = v1alpha1.ToGroupStatuses([]v1alpha1.ResourceStatus{resStatus})[0]
+		} else {
+			subgroupStatuses[i] = existing
+		}
+	}
+	return resourceStatuses, subgroupStatuses
+}
 
-		if resStatus.Status == v1alpha1.Failed || controllerstatus.IsCNRMResource(resStatus.Group) && resStatus.Status != v1alpha1.Current {
-			hasErr = true
+// statusMatchesSpec checks whether the status was computed for the given
+// generation, and its entries match the members in the spec in the same order.
+func statusMatchesSpec(spec v1alpha1.ResourceGroupSpec, status v1alpha1.ResourceGroupStatus, generation int64) bool {
+	if status.ObservedGeneration != generation ||
+		len(status.ResourceStatuses) != len(spec.Resources) ||
+		len(status.SubgroupStatuses) != len(spec.Subgroups) {
+		return false
+	}
+	for i, res := range spec.Resources {
+		if status.ResourceStatuses[i].ObjMetadata != res {
+			return false
+		}
+	}
+	for i, group := range spec.Subgroups {
+		if status.SubgroupStatuses[i].GroupMetadata != group {
+			return false
 		}
+	}
+	return true
+}
+
+// isErrorStatus checks whether the resource status should be reported as a pipeline error.
+func isErrorStatus(resStatus v1alpha1.ResourceStatus) bool {
+	return resStatus.Status == v1alpha1.Failed || controllerstatus.IsCNRMResource(resStatus.Group) && resStatus.Status != v1alpha1.Current
+}
 
-		// Update the legacy status field based on the actuation, strategy and reconcile
-		// statuses set by cli-utils. If the actuation is not successful, update the legacy
-		// status field to be of unknown status.
-		aStatus, exists := actuationStatuses[resStatus.ObjMetadata]
-		if exists {
-			resStatus.Actuation = aStatus.Actuation
-			resStatus.Strategy = aStatus.Strategy
-			resStatus.Reconcile = aStatus.Reconcile
+// computeResourceStatus computes the status of a single resource from the
+// cached status, or from the object on the API server when the cache misses.
+// existing is the entry of the resource in the current status, which carries
+// the actuation, strategy and reconcile statuses set by cli-utils, and the
+// conditions whose LastTransitionTime is preserved if their status did not change.
+//
+// The second return value reports whether the computed status is an error.
+func (r *reconciler) computeResourceStatus(
+	ctx context.Context,
+	id string,
+	res v1alpha1.ObjMetadata,
+	existing *v1alpha1.ResourceStatus,
+) (v1alpha1.ResourceStatus, bool) {
+	resStatus := v1alpha1.ResourceStatus{
+		ObjMetadata: res,
+	}
+	var existingConditions []v1alpha1.Condition
+	if existing != nil {
+		existingConditions = existing.Conditions
+	}
 
-			resStatus.Status = ActuationStatusToLegacy(resStatus)
+	cachedStatus := r.resMap.GetStatus(res)
+
+	// Add status to cache, if not present.
+	switch {
+	case cachedStatus != nil:
+		r.log.V(4).Info("found the cached resource status for", "namespace", res.Namespace, "name", res.Name)
+		setResStatus(id, &resStatus, cachedStatus, existingConditions)
+	default:
+		gvk, gvkFound := r.resolver.Resolve(schema.GroupKind(res.GroupKind))
+		if !gvkFound {
+			// If the resolver cache does not contain the server preferred GVK, then GVK returned
+			// will be empty resulting in a GET error. An instance of this occurring is when the
+			// resource type (CRD) does not exist.
+			r.log.V(4).Info("unable to get object from API server to compute status as resource does not exist", "namespace", res.Namespace, "name", res.Name)
+			resStatus.Status = v1alpha1.NotFound
+			break
+		}
+		r.log.Info("get the object from API server to compute status for", "namespace", res.Namespace, "name", res.Name)
+		resObj, err := controllerstatus.GetObject(ctx, r.reader(gvk.GroupKind()), gvk, types.NamespacedName{
+			Namespace: res.Namespace,
+			Name:      res.Name,
+		}, r.opts.MetadataOnlyKinds[gvk.GroupKind()])
+		if err != nil {
+			if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+				resStatus.Status = v1alpha1.NotFound
+				r.log.V(4).Error(err, "unable to get object from API server to compute status", "namespace", res.Namespace, "name", res.Name)
+				break // Breaks out of the switch statement.
+			}
+			// The status is Unknown because the object cannot be read, which
+			// is reported with the ReadFailed condition of the group, so it is
+			// only logged at a high verbosity to avoid an error per resource.
+			resStatus.Status = v1alpha1.Unknown
+			resStatus.Conditions = controllerstatus.ReadFailedConditions(err, existingConditions)
+			r.log.V(4).Error(err, "unable to get object from API server to compute status", "namespace", res.Namespace, "name", res.Name,
+				"reason", controllerstatus.ReadFailedReason(err))
+
+			break // Breaks out of the switch statement.
 		}
+		// get the resource status using the kstatus library
+		cachedStatus = controllerstatus.ComputeStatus(resObj, existingConditions, r.opts.AnnotationKeys)
+		cachedStatus.ResourceVersion = resObj.GetResourceVersion()
+		// save the computed status and condition in memory.
+		r.resMap.SetStatus(res, cachedStatus)
+		// Update the new resource status.
+		setResStatus(id, &resStatus, cachedStatus, existingConditions)
+	}
+
+	hasErr := isErrorStatus(resStatus)
 
-		// add the resource status into resgroup
-		statuses = append(statuses, resStatus)
+	// Update the legacy status field based on the actuation, strategy and reconcile
+	// statuses set by cli-utils. If the actuation is not successful, update the legacy
+	// status field to be of unknown status.
+	if existing != nil {
+		resStatus.Actuation = existing.Actuation
+		resStatus.Strategy = existing.Strategy
+		resStatus.Reconcile = existing.Reconcile
+
+		resStatus.Status = ActuationStatusToLegacy(resStatus)
 	}
-	// Only record pipeline_error_observed when computing status for resources
-	// Since the subgroup is always 0 and can overwrite the resource status
-	if isResource {
-		metrics.RecordPipelineError(ctx, nn, readinessComponent, hasErr)
+	return resStatus, hasErr
+}
+
+// reader returns the reader of the objects of the given kind. The metadata of
+// the objects of the metadata-only kinds is read from the API reader, since
+// the cached client would start an informer for it.
+func (r *reconciler) reader(gk schema.GroupKind) client.Reader {
+	if r.opts.MetadataOnlyKinds[gk] {
+		return r.apiReader
 	}
-	return statuses
+	return r.Client
 }
 
 // ActuationStatusToLegacy contains the logic/rules to convert from the actuation statuses
@@ -374,18 +704,23 @@ func ActuationStatusToLegacy(s v1alpha1.ResourceStatus) v1alpha1.Status {
 }
 
 // setResStatus updates a resource status struct using values within the cached status struct.
-func setResStatus(id string, resStatus *v1alpha1.ResourceStatus, cachedStatus *resourcemap.CachedStatus) {
+// The LastTransitionTime of the existing conditions is preserved for the conditions
+// whose status did not change.
+func setResStatus(id string, resStatus *v1alpha1.ResourceStatus, cachedStatus *resourcemap.CachedStatus, existing []v1alpha1.Condition) {
 	resStatus.Status = cachedStatus.Status
-	resStatus.Conditions = make([]v1alpha1.Condition, len(cachedStatus.Conditions))
-	copy(resStatus.Conditions, cachedStatus.Conditions)
+	resStatus.Conditions = make([]v1alpha1.Condition, 0, len(cachedStatus.Conditions)+1)
+	resStatus.Conditions = append(resStatus.Conditions, controllerstatus.MergeConditions(existing, cachedStatus.Conditions)...)
 	resStatus.SourceHash = cachedStatus.SourceHash
-	cond := ownershipCondition(id, cachedStatus.InventoryID)
+	cond := ownershipCondition(id, cachedStatus.InventoryID, existing)
 	if cond != nil {
 		resStatus.Conditions = append(resStatus.Conditions, *cond)
 	}
 }
 
-func aggregateResourceStatuses(statuses []v1alpha1.ResourceStatus) v1alpha1.Condition {
+// aggregateResourceStatuses returns the Stalled condition of a group whose
+// resources have the given statuses. The resources which exceeded their
+// progress deadline are treated as failed.
+func aggregateResourceStatuses(statuses []v1alpha1.ResourceStatus, exceeded []string) v1alpha1.Condition {
 	failedResources := []string{}
 	for _, status := range statuses {
 		if status.Status == v1alpha1.Failed {
@@ -394,23 +729,76 @@ func aggregateResourceStatuses(statuses []v1alpha1.ResourceStatus) v1alpha1.Cond
 			failedResources = append(failedResources, resStr)
 		}
 	}
-	if len(failedResources) > 0 {
+	var exceededMsg string
+	if len(exceeded) > 0 {
+		exceededMsg = progressDeadlineExceededMsgPrefix + strings.Join(exceeded, ", ")
+	}
+	switch {
+	case len(failedResources) > 0 && len(exceeded) > 0:
+		return newStalledCondition(v1alpha1.TrueConditionStatus, ComponentFailed,
+			componentFailedMsgPrefix+strings.Join(failedResources, ", ")+"; "+exceededMsg)
+	case len(failedResources) > 0:
 		return newStalledCondition(v1alpha1.TrueConditionStatus, ComponentFailed,
 			componentFailedMsgPrefix+strings.Join(failedResources, ", "))
+	case len(exceeded) > 0:
+		return newStalledCondition(v1alpha1.TrueConditionStatus, ProgressDeadlineExceeded, exceededMsg)
 	}
 	return newStalledCondition(v1alpha1.FalseConditionStatus, FinishReconciling, finishReconcilingMsg)
 }
 
+// withClusterStatuses returns the statuses of the resources of the group
+// followed by the statuses of its resources in the member clusters.
+func withClusterStatuses(statuses []v1alpha1.ResourceStatus, clusterStatuses []v1alpha1.ClusterStatus) []v1alpha1.ResourceStatus {
+	if len(clusterStatuses) == 0 {
+		return statuses
+	}
+	result := make([]v1alpha1.ResourceStatus, 0, len(statuses))
+	result = append(result, statuses...)
+	for _, cs := range clusterStatuses {
+		result = append(result, cs.ResourceStatuses...)
+	}
+	return result
+}
+
+// withClusterConditions folds the Stalled conditions of the member clusters,
+// e.g. which are not connected, into the Stalled condition of the group.
+func withClusterConditions(stalled v1alpha1.Condition, clusterStatuses []v1alpha1.ClusterStatus) v1alpha1.Condition {
+	var reason string
+	var clusters []string
+	for _, cs := range clusterStatuses {
+		for _, cond := range cs.Conditions {
+			if cond.Type != v1alpha1.Stalled || cond.Status != v1alpha1.TrueConditionStatus {
+				continue
+			}
+			if reason == "" {
+				reason = cond.Reason
+			}
+			clusters = append(clusters, fmt.Sprintf("%s (%s)", cs.Cluster, cond.Reason))
+		}
+	}
+	if len(clusters) == 0 {
+		return stalled
+	}
+	msg := clusterStalledMsgPrefix + strings.Join(clusters, ", ")
+	if stalled.Status == v1alpha1.TrueConditionStatus {
+		return newStalledCondition(v1alpha1.TrueConditionStatus, stalled.Reason, stalled.Message+"; "+msg)
+	}
+	return newStalledCondition(v1alpha1.TrueConditionStatus, reason, msg)
+}
+
 // NewRGController creates a new ResourceGroup controller and registers it with
 // the provided manager.
-func NewRGController(mgr ctrl.Manager, channel chan event.GenericEvent, logger logr.Logger,
-	resolver *typeresolver.TypeResolver, resMap *resourcemap.ResourceMap, duration time.Duration) error {
+func NewRGController(mgr ctrl.Manager, events *eventbus.Bus, logger logr.Logger,
+	resolver *typeresolver.TypeResolver, resMap *resourcemap.ResourceMap, clusterHub *hub.Hub, duration time.Duration, opts Options) error {
 	r := &reconciler{
-		Client:   mgr.GetClient(),
-		log:      logger,
-		scheme:   mgr.GetScheme(),
-		resolver: resolver,
-		resMap:   resMap,
+		Client:    mgr.GetClient(),
+		log:       logger,
+		scheme:    mgr.GetScheme(),
+		resolver:  resolver,
+		resMap:    resMap,
+		apiReader: mgr.GetAPIReader(),
+		hub:       clusterHub,
+		opts:      opts,
 	}
 
 	c, err := controller.New(v1alpha1.ResourceGroupKind, mgr, controller.Options{
@@ -421,7 +809,7 @@ func NewRGController(mgr ctrl.Manager, channel chan event.GenericEvent, logger l
 		return err
 	}
 
-	err = c.Watch(&source.Channel{Source: channel}, handler.NewThrottler(duration))
+	err = c.Watch(events, handler.NewThrottler(duration))
 	if err != nil {
 		return err
 	}
@@ -435,7 +823,11 @@ func getInventoryID(labels map[string]string) string {
 	return labels[common.InventoryLabel]
 }
 
-func ownershipCondition(id, inv string) *v1alpha1.Condition {
+// ownershipCondition returns the Ownership condition of a resource whose owning
+// inventory inv does not match the inventory id of the ResourceGroup, or nil if
+// they match. The LastTransitionTime of the existing Ownership condition is
+// preserved if its status did not change.
+func ownershipCondition(id, inv string, existing []v1alpha1.Condition) *v1alpha1.Condition {
 	if id == inv {
 		return nil
 	}
@@ -454,6 +846,7 @@ func ownershipCondition(id, inv string) *v1alpha1.Condition {
 		c.Message = "This object is not owned by any inventory object. " +
 			"The status for the current object may not reflect the specification for it in current ResourceGroup."
 	}
+	*c = controllerstatus.MergeConditions(existing, []v1alpha1.Condition{*c})[0]
 	return c
 }
 
@@ -20,18 +20,22 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-logr/logr"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/goleak"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/controllers/eventbus"
 	"kpt.dev/resourcegroup/controllers/resourcemap"
+	controllerstatus "kpt.dev/resourcegroup/controllers/status"
 	"kpt.dev/resourcegroup/controllers/typeresolver"
 	"sigs.k8s.io/cli-utils/pkg/common"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
 
@@ -41,7 +45,7 @@ var c client.Client
 var ctx context.Context
 
 func TestReconcile(t *testing.T) {
-	var channelKpt chan event.GenericEvent
+	var events *eventbus.Bus
 	var namespace = metav1.NamespaceDefault
 
 	// Setup the Manager
@@ -53,11 +57,11 @@ func TestReconcile(t *testing.T) {
 	ctx = context.WithValue(context.TODO(), contextResourceGroupControllerKey, logger)
 
 	// Setup the controller
-	channelKpt = make(chan event.GenericEvent)
+	events = eventbus.New(0)
 	resolver, err := typeresolver.NewTypeResolver(mgr, logger)
 	assert.NoError(t, err)
 	resMap := resourcemap.NewResourceMap()
-	err = NewRGController(mgr, channelKpt, logger, resolver, resMap, 0)
+	err = NewRGController(mgr, events, logger, resolver, resMap, nil, 0, DefaultOptions())
 	assert.NoError(t, err)
 
 	// Start the manager
@@ -95,8 +99,8 @@ func TestReconcile(t *testing.T) {
 	assert.NoError(t, err)
 	verifyClusterResourceGroup(t, updatedResgroupKpt, 1, 0, v1alpha1.ResourceGroupStatus{})
 
-	// Push an event to the channel, which will cause trigger a reconciliation for resgroup
-	channelKpt <- event.GenericEvent{Object: resgroupKpt}
+	// Push an event to the event bus, which will cause trigger a reconciliation for resgroup
+	events.Publish(ctx, resgroupNamespacedName)
 	time.Sleep(5 * time.Second)
 
 	// Verify that the reconciliation modifies the ResourceGroupStatus field correctly
@@ -107,6 +111,11 @@ func TestReconcile(t *testing.T) {
 		Conditions: []v1alpha1.Condition{
 			newReconcilingCondition(v1alpha1.FalseConditionStatus, FinishReconciling, finishReconcilingMsg),
 			newStalledCondition(v1alpha1.FalseConditionStatus, FinishReconciling, finishReconcilingMsg),
+			newBlockedCondition(v1alpha1.FalseConditionStatus, DependenciesReady, ""),
+			newDriftedCondition(v1alpha1.UnknownConditionStatus, RevisionUnknown, ""),
+			newReadFailedCondition(v1alpha1.FalseConditionStatus, ResourcesRead, ""),
+			newReadyCondition(v1alpha1.TrueConditionStatus, ResourcesReady, ""),
+			newWatchForbiddenCondition(v1alpha1.FalseConditionStatus, WatchesAllowed, ""),
 		},
 	}
 	verifyClusterResourceGroup(t, updatedResgroupKpt, 1, 0, expectedStatus)
@@ -136,7 +145,7 @@ func TestReconcile(t *testing.T) {
 	assert.NoError(t, err)
 	time.Sleep(5 * time.Second)
 
-	channelKpt <- event.GenericEvent{Object: resgroupKpt}
+	events.Publish(ctx, resgroupNamespacedName)
 	time.Sleep(5 * time.Second)
 
 	// Verify that the reconciliation modifies the ResourceGroupStatus field correctly
@@ -157,6 +166,11 @@ func TestReconcile(t *testing.T) {
 		Conditions: []v1alpha1.Condition{
 			newReconcilingCondition(v1alpha1.FalseConditionStatus, FinishReconciling, finishReconcilingMsg),
 			newStalledCondition(v1alpha1.FalseConditionStatus, FinishReconciling, finishReconcilingMsg),
+			newBlockedCondition(v1alpha1.FalseConditionStatus, DependenciesReady, ""),
+			newDriftedCondition(v1alpha1.UnknownConditionStatus, RevisionUnknown, ""),
+			newReadFailedCondition(v1alpha1.FalseConditionStatus, ResourcesRead, ""),
+			newReadyCondition(v1alpha1.FalseConditionStatus, ResourcesNotReady, ""),
+			newWatchForbiddenCondition(v1alpha1.FalseConditionStatus, WatchesAllowed, ""),
 		},
 	}
 	verifyClusterResourceGroup(t, updatedResgroupKpt, 2, 2, expectedStatus)
@@ -167,7 +181,7 @@ func TestReconcile(t *testing.T) {
 			Name:      res2.Name,
 			Namespace: res2.Namespace,
 			Annotations: map[string]string{
-				owningInventoryKey: "other",
+				controllerstatus.DefaultOwningInventoryKey: "other",
 			},
 		},
 		Spec: corev1.PodSpec{
@@ -194,7 +208,7 @@ func TestReconcile(t *testing.T) {
 		ObjectMeta: metav1.ObjectMeta{
 			Name: res1.Name,
 			Annotations: map[string]string{
-				owningInventoryKey: "group0",
+				controllerstatus.DefaultOwningInventoryKey: "group0",
 			},
 		},
 	}
@@ -207,7 +221,7 @@ func TestReconcile(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, corev1.NamespaceActive, updatedNS.Status.Phase)
 
-	channelKpt <- event.GenericEvent{Object: resgroupKpt}
+	events.Publish(ctx, resgroupNamespacedName)
 	time.Sleep(5 * time.Second)
 
 	// Verify that the reconciliation modifies the ResourceGroupStatus field correctly
@@ -236,6 +250,11 @@ func TestReconcile(t *testing.T) {
 		Conditions: []v1alpha1.Condition{
 			newReconcilingCondition(v1alpha1.FalseConditionStatus, FinishReconciling, finishReconcilingMsg),
 			newStalledCondition(v1alpha1.FalseConditionStatus, FinishReconciling, finishReconcilingMsg),
+			newBlockedCondition(v1alpha1.FalseConditionStatus, DependenciesReady, ""),
+			newDriftedCondition(v1alpha1.UnknownConditionStatus, RevisionUnknown, ""),
+			newReadFailedCondition(v1alpha1.FalseConditionStatus, ResourcesRead, ""),
+			newReadyCondition(v1alpha1.FalseConditionStatus, ResourcesInProgress, ""),
+			newWatchForbiddenCondition(v1alpha1.FalseConditionStatus, WatchesAllowed, ""),
 		},
 	}
 	verifyClusterResourceGroup(t, updatedResgroupKpt, 2, 2, expectedStatus)
@@ -255,7 +274,7 @@ func TestReconcile(t *testing.T) {
 
 	verifyClusterResourceGroup(t, updatedResgroupKpt, 3, 1, expectedStatus)
 
-	channelKpt <- event.GenericEvent{Object: resgroupKpt}
+	events.Publish(ctx, resgroupNamespacedName)
 	time.Sleep(5 * time.Second)
 
 	// Verify that the reconciliation modifies the ResourceGroupStatus field correctly
@@ -272,6 +291,11 @@ func TestReconcile(t *testing.T) {
 		Conditions: []v1alpha1.Condition{
 			newReconcilingCondition(v1alpha1.FalseConditionStatus, FinishReconciling, finishReconcilingMsg),
 			newStalledCondition(v1alpha1.FalseConditionStatus, FinishReconciling, finishReconcilingMsg),
+			newBlockedCondition(v1alpha1.FalseConditionStatus, DependenciesReady, ""),
+			newDriftedCondition(v1alpha1.UnknownConditionStatus, RevisionUnknown, ""),
+			newReadFailedCondition(v1alpha1.FalseConditionStatus, ResourcesRead, ""),
+			newReadyCondition(v1alpha1.TrueConditionStatus, ResourcesReady, ""),
+			newWatchForbiddenCondition(v1alpha1.FalseConditionStatus, WatchesAllowed, ""),
 		},
 	}
 	verifyClusterResourceGroup(t, updatedResgroupKpt, 3, 1, expectedStatus)
@@ -337,6 +361,7 @@ func TestAggregateResourceStatuses(t *testing.T) {
 	}
 	tests := map[string]struct {
 		input           []v1alpha1.ResourceStatus
+		exceeded        []string
 		expectedType    v1alpha1.ConditionType
 		expectedStatus  v1alpha1.ConditionStatus
 		expectedReason  string
@@ -356,6 +381,22 @@ func TestAggregateResourceStatuses(t *testing.T) {
 			expectedReason:  ComponentFailed,
 			expectedMessage: componentFailedMsgPrefix + "group1/kind1/ns1/name1, group2/kind2/ns2/name2",
 		},
+		"should return a True Stalled condition with a component exceeding its progress deadline": {
+			input:           []v1alpha1.ResourceStatus{currentStatus, inProgressStatus},
+			exceeded:        []string{"apps/Deployment/ns1/app"},
+			expectedType:    v1alpha1.Stalled,
+			expectedStatus:  v1alpha1.TrueConditionStatus,
+			expectedReason:  ProgressDeadlineExceeded,
+			expectedMessage: progressDeadlineExceededMsgPrefix + "apps/Deployment/ns1/app",
+		},
+		"should return a True Stalled condition with failed and exceeding components": {
+			input:           []v1alpha1.ResourceStatus{failedStatus1, inProgressStatus},
+			exceeded:        []string{"apps/Deployment/ns1/app"},
+			expectedType:    v1alpha1.Stalled,
+			expectedStatus:  v1alpha1.TrueConditionStatus,
+			expectedReason:  ComponentFailed,
+			expectedMessage: componentFailedMsgPrefix + "group1/kind1/ns1/name1; " + progressDeadlineExceededMsgPrefix + "apps/Deployment/ns1/app",
+		},
 		"should return a False Stalled condition": {
 			input: []v1alpha1.ResourceStatus{currentStatus,
 				inProgressStatus, unknownStatus, terminatingStatus},
@@ -367,7 +408,7 @@ func TestAggregateResourceStatuses(t *testing.T) {
 	}
 	for name, tc := range tests {
 		t.Run(fmt.Sprintf("aggregateResourceStatuses %s", name), func(t *testing.T) {
-			cond := aggregateResourceStatuses(tc.input)
+			cond := aggregateResourceStatuses(tc.input, tc.exceeded)
 			assert.Equal(t, tc.expectedType, cond.Type)
 			assert.Equal(t, tc.expectedStatus, cond.Status)
 			assert.Equal(t, tc.expectedReason, cond.Reason)
@@ -376,6 +417,30 @@ func TestAggregateResourceStatuses(t *testing.T) {
 	}
 }
 
+func TestWithClusterConditions(t *testing.T) {
+	notConnected := v1alpha1.ClusterStatus{
+		Cluster: "member1",
+		Conditions: []v1alpha1.Condition{
+			{Type: v1alpha1.Stalled, Status: v1alpha1.TrueConditionStatus, Reason: "ClusterNotConnected"},
+		},
+	}
+	connected := v1alpha1.ClusterStatus{Cluster: "member2"}
+
+	notStalled := newStalledCondition(v1alpha1.FalseConditionStatus, FinishReconciling, finishReconcilingMsg)
+	assert.Equal(t, notStalled, withClusterConditions(notStalled, []v1alpha1.ClusterStatus{connected}))
+
+	cond := withClusterConditions(notStalled, []v1alpha1.ClusterStatus{notConnected, connected})
+	assert.Equal(t, v1alpha1.TrueConditionStatus, cond.Status)
+	assert.Equal(t, "ClusterNotConnected", cond.Reason)
+	assert.Equal(t, clusterStalledMsgPrefix+"member1 (ClusterNotConnected)", cond.Message)
+
+	failed := newStalledCondition(v1alpha1.TrueConditionStatus, ComponentFailed, componentFailedMsgPrefix+"apps/Deployment/ns1/app")
+	cond = withClusterConditions(failed, []v1alpha1.ClusterStatus{notConnected})
+	assert.Equal(t, v1alpha1.TrueConditionStatus, cond.Status)
+	assert.Equal(t, ComponentFailed, cond.Reason)
+	assert.Equal(t, componentFailedMsgPrefix+"apps/Deployment/ns1/app; "+clusterStalledMsgPrefix+"member1 (ClusterNotConnected)", cond.Message)
+}
+
 func TestReconcileTimeout(t *testing.T) {
 	tests := map[string]struct {
 		resourceCount int
@@ -470,3 +535,130 @@ func TestActuationStatusToLegacy(t *testing.T) {
 		})
 	}
 }
+
+// blockingClient is a client whose Get calls only return when
+// the context of the request is done.
+type blockingClient struct {
+	client.Client
+}
+
+func (blockingClient) Get(ctx context.Context, _ client.ObjectKey, _ client.Object, _ ...client.GetOption) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestEndReconcilingStatusCanceled(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	deploymentGK := schema.GroupKind{Group: "apps", Kind: "Deployment"}
+	r := &reconciler{
+		Client: blockingClient{},
+		log:    logr.Discard(),
+		resolver: typeresolver.NewFakeTypeResolver(map[schema.GroupKind]schema.GroupVersionKind{
+			deploymentGK: deploymentGK.WithVersion("v1"),
+		}),
+		resMap: resourcemap.NewResourceMap(),
+	}
+	spec := v1alpha1.ResourceGroupSpec{
+		Resources: []v1alpha1.ObjMetadata{
+			{
+				Name:      "deployment",
+				Namespace: "default",
+				GroupKind: v1alpha1.GroupKind{Group: "apps", Kind: "Deployment"},
+			},
+		},
+	}
+
+	// Cancel the context while the GET for the Deployment is in flight,
+	// as it happens when the controller manager shuts down.
+	ctx, cancel := context.WithCancel(context.Background())
+	timer := time.AfterFunc(100*time.Millisecond, cancel)
+	defer timer.Stop()
+
+	start := time.Now()
+	status := r.endReconcilingStatus(ctx, "", types.NamespacedName{Name: "group", Namespace: "default"},
+		spec, v1alpha1.ResourceGroupStatus{}, 1)
+	assert.Less(t, time.Since(start), getReconcileTimeOut(len(spec.Resources)))
+	assert.Equal(t, int64(0), status.ObservedGeneration)
+	assert.Equal(t, ExceedTimeout, status.Conditions[0].Reason)
+	assert.Equal(t, v1alpha1.TrueConditionStatus, status.Conditions[1].Status)
+}
+
+func TestEndReconcilingStatusIncremental(t *testing.T) {
+	deployment := v1alpha1.ObjMetadata{
+		Name:      "deployment",
+		Namespace: "default",
+		GroupKind: v1alpha1.GroupKind{Group: "apps", Kind: "Deployment"},
+	}
+	service := v1alpha1.ObjMetadata{
+		Name:      "service",
+		Namespace: "default",
+		GroupKind: v1alpha1.GroupKind{Group: "", Kind: "Service"},
+	}
+	subgroup := v1alpha1.GroupMetadata{Name: "subgroup", Namespace: "default"}
+	nn := types.NamespacedName{Name: "group", Namespace: "default"}
+	spec := v1alpha1.ResourceGroupSpec{
+		Resources: []v1alpha1.ObjMetadata{deployment, service},
+		Subgroups: []v1alpha1.GroupMetadata{subgroup},
+	}
+
+	resMap := resourcemap.NewResourceMap()
+	resMap.Reconcile(context.TODO(), nn, append([]v1alpha1.ObjMetadata{deployment, service}, v1alpha1.ToObjMetadata(spec.Subgroups)...), false)
+	resMap.SetStatus(deployment, &resourcemap.CachedStatus{Status: v1alpha1.InProgress})
+	resMap.SetStatus(service, &resourcemap.CachedStatus{Status: v1alpha1.Current})
+	resMap.SetStatus(v1alpha1.ToObjMetadata(spec.Subgroups)[0], &resourcemap.CachedStatus{Status: v1alpha1.Current})
+	r := &reconciler{
+		Client: blockingClient{},
+		log:    logr.Discard(),
+		resMap: resMap,
+	}
+
+	// The first reconciliation computes the whole status.
+	status := r.endReconcilingStatus(context.TODO(), "", nn, spec, v1alpha1.ResourceGroupStatus{}, 1)
+	assert.Equal(t, int64(1), status.ObservedGeneration)
+	assert.Equal(t, v1alpha1.InProgress, status.ResourceStatuses[0].Status)
+	assert.Equal(t, v1alpha1.Current, status.ResourceStatuses[1].Status)
+	assert.Equal(t, v1alpha1.Current, status.SubgroupStatuses[0].Status)
+
+	// Only the changed entries are recomputed afterwards. The cached status of
+	// the Service is modified without recording a change to verify that.
+	status.ResourceStatuses[0].Actuation = v1alpha1.ActuationSucceeded
+	resMap.SetStatus(deployment, &resourcemap.CachedStatus{Status: v1alpha1.Failed})
+	resMap.GetStatus(service).Status = v1alpha1.Failed
+	status = r.endReconcilingStatus(context.TODO(), "", nn, spec, status, 1)
+	assert.Equal(t, v1alpha1.Failed, status.ResourceStatuses[0].Status)
+	assert.Equal(t, v1alpha1.ActuationSucceeded, status.ResourceStatuses[0].Actuation)
+	assert.Equal(t, v1alpha1.Current, status.ResourceStatuses[1].Status)
+	assert.Equal(t, v1alpha1.Current, status.SubgroupStatuses[0].Status)
+	assert.Equal(t, ComponentFailed, status.Conditions[1].Reason)
+
+	// A new generation requires a full recomputation.
+	status = r.endReconcilingStatus(context.TODO(), "", nn, spec, status, 2)
+	assert.Equal(t, int64(2), status.ObservedGeneration)
+	assert.Equal(t, v1alpha1.Failed, status.ResourceStatuses[1].Status)
+}
+
+func TestEndReconcilingStatusOrphans(t *testing.T) {
+	nn := types.NamespacedName{Namespace: "default", Name: "group"}
+	resMap := resourcemap.NewResourceMap()
+	resMap.SetInventoryID(nn, "inv")
+	var orphans []v1alpha1.ObjMetadata
+	for i := 0; i <= maxOrphans; i++ {
+		orphans = append(orphans, v1alpha1.ObjMetadata{
+			Namespace: "default",
+			Name:      fmt.Sprintf("cm-%03d", i),
+			GroupKind: v1alpha1.GroupKind{Kind: "ConfigMap"},
+		})
+	}
+	resMap.SetOrphans(map[types.NamespacedName][]v1alpha1.ObjMetadata{nn: orphans}, nil)
+	r := &reconciler{
+		Client: blockingClient{},
+		log:    logr.Discard(),
+		resMap: resMap,
+	}
+
+	// Only the first orphans are listed, but all of them are counted.
+	status := r.endReconcilingStatus(context.TODO(), "", nn, v1alpha1.ResourceGroupSpec{}, v1alpha1.ResourceGroupStatus{}, 1)
+	assert.Equal(t, orphans[:maxOrphans], status.Orphans)
+	assert.Equal(t, maxOrphans+1, status.OrphanCount)
+}
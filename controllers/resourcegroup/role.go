@@ -0,0 +1,71 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroup
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/controllers/rbac"
+)
+
+//nolint:revive // TODO: add comments for public constants and enable linting
+const (
+	KindsOutsideRole = "KindsOutsideRole"
+	KindsInRole      = "KindsInRole"
+	RoleNotRead      = "RoleNotRead"
+)
+
+func newOutsideRoleCondition(status v1alpha1.ConditionStatus, reason, message string) v1alpha1.Condition {
+	return v1alpha1.Condition{
+		Type:               v1alpha1.OutsideRole,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: metav1.Time{Time: time.Now().UTC()},
+	}
+}
+
+// outsideRoleCondition returns the OutsideRole condition of a group with the
+// given spec. It lists the kinds of its members whose resources the
+// least-privilege ClusterRole does not grant the controller access to. The kinds which
+// are not served by the API server are ignored, since their resources do not exist.
+func (r *reconciler) outsideRoleCondition(ctx context.Context, spec v1alpha1.ResourceGroupSpec) v1alpha1.Condition {
+	role := &rbacv1.ClusterRole{}
+	if err := r.Get(ctx, client.ObjectKey{Name: r.opts.LeastPrivilegeRole}, role); err != nil {
+		return newOutsideRoleCondition(v1alpha1.UnknownConditionStatus, RoleNotRead,
+			fmt.Sprintf("failed to get the ClusterRole %s: %v", r.opts.LeastPrivilegeRole, err))
+	}
+	var outside []string
+	for _, gk := range memberGroupKinds(spec) {
+		gvr, found := r.resolver.ResolveResource(gk)
+		if found && !rbac.Allows(role.Rules, gvr.GroupResource()) {
+			outside = append(outside, gk.String())
+		}
+	}
+	if len(outside) == 0 {
+		return newOutsideRoleCondition(v1alpha1.FalseConditionStatus, KindsInRole,
+			fmt.Sprintf("the ClusterRole %s grants access to the kinds of all the resources", r.opts.LeastPrivilegeRole))
+	}
+	return newOutsideRoleCondition(v1alpha1.TrueConditionStatus, KindsOutsideRole,
+		fmt.Sprintf("the ClusterRole %s does not grant access to %d kinds of resources: %s",
+			r.opts.LeastPrivilegeRole, len(outside), truncatedList(outside)))
+}
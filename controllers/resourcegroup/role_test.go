@@ -0,0 +1,91 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroup
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/controllers/rbac"
+	"kpt.dev/resourcegroup/controllers/resourcemap"
+	"kpt.dev/resourcegroup/controllers/typeresolver"
+	"kpt.dev/resourcegroup/pkg/groupstatus"
+)
+
+func TestOutsideRoleCondition(t *testing.T) {
+	deploymentGK := schema.GroupKind{Group: "apps", Kind: "Deployment"}
+	serviceGK := schema.GroupKind{Kind: "Service"}
+	spec := v1alpha1.ResourceGroupSpec{
+		Resources: []v1alpha1.ObjMetadata{
+			{Namespace: "ns1", Name: "app", GroupKind: v1alpha1.GroupKind(deploymentGK)},
+			{Namespace: "ns1", Name: "svc", GroupKind: v1alpha1.GroupKind(serviceGK)},
+			{Namespace: "ns1", Name: "widget", GroupKind: v1alpha1.GroupKind{Group: "example.com", Kind: "Widget"}},
+		},
+	}
+	role := rbac.NewClusterRole("least-privilege", []schema.GroupResource{{Resource: "services"}})
+	r := &reconciler{
+		Client: fake.NewClientBuilder().WithObjects(role).Build(),
+		resolver: typeresolver.NewFakeTypeResolver(map[schema.GroupKind]schema.GroupVersionKind{
+			deploymentGK: deploymentGK.WithVersion("v1"),
+			serviceGK:    serviceGK.WithVersion("v1"),
+		}),
+	}
+
+	// The kinds which are not served, e.g. Widget, are ignored.
+	r.opts.LeastPrivilegeRole = "least-privilege"
+	cond := r.outsideRoleCondition(context.TODO(), spec)
+	assert.Equal(t, v1alpha1.OutsideRole, cond.Type)
+	assert.Equal(t, v1alpha1.TrueConditionStatus, cond.Status)
+	assert.Equal(t, KindsOutsideRole, cond.Reason)
+	assert.Equal(t, "the ClusterRole least-privilege does not grant access to 1 kinds of resources: Deployment.apps", cond.Message)
+
+	role.Rules = append(role.Rules, rbacv1.PolicyRule{APIGroups: []string{"apps"}, Resources: []string{"*"}, Verbs: []string{"*"}})
+	assert.NoError(t, r.Update(context.TODO(), role))
+	cond = r.outsideRoleCondition(context.TODO(), spec)
+	assert.Equal(t, v1alpha1.FalseConditionStatus, cond.Status)
+	assert.Equal(t, KindsInRole, cond.Reason)
+
+	r.opts.LeastPrivilegeRole = "missing"
+	cond = r.outsideRoleCondition(context.TODO(), spec)
+	assert.Equal(t, v1alpha1.UnknownConditionStatus, cond.Status)
+	assert.Equal(t, RoleNotRead, cond.Reason)
+}
+
+func TestEndReconcilingStatusOutsideRole(t *testing.T) {
+	role := &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "least-privilege"}}
+	r := &reconciler{
+		Client:   fake.NewClientBuilder().WithObjects(role).Build(),
+		resolver: typeresolver.NewFakeTypeResolver(nil),
+		resMap:   resourcemap.NewResourceMap(),
+	}
+
+	status := r.endReconcilingStatus(context.TODO(), "", types.NamespacedName{Namespace: "ns1", Name: "group"}, v1alpha1.ResourceGroupSpec{}, v1alpha1.ResourceGroupStatus{}, 1)
+	_, found := groupstatus.GetCondition(status.Conditions, v1alpha1.OutsideRole)
+	assert.False(t, found)
+
+	r.opts.LeastPrivilegeRole = role.Name
+	status = r.endReconcilingStatus(context.TODO(), "", types.NamespacedName{Namespace: "ns1", Name: "group"}, v1alpha1.ResourceGroupSpec{}, v1alpha1.ResourceGroupStatus{}, 1)
+	cond, found := groupstatus.GetCondition(status.Conditions, v1alpha1.OutsideRole)
+	assert.True(t, found)
+	assert.Equal(t, KindsInRole, cond.Reason)
+}
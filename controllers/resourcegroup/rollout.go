@@ -0,0 +1,63 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroup
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/controllers/metrics"
+	"kpt.dev/resourcegroup/pkg/groupstatus"
+)
+
+// carryOverRolloutTimes copies the rollout fields of status into newStatus.
+func carryOverRolloutTimes(newStatus *v1alpha1.ResourceGroupStatus, status v1alpha1.ResourceGroupStatus) {
+	newStatus.GenerationChangedTime = status.GenerationChangedTime
+	newStatus.ReadyGeneration = status.ReadyGeneration
+	newStatus.LastReadyTime = status.LastReadyTime
+}
+
+// setRolloutTimes sets the rollout fields of newStatus, computed for the
+// given generation from status, the previous status of the group, and from
+// the Ready condition of newStatus.
+//
+// generationChangedTime is reset when the generation differs from the
+// observed generation of the previous status. The first time the group is
+// Ready for its generation, readyGeneration and lastReadyTime are set, and
+// the time taken to become ready is recorded in the TimeToReady metric, unless
+// the time the generation changed is unknown, e.g. for the groups reconciled
+// by a controller which did not set generationChangedTime.
+func setRolloutTimes(ctx context.Context, nn types.NamespacedName, newStatus *v1alpha1.ResourceGroupStatus,
+	status v1alpha1.ResourceGroupStatus, generation int64, now time.Time) {
+	carryOverRolloutTimes(newStatus, status)
+	changed := generation != status.ObservedGeneration
+	known := changed || status.GenerationChangedTime != nil
+	if changed || status.GenerationChangedTime == nil {
+		newStatus.GenerationChangedTime = &metav1.Time{Time: now.UTC()}
+	}
+	ready, found := groupstatus.GetCondition(newStatus.Conditions, v1alpha1.Ready)
+	if !found || ready.Status != v1alpha1.TrueConditionStatus || newStatus.ReadyGeneration == generation {
+		return
+	}
+	newStatus.ReadyGeneration = generation
+	newStatus.LastReadyTime = &metav1.Time{Time: now.UTC()}
+	if known {
+		metrics.RecordTimeToReady(ctx, nn, now.Sub(newStatus.GenerationChangedTime.Time))
+	}
+}
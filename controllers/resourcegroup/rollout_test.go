@@ -0,0 +1,81 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+)
+
+func TestSetRolloutTimes(t *testing.T) {
+	nn := types.NamespacedName{Namespace: "ns1", Name: "group"}
+	notReady := []v1alpha1.Condition{newReadyCondition(v1alpha1.FalseConditionStatus, ResourcesInProgress, "")}
+	ready := []v1alpha1.Condition{newReadyCondition(v1alpha1.TrueConditionStatus, ResourcesReady, "")}
+	start := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	// The first generation is observed, and its resources are not ready yet.
+	status := v1alpha1.ResourceGroupStatus{}
+	newStatus := v1alpha1.ResourceGroupStatus{ObservedGeneration: 1, Conditions: notReady}
+	setRolloutTimes(context.TODO(), nn, &newStatus, status, 1, start)
+	assert.Equal(t, start, newStatus.GenerationChangedTime.Time)
+	assert.Zero(t, newStatus.ReadyGeneration)
+	assert.Nil(t, newStatus.LastReadyTime)
+
+	// The resources become ready for the first generation.
+	status = newStatus
+	newStatus = v1alpha1.ResourceGroupStatus{ObservedGeneration: 1, Conditions: ready}
+	setRolloutTimes(context.TODO(), nn, &newStatus, status, 1, start.Add(time.Minute))
+	assert.Equal(t, start, newStatus.GenerationChangedTime.Time)
+	assert.Equal(t, int64(1), newStatus.ReadyGeneration)
+	assert.Equal(t, start.Add(time.Minute), newStatus.LastReadyTime.Time)
+
+	// The ready time is kept while the generation does not change.
+	status = newStatus
+	newStatus = v1alpha1.ResourceGroupStatus{ObservedGeneration: 1, Conditions: ready}
+	setRolloutTimes(context.TODO(), nn, &newStatus, status, 1, start.Add(time.Hour))
+	assert.Equal(t, start.Add(time.Minute), newStatus.LastReadyTime.Time)
+
+	// A new generation is observed, and its resources are not ready yet.
+	status = newStatus
+	newStatus = v1alpha1.ResourceGroupStatus{ObservedGeneration: 2, Conditions: notReady}
+	setRolloutTimes(context.TODO(), nn, &newStatus, status, 2, start.Add(2*time.Hour))
+	assert.Equal(t, start.Add(2*time.Hour), newStatus.GenerationChangedTime.Time)
+	assert.Equal(t, int64(1), newStatus.ReadyGeneration)
+	assert.Equal(t, start.Add(time.Minute), newStatus.LastReadyTime.Time)
+
+	status = newStatus
+	newStatus = v1alpha1.ResourceGroupStatus{ObservedGeneration: 2, Conditions: ready}
+	setRolloutTimes(context.TODO(), nn, &newStatus, status, 2, start.Add(3*time.Hour))
+	assert.Equal(t, start.Add(2*time.Hour), newStatus.GenerationChangedTime.Time)
+	assert.Equal(t, int64(2), newStatus.ReadyGeneration)
+	assert.Equal(t, start.Add(3*time.Hour), newStatus.LastReadyTime.Time)
+}
+
+func TestStartReconcilingStatusKeepsRolloutTimes(t *testing.T) {
+	changed := metav1.Now()
+	status := v1alpha1.ResourceGroupStatus{ObservedGeneration: 2, GenerationChangedTime: &changed, ReadyGeneration: 1}
+	r := &reconciler{}
+	newStatus := r.startReconcilingStatus(status)
+	assert.Equal(t, status.GenerationChangedTime, newStatus.GenerationChangedTime)
+	assert.Equal(t, int64(1), newStatus.ReadyGeneration)
+	assert.Nil(t, newStatus.LastReadyTime)
+}
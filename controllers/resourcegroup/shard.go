@@ -0,0 +1,162 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/pkg/groupstatus"
+)
+
+// statusShardName returns the name of the i-th status shard of a ResourceGroup.
+func statusShardName(group string, i int) string {
+	return ownedObjectName(group, fmt.Sprintf("-status-%d", i))
+}
+
+// ownedObjectName returns the name of an object owned by a ResourceGroup,
+// made of the name of the ResourceGroup truncated to keep the name valid,
+// followed by suffix.
+func ownedObjectName(group, suffix string) string {
+	if max := validation.DNS1123SubdomainMaxLength - len(suffix); len(group) > max {
+		group = strings.TrimRight(group[:max], ".-")
+	}
+	return group + suffix
+}
+
+// statusShards returns the status shards of at most size resource statuses
+// needed to store the given resource statuses, or nil if they can be stored
+// in the ResourceGroup object. A size of 0 disables the sharding.
+func statusShards(group string, statuses []v1alpha1.ResourceStatus, size int) []v1alpha1.StatusShard {
+	if size <= 0 || len(statuses) <= size {
+		return nil
+	}
+	var shards []v1alpha1.StatusShard
+	for i := 0; i*size < len(statuses); i++ {
+		count := size
+		if remaining := len(statuses) - i*size; remaining < count {
+			count = remaining
+		}
+		shards = append(shards, v1alpha1.StatusShard{
+			Name:  statusShardName(group, i),
+			Count: count,
+		})
+	}
+	return shards
+}
+
+// shardedResourceStatuses returns the resource statuses to apply in the object
+// of a sharded ResourceGroup. They only include the identity and the status of
+// each resource, so that the controller keeps owning the required status field
+// of the entries shared with the applier, while the sourceHash and conditions
+// are only stored in the status shards.
+func shardedResourceStatuses(statuses []v1alpha1.ResourceStatus) []v1alpha1.ResourceStatus {
+	result := make([]v1alpha1.ResourceStatus, len(statuses))
+	for i, res := range statuses {
+		result[i] = v1alpha1.ResourceStatus{
+			ObjMetadata: res.ObjMetadata,
+			Status:      res.Status,
+		}
+	}
+	return result
+}
+
+// ownerReference returns the controller owner reference to resgroup set on
+// the objects written by the controller for it.
+func ownerReference(resgroup *v1alpha1.ResourceGroup) metav1.OwnerReference {
+	return metav1.OwnerReference{
+		APIVersion: v1alpha1.SchemeGroupVersion.String(),
+		Kind:       v1alpha1.ResourceGroupKind,
+		Name:       resgroup.Name,
+		UID:        resgroup.UID,
+		Controller: &[]bool{true}[0],
+	}
+}
+
+// writeStatusShards writes the resource statuses of newStatus into the status
+// shards listed in newStatus. The shards whose content did not change since
+// the status of resgroup was read are skipped.
+func (r *reconciler) writeStatusShards(ctx context.Context, resgroup *v1alpha1.ResourceGroup, newStatus v1alpha1.ResourceGroupStatus) error {
+	oldStatus := resgroup.Status
+	offset := 0
+	for i, shard := range newStatus.StatusShards {
+		statuses := newStatus.ResourceStatuses[offset : offset+shard.Count]
+		oldOffset := offset
+		offset += shard.Count
+		if i < len(oldStatus.StatusShards) && oldStatus.StatusShards[i] == shard &&
+			len(oldStatus.ResourceStatuses) >= oldOffset+shard.Count &&
+			apiequality.Semantic.DeepEqual(oldStatus.ResourceStatuses[oldOffset:oldOffset+shard.Count], statuses) {
+			continue
+		}
+
+		data, err := json.Marshal(statuses)
+		if err != nil {
+			return err
+		}
+		cm := &corev1.ConfigMap{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "ConfigMap",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:       resgroup.Namespace,
+				Name:            shard.Name,
+				OwnerReferences: []metav1.OwnerReference{ownerReference(resgroup)},
+			},
+			Data: map[string]string{
+				groupstatus.StatusShardDataKey: string(data),
+			},
+		}
+		r.log.V(4).Info("writing the status shard", "namespace", cm.Namespace, "name", cm.Name, "count", shard.Count)
+		if err := r.Patch(ctx, cm, client.Apply, client.FieldOwner(FieldManager), client.ForceOwnership); err != nil {
+			return fmt.Errorf("failed to write the status shard %s: %w", shard.Name, err)
+		}
+	}
+	return nil
+}
+
+// deleteStatusShards deletes the status shards of resgroup which are not used anymore.
+func (r *reconciler) deleteStatusShards(ctx context.Context, namespace string, oldShards, newShards []v1alpha1.StatusShard) error {
+	used := make(map[string]bool, len(newShards))
+	for _, shard := range newShards {
+		used[shard.Name] = true
+	}
+	for _, shard := range oldShards {
+		if used[shard.Name] {
+			continue
+		}
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      shard.Name,
+			},
+		}
+		r.log.V(4).Info("deleting the status shard", "namespace", cm.Namespace, "name", cm.Name)
+		if err := r.Delete(ctx, cm); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete the status shard %s: %w", shard.Name, err)
+		}
+	}
+	return nil
+}
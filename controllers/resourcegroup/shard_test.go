@@ -0,0 +1,150 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/pkg/groupstatus"
+)
+
+func shardTestStatuses(count int) []v1alpha1.ResourceStatus {
+	statuses := make([]v1alpha1.ResourceStatus, count)
+	for i := range statuses {
+		statuses[i] = v1alpha1.ResourceStatus{
+			ObjMetadata: v1alpha1.ObjMetadata{
+				Name:      fmt.Sprintf("cm-%d", i),
+				Namespace: "ns1",
+				GroupKind: v1alpha1.GroupKind{Kind: "ConfigMap"},
+			},
+			Status: v1alpha1.Current,
+		}
+	}
+	return statuses
+}
+
+func TestStatusShards(t *testing.T) {
+	assert.Nil(t, statusShards("group", shardTestStatuses(5), 0))
+
+	assert.Nil(t, statusShards("group", shardTestStatuses(2), 2))
+	assert.Equal(t, []v1alpha1.StatusShard{
+		{Name: "group-status-0", Count: 2},
+		{Name: "group-status-1", Count: 2},
+		{Name: "group-status-2", Count: 1},
+	}, statusShards("group", shardTestStatuses(5), 2))
+}
+
+func TestStatusShardName(t *testing.T) {
+	assert.Equal(t, "group-status-3", statusShardName("group", 3))
+
+	long := strings.Repeat("a", 242) + "." + strings.Repeat("b", 12)
+	name := statusShardName(long, 10)
+	assert.Len(t, name, validation.DNS1123SubdomainMaxLength-1)
+	assert.Equal(t, strings.Repeat("a", 242)+"-status-10", name)
+	assert.Empty(t, validation.IsDNS1123Subdomain(name))
+}
+
+// applyRecorder records the objects applied with server-side apply, which is
+// not supported by the fake client, and creates the applied ConfigMaps.
+type applyRecorder struct {
+	client.Client
+	applied       []client.Object
+	appliedStatus []*unstructured.Unstructured
+}
+
+func (c *applyRecorder) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if patch.Type() != types.ApplyPatchType {
+		return c.Client.Patch(ctx, obj, patch, opts...)
+	}
+	c.applied = append(c.applied, obj)
+	return c.Client.Create(ctx, obj.DeepCopyObject().(client.Object))
+}
+
+func (c *applyRecorder) Status() client.SubResourceWriter {
+	return &applyRecorderStatus{SubResourceWriter: c.Client.Status(), recorder: c}
+}
+
+type applyRecorderStatus struct {
+	client.SubResourceWriter
+	recorder *applyRecorder
+}
+
+func (w *applyRecorderStatus) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+	if patch.Type() != types.ApplyPatchType {
+		return w.SubResourceWriter.Patch(ctx, obj, patch, opts...)
+	}
+	w.recorder.appliedStatus = append(w.recorder.appliedStatus, obj.(*unstructured.Unstructured))
+	return nil
+}
+
+func TestUpdateStatusShardedGroup(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, v1alpha1.AddToScheme(scheme))
+	assert.NoError(t, corev1.AddToScheme(scheme))
+	resgroup := &v1alpha1.ResourceGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "group", Namespace: "ns1", UID: "uid"},
+	}
+	c := &applyRecorder{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(resgroup).Build()}
+	r := &reconciler{Client: c, apiReader: c, log: logr.Discard(), opts: Options{StatusShardSize: 2}}
+
+	newStatus := v1alpha1.ResourceGroupStatus{ResourceStatuses: shardTestStatuses(3)}
+	newStatus.ResourceStatuses[1].SourceHash = "1234567"
+	newStatus.ResourceStatuses[1].Conditions = []v1alpha1.Condition{
+		{Type: v1alpha1.Ownership, Status: v1alpha1.UnknownConditionStatus, Reason: v1alpha1.OwnershipEmpty},
+	}
+	assert.NoError(t, r.updateStatusKptGroup(context.TODO(), resgroup, newStatus))
+
+	// The status shards are written with the full resource statuses.
+	assert.Len(t, c.applied, 2)
+	// The ResourceGroup object keeps the required status of each resource, so
+	// the entries shared with the applier stay valid, but not the details.
+	assert.Len(t, c.appliedStatus, 1)
+	resourceStatuses, found, err := unstructured.NestedSlice(c.appliedStatus[0].Object, "status", "resourceStatuses")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Len(t, resourceStatuses, 3)
+	assert.Equal(t, map[string]interface{}{
+		"group":     "",
+		"kind":      "ConfigMap",
+		"namespace": "ns1",
+		"name":      "cm-1",
+		"status":    string(v1alpha1.Current),
+	}, resourceStatuses[1])
+	shards, found, err := unstructured.NestedSlice(c.appliedStatus[0].Object, "status", "statusShards")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Len(t, shards, 2)
+
+	// The full statuses are read back from the shards.
+	resgroup.Status.ResourceStatuses = []v1alpha1.ResourceStatus{}
+	got, err := groupstatus.GetResourceStatuses(context.TODO(), c, resgroup)
+	assert.NoError(t, err)
+	assert.Equal(t, newStatus.ResourceStatuses, got)
+}
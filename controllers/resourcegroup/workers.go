@@ -0,0 +1,60 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroup
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultStatusWorkers is the default value of Options.StatusWorkers.
+const DefaultStatusWorkers = 10
+
+// forEachMember calls compute for every index in [0, n) from at most
+// workers goroutines, and returns once all the calls returned.
+// The remaining indexes are skipped once ctx is done.
+func forEachMember(ctx context.Context, workers, n int, compute func(i int)) {
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		for i := 0; i < n && ctx.Err() == nil; i++ {
+			compute(i)
+		}
+		return
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				compute(i)
+			}
+		}()
+	}
+Send:
+	for i := 0; i < n; i++ {
+		select {
+		case <-ctx.Done():
+			break Send
+		case indexes <- i:
+		}
+	}
+	close(indexes)
+	wg.Wait()
+}
@@ -0,0 +1,112 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcegroup
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/goleak"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/controllers/resourcemap"
+	"kpt.dev/resourcegroup/controllers/typeresolver"
+)
+
+func TestForEachMember(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	var running, maxRunning int32
+	done := make([]bool, 100)
+	forEachMember(context.TODO(), DefaultStatusWorkers, len(done), func(i int) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		done[i] = true
+		atomic.AddInt32(&running, -1)
+	})
+	for i := range done {
+		assert.True(t, done[i], "index %d", i)
+	}
+	assert.LessOrEqual(t, int(maxRunning), DefaultStatusWorkers)
+
+	// The remaining indexes are skipped once the context is done.
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls int32
+	forEachMember(ctx, DefaultStatusWorkers, 1000, func(int) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			cancel()
+		}
+	})
+	assert.Less(t, int(calls), 1000)
+}
+
+// slowClient adds a latency to the GET requests, like a round trip to the
+// API server.
+type slowClient struct {
+	client.Client
+	latency time.Duration
+}
+
+func (c slowClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	time.Sleep(c.latency)
+	return c.Client.Get(ctx, key, obj, opts...)
+}
+
+func benchmarkComputeStatus(b *testing.B, workers int) {
+	cmGK := schema.GroupKind{Kind: "ConfigMap"}
+	builder := fake.NewClientBuilder()
+	var metas []v1alpha1.ObjMetadata
+	for i := 0; i < 200; i++ {
+		name := fmt.Sprintf("cm%d", i)
+		builder.WithObjects(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: name}})
+		metas = append(metas, v1alpha1.ObjMetadata{Namespace: "ns1", Name: name, GroupKind: v1alpha1.GroupKind(cmGK)})
+	}
+	c := slowClient{Client: builder.Build(), latency: time.Millisecond}
+	r := &reconciler{
+		Client:    c,
+		apiReader: c,
+		log:       logr.Discard(),
+		resolver:  typeresolver.NewFakeTypeResolver(map[schema.GroupKind]schema.GroupVersionKind{cmGK: cmGK.WithVersion("v1")}),
+		// Every member missing from the cache is read with a GET.
+		opts: Options{StatusWorkers: workers},
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.resMap = resourcemap.NewResourceMap()
+		r.computeStatus(context.TODO(), "", v1alpha1.ResourceGroupStatus{}, metas, types.NamespacedName{}, true)
+	}
+}
+
+func BenchmarkComputeStatusSequential(b *testing.B) { benchmarkComputeStatus(b, 1) }
+
+func BenchmarkComputeStatusWorkers(b *testing.B) { benchmarkComputeStatus(b, DefaultStatusWorkers) }
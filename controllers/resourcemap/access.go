@@ -0,0 +1,40 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcemap
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// SetForbiddenVerbs records the verbs needed to watch the given GroupKind
+// which the controller is not allowed. No verbs means that the GroupKind can
+// be watched.
+func (m *ResourceMap) SetForbiddenVerbs(gk schema.GroupKind, verbs []string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if len(verbs) == 0 {
+		delete(m.gkToForbiddenVerbs, gk)
+		return
+	}
+	m.gkToForbiddenVerbs[gk] = append([]string(nil), verbs...)
+}
+
+// GetForbiddenVerbs returns the verbs needed to watch the given GroupKind
+// which the controller is not allowed.
+func (m *ResourceMap) GetForbiddenVerbs(gk schema.GroupKind) []string {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.gkToForbiddenVerbs[gk]
+}
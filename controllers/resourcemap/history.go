@@ -0,0 +1,246 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcemap
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+)
+
+// DefaultHistorySize is the default number of status transitions kept per resource group.
+const DefaultHistorySize = 100
+
+// HistoryPath is the path of the HTTP endpoint serving the status transitions.
+const HistoryPath = "/debug/resourcegroups/history"
+
+// Transition records a change of the cached status of a resource.
+type Transition struct {
+	// Resource is the resource whose status changed.
+	Resource v1alpha1.ObjMetadata `json:"resource"`
+	// OldStatus is the status of the resource before the transition.
+	OldStatus v1alpha1.Status `json:"oldStatus"`
+	// NewStatus is the status of the resource after the transition.
+	NewStatus v1alpha1.Status `json:"newStatus"`
+	// Time is the time when the transition was observed.
+	Time metav1.Time `json:"time"`
+	// Reason is the reason of the first condition of the new status, if any.
+	Reason string `json:"reason,omitempty"`
+}
+
+// transitionLog is a ring buffer of the latest status transitions of a resource group.
+type transitionLog struct {
+	entries []Transition
+	// next is the index of entries where the next transition is stored once entries is full.
+	next int
+	// loaded is true once the persisted transitions of the resource group were loaded.
+	loaded bool
+	// recorded is the total number of transitions recorded.
+	recorded uint64
+	// persisted is the value of recorded when the history was last persisted.
+	persisted uint64
+}
+
+// add adds a transition into the log, overwriting the oldest one if the log is full.
+func (l *transitionLog) add(t Transition, size int) {
+	l.recorded++
+	if len(l.entries) < size {
+		l.entries = append(l.entries, t)
+		return
+	}
+	l.entries[l.next] = t
+	l.next = (l.next + 1) % len(l.entries)
+}
+
+// toSlice returns the transitions of the log from the oldest to the newest.
+func (l *transitionLog) toSlice() []Transition {
+	result := make([]Transition, 0, len(l.entries))
+	result = append(result, l.entries[l.next:]...)
+	result = append(result, l.entries[:l.next]...)
+	return result
+}
+
+// newTransition returns the transition of a resource from oldStatus to newStatus,
+// or false if the status did not change. A resource without a cached status,
+// e.g. after the CRD of its kind was re-established, has the Unknown status.
+func newTransition(res resource, oldStatus, newStatus *CachedStatus) (Transition, bool) {
+	if newStatus == nil {
+		return Transition{}, false
+	}
+	old := v1alpha1.Unknown
+	if oldStatus != nil {
+		old = oldStatus.Status
+	}
+	if old == newStatus.Status {
+		return Transition{}, false
+	}
+	t := Transition{
+		Resource:  res,
+		OldStatus: old,
+		NewStatus: newStatus.Status,
+		Time:      metav1.Now(),
+	}
+	if len(newStatus.Conditions) > 0 {
+		t.Reason = newStatus.Conditions[0].Reason
+	}
+	return t, true
+}
+
+// recordTransition records the transition for all the resource groups including res.
+// The caller must hold the lock.
+func (m *ResourceMap) recordTransition(res resource, oldStatus, newStatus *CachedStatus) {
+	if m.historySize <= 0 {
+		return
+	}
+	t, changed := newTransition(res, oldStatus, newStatus)
+	if !changed {
+		return
+	}
+	groups, ok := m.resToResgroups[res]
+	if !ok {
+		return
+	}
+	for group := range groups.data {
+		log, ok := m.resgroupToHistory[group]
+		if !ok {
+			log = &transitionLog{}
+			m.resgroupToHistory[group] = log
+		}
+		log.add(t, m.historySize)
+	}
+}
+
+// History returns the latest status transitions of the resources of the given
+// resource group, from the oldest to the newest.
+func (m *ResourceMap) History(group types.NamespacedName) []Transition {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	if log, ok := m.resgroupToHistory[group]; ok {
+		return log.toSlice()
+	}
+	return nil
+}
+
+// UnpersistedHistory returns the latest status transitions of the given resource
+// group, the version of the history, and whether new transitions were recorded
+// since MarkHistoryPersisted was last called for the group.
+func (m *ResourceMap) UnpersistedHistory(group types.NamespacedName) ([]Transition, uint64, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	log, ok := m.resgroupToHistory[group]
+	if !ok || log.recorded == log.persisted {
+		return nil, 0, false
+	}
+	return log.toSlice(), log.recorded, true
+}
+
+// MarkHistoryPersisted records that the history of the given resource group
+// was persisted up to the given version returned by UnpersistedHistory.
+func (m *ResourceMap) MarkHistoryPersisted(group types.NamespacedName, version uint64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if log, ok := m.resgroupToHistory[group]; ok && version > log.persisted {
+		log.persisted = version
+	}
+}
+
+// HistoryLoaded returns true if the persisted transitions of the given resource
+// group were loaded with LoadHistory.
+func (m *ResourceMap) HistoryLoaded(group types.NamespacedName) bool {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	log, ok := m.resgroupToHistory[group]
+	return ok && log.loaded
+}
+
+// LoadHistory merges the persisted transitions of the given resource group,
+// from the oldest to the newest, before the transitions recorded since the
+// controller started. It only has an effect the first time it is called for
+// the group. The loaded transitions are considered as persisted.
+func (m *ResourceMap) LoadHistory(group types.NamespacedName, transitions []Transition) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	log, ok := m.resgroupToHistory[group]
+	if !ok {
+		log = &transitionLog{}
+		m.resgroupToHistory[group] = log
+	}
+	if log.loaded {
+		return
+	}
+	log.loaded = true
+	if m.historySize <= 0 || len(transitions) == 0 {
+		return
+	}
+	recent := log.toSlice()
+	pending := log.recorded != log.persisted
+	*log = transitionLog{loaded: true}
+	for _, t := range transitions {
+		log.add(t, m.historySize)
+	}
+	for _, t := range recent {
+		log.add(t, m.historySize)
+	}
+	if !pending {
+		log.persisted = log.recorded
+	}
+}
+
+// groupHistory is the history of a resource group served by the history endpoint.
+type groupHistory struct {
+	Namespace   string       `json:"namespace"`
+	Name        string       `json:"name"`
+	Transitions []Transition `json:"transitions"`
+}
+
+// HistoryHandler returns an HTTP handler serving the status transitions of
+// the resource groups in JSON. The namespace and name query parameters
+// restrict the response to the matching resource groups.
+func HistoryHandler(m *ResourceMap) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		namespace := req.URL.Query().Get("namespace")
+		name := req.URL.Query().Get("name")
+
+		m.lock.RLock()
+		result := []groupHistory{}
+		for group, log := range m.resgroupToHistory {
+			if (namespace != "" && group.Namespace != namespace) || (name != "" && group.Name != name) {
+				continue
+			}
+			result = append(result, groupHistory{
+				Namespace:   group.Namespace,
+				Name:        group.Name,
+				Transitions: log.toSlice(),
+			})
+		}
+		m.lock.RUnlock()
+
+		sort.Slice(result, func(i, j int) bool {
+			if result[i].Namespace != result[j].Namespace {
+				return result[i].Namespace < result[j].Namespace
+			}
+			return result[i].Name < result[j].Name
+		})
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
@@ -0,0 +1,190 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcemap
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+)
+
+func TestResourceMapHistory(t *testing.T) {
+	res1 := resource{
+		Namespace: "ns1",
+		Name:      "res1",
+		GroupKind: v1alpha1.GroupKind{Group: "apps", Kind: "Deployment"},
+	}
+	res2 := resource{
+		Namespace: "ns1",
+		Name:      "res2",
+		GroupKind: v1alpha1.GroupKind{Group: "apps", Kind: "Deployment"},
+	}
+	group1 := types.NamespacedName{Namespace: "ns1", Name: "group1"}
+	group2 := types.NamespacedName{Namespace: "ns1", Name: "group2"}
+
+	m := NewResourceMapWithHistorySize(3)
+	m.Reconcile(context.TODO(), group1, []resource{res1, res2}, false)
+	m.Reconcile(context.TODO(), group2, []resource{res2}, false)
+
+	// The initial statuses are recorded as transitions from Unknown.
+	m.SetStatus(res1, &CachedStatus{Status: v1alpha1.Current})
+	history := m.History(group1)
+	assert.Len(t, history, 1)
+	assert.Equal(t, v1alpha1.Unknown, history[0].OldStatus)
+	assert.Equal(t, v1alpha1.Current, history[0].NewStatus)
+	m.SetStatus(res2, &CachedStatus{Status: v1alpha1.Current})
+	assert.Len(t, m.History(group1), 2)
+
+	// An unchanged status is not recorded.
+	m.SetStatus(res1, &CachedStatus{Status: v1alpha1.Current})
+	assert.Len(t, m.History(group1), 2)
+
+	// A status reset to nil, e.g. by the CRD event handler, is recorded again from Unknown.
+	m.SetStatus(res1, nil)
+	m.SetStatus(res1, &CachedStatus{Status: v1alpha1.Current})
+	history = m.History(group1)
+	assert.Len(t, history, 3)
+	assert.Equal(t, v1alpha1.Unknown, history[2].OldStatus)
+
+	m.SetStatus(res2, &CachedStatus{
+		Status:     v1alpha1.Failed,
+		Conditions: []v1alpha1.Condition{{Type: v1alpha1.Stalled, Reason: "CrashLoop"}},
+	})
+	history = m.History(group1)
+	assert.Len(t, history, 3)
+	assert.Equal(t, res2, history[2].Resource)
+	assert.Equal(t, v1alpha1.Current, history[2].OldStatus)
+	assert.Equal(t, v1alpha1.Failed, history[2].NewStatus)
+	assert.Equal(t, "CrashLoop", history[2].Reason)
+	assert.False(t, history[2].Time.IsZero())
+	// The transition is recorded for all the groups including the resource.
+	assert.Len(t, m.History(group2), 2)
+
+	// The oldest transitions are dropped once the history is full.
+	m.SetStatus(res2, &CachedStatus{Status: v1alpha1.Current})
+	m.SetStatus(res1, &CachedStatus{Status: v1alpha1.InProgress})
+	m.SetStatus(res1, &CachedStatus{Status: v1alpha1.Current})
+	history = m.History(group1)
+	assert.Len(t, history, 3)
+	assert.Equal(t, []v1alpha1.Status{v1alpha1.Current, v1alpha1.InProgress, v1alpha1.Current},
+		[]v1alpha1.Status{history[0].NewStatus, history[1].NewStatus, history[2].NewStatus})
+	assert.Equal(t, res2, history[0].Resource)
+	assert.Len(t, m.History(group2), 3)
+
+	// The history is deleted with the group.
+	m.Reconcile(context.TODO(), group2, []resource{}, true)
+	assert.Empty(t, m.History(group2))
+}
+
+func TestResourceMapUnpersistedHistory(t *testing.T) {
+	res := resource{Namespace: "ns1", Name: "res1", GroupKind: v1alpha1.GroupKind{Kind: "ConfigMap"}}
+	group := types.NamespacedName{Namespace: "ns1", Name: "group1"}
+
+	m := NewResourceMap()
+	m.Reconcile(context.TODO(), group, []resource{res}, false)
+	_, _, pending := m.UnpersistedHistory(group)
+	assert.False(t, pending)
+
+	m.SetStatus(res, &CachedStatus{Status: v1alpha1.InProgress})
+	m.SetStatus(res, &CachedStatus{Status: v1alpha1.Current})
+	history, version, pending := m.UnpersistedHistory(group)
+	assert.True(t, pending)
+	assert.Len(t, history, 2)
+
+	m.MarkHistoryPersisted(group, version)
+	_, _, pending = m.UnpersistedHistory(group)
+	assert.False(t, pending)
+
+	m.SetStatus(res, &CachedStatus{Status: v1alpha1.Failed})
+	history, _, pending = m.UnpersistedHistory(group)
+	assert.True(t, pending)
+	assert.Len(t, history, 3)
+}
+
+func TestResourceMapLoadHistory(t *testing.T) {
+	res := resource{Namespace: "ns1", Name: "res1", GroupKind: v1alpha1.GroupKind{Kind: "ConfigMap"}}
+	group := types.NamespacedName{Namespace: "ns1", Name: "group1"}
+	persisted := []Transition{
+		{Resource: res, OldStatus: v1alpha1.Unknown, NewStatus: v1alpha1.InProgress},
+		{Resource: res, OldStatus: v1alpha1.InProgress, NewStatus: v1alpha1.Current},
+	}
+
+	// The loaded transitions of a group without new transitions are not written back.
+	m := NewResourceMapWithHistorySize(3)
+	m.Reconcile(context.TODO(), group, []resource{res}, false)
+	assert.False(t, m.HistoryLoaded(group))
+	m.LoadHistory(group, persisted)
+	assert.True(t, m.HistoryLoaded(group))
+	assert.Equal(t, persisted, m.History(group))
+	_, _, pending := m.UnpersistedHistory(group)
+	assert.False(t, pending)
+
+	// The history is only loaded once.
+	m.LoadHistory(group, persisted[:1])
+	assert.Len(t, m.History(group), 2)
+
+	// The new transitions follow the loaded ones, and the oldest are dropped.
+	m = NewResourceMapWithHistorySize(3)
+	m.Reconcile(context.TODO(), group, []resource{res}, false)
+	m.SetStatus(res, &CachedStatus{Status: v1alpha1.Failed})
+	m.SetStatus(res, &CachedStatus{Status: v1alpha1.Current})
+	m.LoadHistory(group, persisted)
+	history, _, pending := m.UnpersistedHistory(group)
+	assert.True(t, pending)
+	assert.Equal(t, []v1alpha1.Status{v1alpha1.Current, v1alpha1.Failed, v1alpha1.Current},
+		[]v1alpha1.Status{history[0].NewStatus, history[1].NewStatus, history[2].NewStatus})
+}
+
+func TestHistoryHandler(t *testing.T) {
+	res := resource{Namespace: "ns1", Name: "res1", GroupKind: v1alpha1.GroupKind{Kind: "ConfigMap"}}
+	group1 := types.NamespacedName{Namespace: "ns1", Name: "group1"}
+	group2 := types.NamespacedName{Namespace: "ns2", Name: "group2"}
+
+	m := NewResourceMap()
+	m.Reconcile(context.TODO(), group1, []resource{res}, false)
+	m.Reconcile(context.TODO(), group2, []resource{res}, false)
+	m.SetStatus(res, &CachedStatus{Status: v1alpha1.InProgress})
+	m.SetStatus(res, &CachedStatus{Status: v1alpha1.Current})
+
+	get := func(url string) []groupHistory {
+		w := httptest.NewRecorder()
+		HistoryHandler(m).ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		var result []groupHistory
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		return result
+	}
+
+	result := get(HistoryPath)
+	assert.Len(t, result, 2)
+	assert.Equal(t, "group1", result[0].Name)
+	assert.Equal(t, "group2", result[1].Name)
+
+	result = get(HistoryPath + "?namespace=ns2&name=group2")
+	assert.Len(t, result, 1)
+	assert.Equal(t, "ns2", result[0].Namespace)
+	assert.Len(t, result[0].Transitions, 2)
+	assert.Equal(t, v1alpha1.Current, result[0].Transitions[1].NewStatus)
+
+	assert.Empty(t, get(HistoryPath+"?namespace=ns3"))
+}
@@ -0,0 +1,134 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcemap
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// GroupKinds returns the group kinds of the resources included in any resource group.
+func (m *ResourceMap) GroupKinds() []schema.GroupKind {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	result := make([]schema.GroupKind, 0, len(m.gkToResources))
+	for gk := range m.gkToResources {
+		result = append(result, gk)
+	}
+	return result
+}
+
+// FindOrphans returns, for each resource group with an inventory id, the live
+// objects owned by its inventory which are not included in the resource group.
+// owners maps the live objects to the inventory id in their owning-inventory
+// annotation.
+func (m *ResourceMap) FindOrphans(owners map[resource]string) map[types.NamespacedName][]resource {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	inventoryToGroups := make(map[string][]types.NamespacedName, len(m.resgroupToInventoryID))
+	for group, id := range m.resgroupToInventoryID {
+		inventoryToGroups[id] = append(inventoryToGroups[id], group)
+	}
+	result := map[types.NamespacedName][]resource{}
+	for res, id := range owners {
+		for _, group := range inventoryToGroups[id] {
+			if resources, ok := m.resgroupToResources[group]; ok && resources.Has(res) {
+				continue
+			}
+			result[group] = append(result[group], res)
+		}
+	}
+	return result
+}
+
+// SetOrphans replaces the orphans of all the resource groups, and returns the
+// resource groups whose orphans changed. The existing orphans of the group
+// kinds in keep are kept, e.g. because their objects could not be listed.
+func (m *ResourceMap) SetOrphans(orphans map[types.NamespacedName][]resource, keep []schema.GroupKind) []types.NamespacedName {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if len(keep) > 0 {
+		kept := make(map[schema.GroupKind]bool, len(keep))
+		for _, gk := range keep {
+			kept[gk] = true
+		}
+		merged := make(map[types.NamespacedName][]resource, len(orphans))
+		for group, resources := range orphans {
+			merged[group] = append(merged[group], resources...)
+		}
+		for group, old := range m.resgroupToOrphans {
+			for res := range old.data {
+				if kept[res.GK()] {
+					merged[group] = append(merged[group], res)
+				}
+			}
+		}
+		orphans = merged
+	}
+	var changed []types.NamespacedName
+	for group, old := range m.resgroupToOrphans {
+		if _, ok := orphans[group]; !ok && old.Len() > 0 {
+			changed = append(changed, group)
+		}
+	}
+	newOrphans := make(map[types.NamespacedName]*resourceSet, len(orphans))
+	for group, resources := range orphans {
+		if _, ok := m.resgroupToInventoryID[group]; !ok {
+			// The resource group was deleted since the orphans were found.
+			continue
+		}
+		set := newresourceSet(resources)
+		if !sameResources(m.resgroupToOrphans[group], set) {
+			changed = append(changed, group)
+		}
+		newOrphans[group] = set
+	}
+	m.resgroupToOrphans = newOrphans
+	return changed
+}
+
+// GetOrphans returns the sorted orphans of the given resource group, or nil
+// if there are none.
+func (m *ResourceMap) GetOrphans(group types.NamespacedName) []resource {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	set, ok := m.resgroupToOrphans[group]
+	if !ok || set.Len() == 0 {
+		return nil
+	}
+	result := set.toSlice()
+	sort.Slice(result, func(i, j int) bool {
+		return lessResource(result[i], result[j])
+	})
+	return result
+}
+
+// sameResources checks whether two resource sets have the same resources.
+func sameResources(a, b *resourceSet) bool {
+	if a == nil || b == nil {
+		return (a == nil || a.Len() == 0) && (b == nil || b.Len() == 0)
+	}
+	if a.Len() != b.Len() {
+		return false
+	}
+	for res := range a.data {
+		if !b.Has(res) {
+			return false
+		}
+	}
+	return true
+}
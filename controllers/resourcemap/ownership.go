@@ -0,0 +1,184 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcemap
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/controllers/metrics"
+)
+
+// OwnershipPath is the path of the HTTP endpoint serving the ownership report.
+const OwnershipPath = "/debug/resourcegroups/ownership"
+
+// DefaultOwnershipReportInterval is the default interval between two
+// measurements of the ownership metrics.
+const DefaultOwnershipReportInterval = time.Minute
+
+// GroupReference identifies a resource group in the ownership report.
+type GroupReference struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// Overlap is a resource included in more than one resource group.
+type Overlap struct {
+	// Resource is the resource included in several resource groups.
+	Resource v1alpha1.ObjMetadata `json:"resource"`
+	// Groups are the resource groups including the resource.
+	Groups []GroupReference `json:"groups"`
+	// OwningInventory is the inventory id set in the owning-inventory
+	// annotation of the live object, if any.
+	OwningInventory string `json:"owningInventory,omitempty"`
+	// OwningGroup is the resource group whose inventory id matches
+	// OwningInventory, if any.
+	OwningGroup *GroupReference `json:"owningGroup,omitempty"`
+}
+
+// Orphan is an existing resource included in a resource group whose live
+// object is not owned by any inventory.
+type Orphan struct {
+	// Resource is the resource without owner.
+	Resource v1alpha1.ObjMetadata `json:"resource"`
+	// Groups are the resource groups including the resource.
+	Groups []GroupReference `json:"groups"`
+}
+
+// OwnershipReport lists the resources whose ownership is ambiguous across
+// all the resource groups of the ResourceMap.
+type OwnershipReport struct {
+	Overlaps []Overlap `json:"overlaps"`
+	Orphans  []Orphan  `json:"orphans"`
+}
+
+// SetInventoryID records the inventory id of the given resource group, which
+// is used to find the resource group owning a live object.
+func (m *ResourceMap) SetInventoryID(group types.NamespacedName, id string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if id == "" {
+		delete(m.resgroupToInventoryID, group)
+		return
+	}
+	m.resgroupToInventoryID[group] = id
+}
+
+// OwnershipReport returns the resources included in more than one resource
+// group, and the existing resources which are not owned by any inventory.
+// The entries are sorted by resource.
+func (m *ResourceMap) OwnershipReport() OwnershipReport {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	inventoryToGroup := make(map[string]types.NamespacedName, len(m.resgroupToInventoryID))
+	for group, id := range m.resgroupToInventoryID {
+		// Prefer the smallest group name to make the report deterministic when
+		// several resource groups share the same inventory id.
+		if other, ok := inventoryToGroup[id]; !ok || group.String() < other.String() {
+			inventoryToGroup[id] = group
+		}
+	}
+
+	report := OwnershipReport{Overlaps: []Overlap{}, Orphans: []Orphan{}}
+	for res, groups := range m.resToResgroups {
+		status := m.resToStatus[res]
+		if groups.Len() > 1 {
+			overlap := Overlap{Resource: res, Groups: groupReferences(groups)}
+			if status != nil && status.InventoryID != "" {
+				overlap.OwningInventory = status.InventoryID
+				if owner, ok := inventoryToGroup[status.InventoryID]; ok {
+					overlap.OwningGroup = &GroupReference{Namespace: owner.Namespace, Name: owner.Name}
+				}
+			}
+			report.Overlaps = append(report.Overlaps, overlap)
+		}
+		if isOrphan(status) {
+			report.Orphans = append(report.Orphans, Orphan{Resource: res, Groups: groupReferences(groups)})
+		}
+	}
+	sort.Slice(report.Overlaps, func(i, j int) bool {
+		return lessResource(report.Overlaps[i].Resource, report.Overlaps[j].Resource)
+	})
+	sort.Slice(report.Orphans, func(i, j int) bool {
+		return lessResource(report.Orphans[i].Resource, report.Orphans[j].Resource)
+	})
+	return report
+}
+
+// isOrphan checks whether the live object of a resource exists without an
+// owning inventory.
+func isOrphan(status *CachedStatus) bool {
+	if status == nil || status.InventoryID != "" {
+		return false
+	}
+	return status.Status != v1alpha1.NotFound && status.Status != v1alpha1.Unknown
+}
+
+// groupReferences returns the sorted references of the resource groups in groups.
+func groupReferences(groups *resourceGroupSet) []GroupReference {
+	result := make([]GroupReference, 0, groups.Len())
+	for group := range groups.data {
+		result = append(result, GroupReference{Namespace: group.Namespace, Name: group.Name})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Namespace != result[j].Namespace {
+			return result[i].Namespace < result[j].Namespace
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// lessResource orders resources by group, kind, namespace and name.
+func lessResource(a, b resource) bool {
+	if a.Group != b.Group {
+		return a.Group < b.Group
+	}
+	if a.Kind != b.Kind {
+		return a.Kind < b.Kind
+	}
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	return a.Name < b.Name
+}
+
+// OwnershipHandler returns an HTTP handler serving the ownership report of
+// the ResourceMap in JSON.
+func OwnershipHandler(m *ResourceMap) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(m.OwnershipReport()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// ReportOwnership records the ownership metrics of the ResourceMap every
+// interval until ctx is done.
+func ReportOwnership(ctx context.Context, m *ResourceMap, interval time.Duration) {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		report := m.OwnershipReport()
+		metrics.RecordOwnershipReport(ctx, int64(len(report.Overlaps)), int64(len(report.Orphans)))
+	}, interval)
+}
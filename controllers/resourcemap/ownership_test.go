@@ -0,0 +1,85 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcemap
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+)
+
+func TestOwnershipReport(t *testing.T) {
+	newRes := func(name string) resource {
+		return resource{
+			Namespace: "ns1",
+			Name:      name,
+			GroupKind: v1alpha1.GroupKind{Group: "apps", Kind: "Deployment"},
+		}
+	}
+	shared := newRes("shared")
+	unowned := newRes("unowned")
+	missing := newRes("missing")
+	owned := newRes("owned")
+	group1 := types.NamespacedName{Namespace: "ns1", Name: "group1"}
+	group2 := types.NamespacedName{Namespace: "ns1", Name: "group2"}
+
+	m := NewResourceMap()
+	m.Reconcile(context.TODO(), group1, []resource{shared, unowned, missing, owned}, false)
+	m.Reconcile(context.TODO(), group2, []resource{shared, owned}, false)
+	m.SetInventoryID(group1, "inv1")
+	m.SetInventoryID(group2, "inv2")
+
+	m.SetStatus(shared, &CachedStatus{Status: v1alpha1.Current, InventoryID: "inv2"})
+	m.SetStatus(owned, &CachedStatus{Status: v1alpha1.Current, InventoryID: "other"})
+	m.SetStatus(unowned, &CachedStatus{Status: v1alpha1.InProgress})
+	m.SetStatus(missing, &CachedStatus{Status: v1alpha1.NotFound})
+
+	refs := []GroupReference{{Namespace: "ns1", Name: "group1"}, {Namespace: "ns1", Name: "group2"}}
+	expected := OwnershipReport{
+		Overlaps: []Overlap{
+			{Resource: owned, Groups: refs, OwningInventory: "other"},
+			{
+				Resource:        shared,
+				Groups:          refs,
+				OwningInventory: "inv2",
+				OwningGroup:     &GroupReference{Namespace: "ns1", Name: "group2"},
+			},
+		},
+		Orphans: []Orphan{
+			{Resource: unowned, Groups: refs[:1]},
+		},
+	}
+	assert.Equal(t, expected, m.OwnershipReport())
+
+	// The overlap is resolved once a resource group drops the resource.
+	m.Reconcile(context.TODO(), group2, []resource{}, true)
+	report := m.OwnershipReport()
+	assert.Empty(t, report.Overlaps)
+	assert.Len(t, report.Orphans, 1)
+
+	rec := httptest.NewRecorder()
+	OwnershipHandler(m).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, OwnershipPath, nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var served OwnershipReport
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &served))
+	assert.Equal(t, report, served)
+}
@@ -17,6 +17,7 @@ package resourcemap
 import (
 	"context"
 	"sync"
+	"time"
 
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
@@ -122,6 +123,18 @@ type CachedStatus struct {
 	Conditions  []v1alpha1.Condition
 	SourceHash  string
 	InventoryID string
+	// Dependencies lists the resources the resource depends on,
+	// read from its depends-on annotation.
+	Dependencies []v1alpha1.ObjMetadata
+	// ResourceVersion is the resource version of the object the status was
+	// computed from. It is empty if the status was not computed from an object.
+	ResourceVersion string
+	// InProgressSince is the time the resource entered the InProgress status,
+	// or zero if it is not InProgress. It is set by ResourceMap.SetStatus.
+	InProgressSince time.Time
+	// SourceHashRemoved is true if the source hash annotation of the resource
+	// was removed, as opposed to never set. It is set by ResourceMap.SetStatus.
+	SourceHashRemoved bool
 }
 
 // ResourceMap maintains the following maps:
@@ -129,6 +142,9 @@ type CachedStatus struct {
 // 2) resgroupToResources maps a resource group to its resource set
 // 3) resToStatus maps a resource to its cached status
 // 4) gkToResources maps a GroupKind to its resource set
+// 5) resgroupToChanges maps a resource group to the resources whose status changed since
+// the status of the resource group was last computed
+// 6) resgroupToHistory maps a resource group to the latest status transitions of its resources
 // During the reconciliation of a RG in the root controller, the updates to these two maps should be atomic.
 type ResourceMap struct {
 	// use a lock to make sure that updating resToResgroups and resgroupToResources is atomic
@@ -141,6 +157,25 @@ type ResourceMap struct {
 	resgroupToResources map[types.NamespacedName]*resourceSet
 	// gkToResources maps a GroupKind to its resource set
 	gkToResources map[schema.GroupKind]*resourceSet
+	// resgroupToChanges maps a resource group to the set of its resources whose
+	// cached status changed since the last call to TakeChanges.
+	// A resource group without an entry needs a full recomputation of its status.
+	resgroupToChanges map[types.NamespacedName]*resourceSet
+	// resgroupToHistory maps a resource group to the latest status transitions
+	// of its resources.
+	resgroupToHistory map[types.NamespacedName]*transitionLog
+	// historySize is the maximum number of status transitions kept per
+	// resource group. The oldest transitions are dropped when a resource
+	// group has more transitions. 0 disables the history.
+	historySize int
+	// resgroupToInventoryID maps a resource group to its inventory id.
+	resgroupToInventoryID map[types.NamespacedName]string
+	// resgroupToOrphans maps a resource group to the live objects owned by its
+	// inventory which are not included in the resource group.
+	resgroupToOrphans map[types.NamespacedName]*resourceSet
+	// gkToForbiddenVerbs maps a GroupKind to the verbs needed to watch it
+	// which the controller is not allowed.
+	gkToForbiddenVerbs map[schema.GroupKind][]string
 }
 
 // Reconcile takes a resourcegroup name and all the resources belonging to it, and
@@ -219,9 +254,15 @@ func (m *ResourceMap) Reconcile(ctx context.Context, group types.NamespacedName,
 
 	if len(resources) == 0 && deleteRG {
 		delete(m.resgroupToResources, group)
+		delete(m.resgroupToHistory, group)
+		delete(m.resgroupToInventoryID, group)
+		delete(m.resgroupToOrphans, group)
 	} else {
 		m.resgroupToResources[group] = newresourceSet(resources)
 	}
+	// The spec of the resource group may have changed, so its status
+	// needs a full recomputation.
+	delete(m.resgroupToChanges, group)
 
 	metrics.RecordResourceGroupTotal(ctx, int64(len(m.resgroupToResources)))
 	var gkSlice []schema.GroupKind
@@ -265,6 +306,17 @@ func (m *ResourceMap) HasResgroup(group types.NamespacedName) bool {
 	return ok
 }
 
+// ResourceGroups returns all the resourcegroups in the ResourceMap
+func (m *ResourceMap) ResourceGroups() []types.NamespacedName {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	groups := make([]types.NamespacedName, 0, len(m.resgroupToResources))
+	for group := range m.resgroupToResources {
+		groups = append(groups, group)
+	}
+	return groups
+}
+
 // Get returns the resourceGroupSet for res
 func (m *ResourceMap) Get(res resource) []types.NamespacedName {
 	m.lock.RLock()
@@ -285,16 +337,101 @@ func (m *ResourceMap) GetStatus(res resource) *CachedStatus {
 	return nil
 }
 
+// SeedInProgressSince moves the time the resource entered the InProgress
+// status back to since, e.g. to the transition time recorded in the status of
+// a resource group before the controller restarted, and returns the resulting
+// time. It returns the zero time if the cached status of the resource is not
+// InProgress.
+func (m *ResourceMap) SeedInProgressSince(res resource, since time.Time) time.Time {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	cached, ok := m.resToStatus[res]
+	if !ok || cached == nil || cached.Status != v1alpha1.InProgress {
+		return time.Time{}
+	}
+	if since.IsZero() || !since.Before(cached.InProgressSince) {
+		return cached.InProgressSince
+	}
+	// The cached status is replaced rather than modified, since it may be
+	// read concurrently.
+	seeded := *cached
+	seeded.InProgressSince = since
+	m.resToStatus[res] = &seeded
+	return since
+}
+
 // GetStatusMap returns the map from resources to their status.
 func (m *ResourceMap) GetStatusMap() map[resource]*CachedStatus {
 	return m.resToStatus
 }
 
-// SetStatus sets the status and conditions for a resource.
+// SetStatus sets the status and conditions for a resource, and records the
+// resource as changed for all the resource groups including it.
+// A change of the status is recorded in the history of these resource groups.
+// The time the resource entered the InProgress status is carried over from
+// its previous status while it stays InProgress, and so is the removal of its
+// source hash while the resource exists without a source hash.
 func (m *ResourceMap) SetStatus(res resource, resStatus *CachedStatus) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
+	old := m.resToStatus[res]
+	if resStatus != nil && resStatus.SourceHash == "" && resStatus.Status != v1alpha1.NotFound &&
+		old != nil && old.Status != v1alpha1.NotFound && (old.SourceHash != "" || old.SourceHashRemoved) {
+		resStatus.SourceHashRemoved = true
+	}
+	switch {
+	case resStatus == nil:
+		// The cached status is reset.
+	case resStatus.Status != v1alpha1.InProgress:
+		resStatus.InProgressSince = time.Time{}
+	case old != nil && old.Status == v1alpha1.InProgress && !old.InProgressSince.IsZero():
+		// The resource is still InProgress.
+		resStatus.InProgressSince = old.InProgressSince
+	case resStatus.InProgressSince.IsZero():
+		resStatus.InProgressSince = time.Now()
+	}
+	m.recordTransition(res, old, resStatus)
 	m.resToStatus[res] = resStatus
+	if groups, ok := m.resToResgroups[res]; ok {
+		for group := range groups.data {
+			if changes, ok := m.resgroupToChanges[group]; ok {
+				changes.Add(res)
+			}
+		}
+	}
+}
+
+// TakeChanges returns the resources of the given resource group whose status
+// changed since the last call to TakeChanges, and starts tracking new changes.
+//
+// The second return value is false if the changes are unknown and the status
+// of the whole resource group needs to be recomputed, e.g. the first time
+// TakeChanges is called for a resource group, or after its resources were
+// updated by Reconcile or RequestFullRecompute was called.
+func (m *ResourceMap) TakeChanges(group types.NamespacedName) ([]resource, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if _, ok := m.resgroupToResources[group]; !ok {
+		// Don't track the changes for a resource group which is not in the ResourceMap.
+		delete(m.resgroupToChanges, group)
+		return nil, false
+	}
+	changes, tracked := m.resgroupToChanges[group]
+	m.resgroupToChanges[group] = newresourceSet(nil)
+	if !tracked {
+		return nil, false
+	}
+	return changes.toSlice(), true
+}
+
+// RequestFullRecompute discards the tracked changes for the given resource group
+// so that the next call to TakeChanges requests a full recomputation of its status.
+// It should be called when the status computed from the changes returned by
+// TakeChanges could not be written.
+func (m *ResourceMap) RequestFullRecompute(group types.NamespacedName) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.resgroupToChanges, group)
 }
 
 // GetResources get the set of resources for the given group kind.
@@ -319,12 +456,25 @@ func (m *ResourceMap) IsEmpty() bool {
 	return len(m.resgroupToResources) == 0 && len(m.resToResgroups) == 0
 }
 
-// NewResourceMap initializes an empty ReverseMap
+// NewResourceMap initializes an empty ReverseMap keeping DefaultHistorySize
+// status transitions per resource group.
 func NewResourceMap() *ResourceMap {
+	return NewResourceMapWithHistorySize(DefaultHistorySize)
+}
+
+// NewResourceMapWithHistorySize initializes an empty ReverseMap keeping at
+// most historySize status transitions per resource group. 0 disables the history.
+func NewResourceMapWithHistorySize(historySize int) *ResourceMap {
 	return &ResourceMap{
-		resToResgroups:      make(map[resource]*resourceGroupSet),
-		resToStatus:         make(map[resource]*CachedStatus),
-		resgroupToResources: make(map[types.NamespacedName]*resourceSet),
-		gkToResources:       make(map[schema.GroupKind]*resourceSet),
+		historySize:           historySize,
+		resToResgroups:        make(map[resource]*resourceGroupSet),
+		resToStatus:           make(map[resource]*CachedStatus),
+		resgroupToResources:   make(map[types.NamespacedName]*resourceSet),
+		gkToResources:         make(map[schema.GroupKind]*resourceSet),
+		resgroupToChanges:     make(map[types.NamespacedName]*resourceSet),
+		resgroupToHistory:     make(map[types.NamespacedName]*transitionLog),
+		resgroupToInventoryID: make(map[types.NamespacedName]string),
+		resgroupToOrphans:     make(map[types.NamespacedName]*resourceSet),
+		gkToForbiddenVerbs:    make(map[schema.GroupKind][]string),
 	}
 }
@@ -199,3 +199,129 @@ func TestDiffResources(t *testing.T) {
 	assert.Equal(t, res3, toAdd[0])
 	assert.Equal(t, res2, toDelete[0])
 }
+
+func TestResourceMapChanges(t *testing.T) {
+	res1 := resource{
+		Namespace: "ns1",
+		Name:      "res1",
+		GroupKind: v1alpha1.GroupKind{
+			Group: "group1",
+			Kind:  "service",
+		},
+	}
+
+	res2 := resource{
+		Namespace: "ns1",
+		Name:      "res2",
+		GroupKind: v1alpha1.GroupKind{
+			Group: "group1",
+			Kind:  "service",
+		},
+	}
+
+	resgroup1 := types.NamespacedName{
+		Namespace: "test-ns",
+		Name:      "group1",
+	}
+
+	resgroup2 := types.NamespacedName{
+		Namespace: "test-ns",
+		Name:      "group2",
+	}
+
+	resourceMap := NewResourceMap()
+
+	// A resource group which is not in the ResourceMap is not tracked.
+	changes, tracked := resourceMap.TakeChanges(resgroup1)
+	assert.False(t, tracked)
+	assert.Empty(t, changes)
+	resourceMap.Reconcile(context.TODO(), resgroup1, []resource{res1, res2}, false)
+	resourceMap.Reconcile(context.TODO(), resgroup2, []resource{res2}, false)
+
+	// The first call requests a full recomputation.
+	_, tracked = resourceMap.TakeChanges(resgroup1)
+	assert.False(t, tracked)
+	_, tracked = resourceMap.TakeChanges(resgroup2)
+	assert.False(t, tracked)
+
+	changes, tracked = resourceMap.TakeChanges(resgroup1)
+	assert.True(t, tracked)
+	assert.Empty(t, changes)
+
+	resourceMap.SetStatus(res1, &CachedStatus{Status: v1alpha1.Current})
+	resourceMap.SetStatus(res2, &CachedStatus{Status: v1alpha1.InProgress})
+	resourceMap.SetStatus(res2, &CachedStatus{Status: v1alpha1.Current})
+	changes, tracked = resourceMap.TakeChanges(resgroup1)
+	assert.True(t, tracked)
+	assert.ElementsMatch(t, []resource{res1, res2}, changes)
+	changes, tracked = resourceMap.TakeChanges(resgroup2)
+	assert.True(t, tracked)
+	assert.Equal(t, []resource{res2}, changes)
+
+	// The changes are reset by TakeChanges.
+	changes, tracked = resourceMap.TakeChanges(resgroup1)
+	assert.True(t, tracked)
+	assert.Empty(t, changes)
+
+	// Reconcile and RequestFullRecompute request a full recomputation.
+	resourceMap.Reconcile(context.TODO(), resgroup1, []resource{res1}, false)
+	_, tracked = resourceMap.TakeChanges(resgroup1)
+	assert.False(t, tracked)
+	resourceMap.RequestFullRecompute(resgroup2)
+	_, tracked = resourceMap.TakeChanges(resgroup2)
+	assert.False(t, tracked)
+
+	// Deleting a resource group stops tracking its changes.
+	resourceMap.Reconcile(context.TODO(), resgroup1, []resource{}, true)
+	assert.NotContains(t, resourceMap.resgroupToChanges, resgroup1)
+	_, tracked = resourceMap.TakeChanges(resgroup1)
+	assert.False(t, tracked)
+	assert.NotContains(t, resourceMap.resgroupToChanges, resgroup1)
+}
+
+func TestResourceMapInProgressSince(t *testing.T) {
+	res := resource{Namespace: "ns1", Name: "app", GroupKind: v1alpha1.GroupKind{Group: "apps", Kind: "Deployment"}}
+	resourceMap := NewResourceMap()
+
+	resourceMap.SetStatus(res, &CachedStatus{Status: v1alpha1.InProgress})
+	since := resourceMap.GetStatus(res).InProgressSince
+	assert.False(t, since.IsZero())
+
+	// The time is kept while the resource stays InProgress.
+	resourceMap.SetStatus(res, &CachedStatus{Status: v1alpha1.InProgress, ResourceVersion: "2"})
+	assert.Equal(t, since, resourceMap.GetStatus(res).InProgressSince)
+
+	resourceMap.SetStatus(res, &CachedStatus{Status: v1alpha1.Current, InProgressSince: since})
+	assert.True(t, resourceMap.GetStatus(res).InProgressSince.IsZero())
+
+	resourceMap.SetStatus(res, &CachedStatus{Status: v1alpha1.InProgress})
+	assert.False(t, resourceMap.GetStatus(res).InProgressSince.Before(since))
+
+	// The cached status can be reset.
+	resourceMap.SetStatus(res, nil)
+	assert.Nil(t, resourceMap.GetStatus(res))
+}
+
+func TestResourceMapSourceHashRemoved(t *testing.T) {
+	res := resource{Namespace: "ns1", Name: "app", GroupKind: v1alpha1.GroupKind{Group: "apps", Kind: "Deployment"}}
+	resourceMap := NewResourceMap()
+
+	// A source hash which was never set is not removed.
+	resourceMap.SetStatus(res, &CachedStatus{Status: v1alpha1.Current})
+	assert.False(t, resourceMap.GetStatus(res).SourceHashRemoved)
+
+	resourceMap.SetStatus(res, &CachedStatus{Status: v1alpha1.Current, SourceHash: "1234567"})
+	assert.False(t, resourceMap.GetStatus(res).SourceHashRemoved)
+
+	// The removal is kept while the resource has no source hash.
+	resourceMap.SetStatus(res, &CachedStatus{Status: v1alpha1.Current})
+	assert.True(t, resourceMap.GetStatus(res).SourceHashRemoved)
+	resourceMap.SetStatus(res, &CachedStatus{Status: v1alpha1.InProgress})
+	assert.True(t, resourceMap.GetStatus(res).SourceHashRemoved)
+
+	// A resource recreated without a source hash did not have it removed.
+	resourceMap.SetStatus(res, &CachedStatus{Status: v1alpha1.NotFound})
+	assert.False(t, resourceMap.GetStatus(res).SourceHashRemoved)
+	resourceMap.SetStatus(res, &CachedStatus{Status: v1alpha1.Current})
+	assert.False(t, resourceMap.GetStatus(res).SourceHashRemoved)
+}
@@ -16,8 +16,10 @@ package root
 
 import (
 	"context"
+	"time"
 
 	"github.com/go-logr/logr"
+	rbacv1 "k8s.io/api/rbac/v1"
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -25,6 +27,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/cli-utils/pkg/common"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
@@ -34,18 +37,21 @@ import (
 
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/controllers/eventbus"
 	"kpt.dev/resourcegroup/controllers/handler"
+	"kpt.dev/resourcegroup/controllers/hub"
 	"kpt.dev/resourcegroup/controllers/resourcegroup"
 	"kpt.dev/resourcegroup/controllers/resourcemap"
+	controllerstatus "kpt.dev/resourcegroup/controllers/status"
 	"kpt.dev/resourcegroup/controllers/typeresolver"
 	"kpt.dev/resourcegroup/controllers/watch"
 )
 
 //nolint:revive // TODO: add comments for public constants and enable linting
 const (
-	KptGroup           = "kpt"
-	DisableStatusKey   = "configsync.gke.io/status"
-	DisableStatusValue = "disabled"
+	KptGroup                = "kpt"
+	DefaultDisableStatusKey = "configsync.gke.io/status"
+	DisableStatusValue      = "disabled"
 )
 
 // contextKey is a custom type for wrapping context values to make them unique
@@ -78,12 +84,19 @@ type Reconciler struct {
 	// and reverse mapping.
 	resMap *resourcemap.ResourceMap
 
-	// channel accepts the events that are from
+	// events accepts the events that are from
 	// different watchers for GVKs.
-	channel chan event.GenericEvent
+	events *eventbus.Bus
 
 	// watches contains the mapping from GVK to their watchers.
 	watches *watch.Manager
+
+	// hub tracks the resources in the member clusters in hub mode, or is nil.
+	hub *hub.Hub
+
+	// disableStatusKey is the name of the annotation which disables the
+	// status of a ResourceGroup when it is set to DisableStatusValue.
+	disableStatusKey string
 }
 
 // Reconcile implements reconcile.Reconciler. This function handles reconciliation
@@ -91,6 +104,8 @@ type Reconciler struct {
 // +kubebuilder:rbac:groups=kpt.dev,resources=resourcegroups,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=kpt.dev,resources=resourcegroups/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=*,resources=*,verbs=get;list;watch
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=selfsubjectaccessreviews,verbs=create
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles;clusterrolebindings;roles;rolebindings,verbs=get;list;watch
 func (r *Reconciler) Reconcile(rootCtx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := r.log
 	ctx := context.WithValue(rootCtx, contextLoggerKey, logger)
@@ -104,41 +119,47 @@ func (r *Reconciler) reconcileKptGroup(ctx context.Context, logger logr.Logger,
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// If the ResourceGroup has been deleted, update the resMap
-			return r.reconcile(ctx, req.NamespacedName, []v1alpha1.ObjMetadata{}, true)
+			return r.reconcile(ctx, req.NamespacedName, []v1alpha1.ObjMetadata{}, nil, true)
 		}
 		return ctrl.Result{}, err
 	}
 
 	// ResourceGroup CR is created from ConfigSync and set to disable the status
-	if isStatusDisabled(resgroup) {
+	if isStatusDisabled(resgroup, r.disableStatusKey) {
 		return r.reconcileDisabledResourceGroup(ctx, req, resgroup)
 	}
 
 	// ResourceGroup is in the process of being deleted, clean up the cache for this ResourceGroup
 	if resgroup.DeletionTimestamp != nil {
-		return r.reconcile(ctx, req.NamespacedName, []v1alpha1.ObjMetadata{}, true)
+		return r.reconcile(ctx, req.NamespacedName, []v1alpha1.ObjMetadata{}, nil, true)
 	}
 
+	r.resMap.SetInventoryID(req.NamespacedName, resgroup.Labels[common.InventoryLabel])
 	resources := make([]v1alpha1.ObjMetadata, 0, len(resgroup.Spec.Resources)+len(resgroup.Spec.Subgroups))
 	resources = append(resources, resgroup.Spec.Resources...)
 	resources = append(resources, v1alpha1.ToObjMetadata(resgroup.Spec.Subgroups)...)
-	if result, err := r.reconcile(ctx, req.NamespacedName, resources, false); err != nil {
+	if result, err := r.reconcile(ctx, req.NamespacedName, resources, resgroup.Spec.Clusters, false); err != nil {
 		return result, err
 	}
 
-	// Push an event to the ResourceGroup event channel
-	r.channel <- event.GenericEvent{Object: resgroup}
+	// Push an event to the ResourceGroup event bus
+	r.events.Publish(ctx, req.NamespacedName)
 	logger.Info("finished reconciling")
 
 	return ctrl.Result{}, nil
 }
 
 func (r *Reconciler) reconcile(ctx context.Context, name types.NamespacedName,
-	resources []v1alpha1.ObjMetadata, deleteRG bool) (ctrl.Result, error) {
+	resources []v1alpha1.ObjMetadata, clusters []v1alpha1.ClusterResources, deleteRG bool) (ctrl.Result, error) {
 	gks := r.resMap.Reconcile(ctx, name, resources, deleteRG)
 	if err := r.updateWatches(ctx, gks); err != nil {
 		return ctrl.Result{}, err
 	}
+	if r.hub != nil {
+		if err := r.hub.ReconcileGroup(ctx, name, clusters, deleteRG); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
 	return ctrl.Result{}, nil
 }
 
@@ -170,27 +191,63 @@ func (r *Reconciler) reconcileDisabledResourceGroup(ctx context.Context, req ctr
 		return ctrl.Result{}, err
 	}
 	// update the resMap
-	return r.reconcile(ctx, req.NamespacedName, []v1alpha1.ObjMetadata{}, true)
+	return r.reconcile(ctx, req.NamespacedName, []v1alpha1.ObjMetadata{}, nil, true)
 }
 
-func isStatusDisabled(resgroup *v1alpha1.ResourceGroup) bool {
+// isStatusDisabled checks whether the annotation key of resgroup disables its status.
+func isStatusDisabled(resgroup *v1alpha1.ResourceGroup, key string) bool {
 	annotations := resgroup.GetAnnotations()
 	if annotations == nil {
 		return false
 	}
-	val, found := annotations[DisableStatusKey]
+	val, found := annotations[key]
 	return found && val == DisableStatusValue
 }
 
+// Options configures the Root controller.
+type Options struct {
+	// ServiceAccount is the ServiceAccount of the controller. Only the changes
+	// of the RBAC objects granting access to it trigger a new check of the
+	// kinds which the controller is not allowed to watch. The empty name
+	// considers all the RBAC objects.
+	ServiceAccount types.NamespacedName
+
+	// LeastPrivilegeRole is the name of the least-privilege ClusterRole of the
+	// controller. Its changes trigger the reconciliation of all the groups.
+	LeastPrivilegeRole string
+
+	// ResyncInterval is the interval between two comparisons of the cached
+	// statuses with the live objects of each watched type.
+	// 0 disables the resync.
+	ResyncInterval time.Duration
+
+	// MetadataOnlyKinds is the set of kinds whose status is fully determined
+	// by their metadata. Only the metadata of their objects is watched.
+	MetadataOnlyKinds map[schema.GroupKind]bool
+
+	// AnnotationKeys names the annotations read when computing the statuses
+	// of the watched objects.
+	AnnotationKeys controllerstatus.AnnotationKeys
+
+	// DisableStatusKey is the name of the annotation which disables the
+	// status of a ResourceGroup when it is set to DisableStatusValue. It can
+	// be changed for the appliers which use a different annotation.
+	DisableStatusKey string
+}
+
 // NewController creates a new Reconciler and registers it with the provided manager
-func NewController(mgr manager.Manager, channel chan event.GenericEvent,
-	logger logr.Logger, resolver *typeresolver.TypeResolver, group string, resMap *resourcemap.ResourceMap) error {
+func NewController(mgr manager.Manager, events *eventbus.Bus,
+	logger logr.Logger, resolver *typeresolver.TypeResolver, group string, resMap *resourcemap.ResourceMap, clusterHub *hub.Hub,
+	opts Options) error {
 	cfg := mgr.GetConfig()
 	watchOption, err := watch.DefaultOptions(cfg)
 	if err != nil {
 		return err
 	}
-	watchManager, err := watch.NewManager(cfg, resMap, channel, watchOption)
+	watchOption.ResyncInterval = opts.ResyncInterval
+	watchOption.MetadataOnlyKinds = opts.MetadataOnlyKinds
+	watchOption.AnnotationKeys = opts.AnnotationKeys
+	watchManager, err := watch.NewManager(cfg, resMap, events, watchOption)
 	if err != nil {
 		return err
 	}
@@ -202,22 +259,37 @@ func NewController(mgr manager.Manager, channel chan event.GenericEvent,
 		scheme:   mgr.GetScheme(),
 		resolver: resolver,
 		resMap:   resMap,
-		channel:  channel,
+		events:   events,
 		watches:  watchManager,
+		hub:      clusterHub,
+
+		disableStatusKey: opts.DisableStatusKey,
 	}
 
-	_, err = ctrl.NewControllerManagedBy(mgr).
+	builder := ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.ResourceGroup{}).
 		Named(group+"Root").
-		WithEventFilter(ResourceGroupPredicate{}).
+		WithEventFilter(ResourceGroupPredicate{DisableStatusKey: opts.DisableStatusKey}).
 		// skip the Generic events
 		WithEventFilter(NoGenericEventPredicate{}).
 		Watches(&source.Kind{Type: &apiextensionsv1.CustomResourceDefinition{}}, &handler.CRDEventHandler{
 			Mapping: resMap,
-			Channel: channel,
+			Events:  events,
 			Log:     logger,
-		}).
-		Build(reconciler)
+		})
+	// A change of the RBAC rules may allow the controller to watch the kinds
+	// which it was not allowed to watch.
+	accessHandler := &handler.AccessEventHandler{
+		Watches:        watchManager,
+		Mapping:        resMap,
+		Role:           opts.LeastPrivilegeRole,
+		ServiceAccount: opts.ServiceAccount,
+		Log:            logger,
+	}
+	for _, obj := range []client.Object{&rbacv1.ClusterRole{}, &rbacv1.ClusterRoleBinding{}, &rbacv1.Role{}, &rbacv1.RoleBinding{}} {
+		builder = builder.Watches(&source.Kind{Type: obj}, accessHandler)
+	}
+	_, err = builder.Build(reconciler)
 
 	if err != nil {
 		return err
@@ -238,11 +310,19 @@ func (NoGenericEventPredicate) Generic(event.GenericEvent) bool {
 // ResourceGroupPredicate skips events where the new status is not changed by the old status.
 type ResourceGroupPredicate struct {
 	predicate.Funcs
+	// DisableStatusKey is the name of the annotation which disables the
+	// status of a ResourceGroup.
+	DisableStatusKey string
 }
 
 // Update ensures only select ResourceGroup updates causes a reconciliation loop. This prevents
 // the controller from generating an infinite loop of reconcilers.
-func (ResourceGroupPredicate) Update(e event.UpdateEvent) bool {
+func (p ResourceGroupPredicate) Update(e event.UpdateEvent) bool {
+	// Allow the events of the RBAC objects, which may change the access of
+	// the controller to the watched kinds.
+	if isRBACObject(e.ObjectNew) {
+		return true
+	}
 	// Only allow ResourceGroup CR events.
 	rgNew, ok := e.ObjectNew.(*v1alpha1.ResourceGroup)
 	if !ok {
@@ -260,7 +340,7 @@ func (ResourceGroupPredicate) Update(e event.UpdateEvent) bool {
 
 	// If a ResourceGroup has the status disabled annotation and it status field
 	// is not empty, it should trigger a reconcile to remove reset the status.
-	if isStatusDisabled(rgNew) {
+	if isStatusDisabled(rgNew, p.DisableStatusKey) {
 		return rgNew.Status.Conditions != nil
 	}
 
@@ -274,6 +354,16 @@ func (ResourceGroupPredicate) Update(e event.UpdateEvent) bool {
 	return statusNeedsUpdate(rgNew.Status.ResourceStatuses)
 }
 
+// isRBACObject checks whether obj is a ClusterRole, ClusterRoleBinding, Role or RoleBinding.
+func isRBACObject(obj client.Object) bool {
+	switch obj.(type) {
+	case *rbacv1.ClusterRole, *rbacv1.ClusterRoleBinding, *rbacv1.Role, *rbacv1.RoleBinding:
+		return true
+	default:
+		return false
+	}
+}
+
 // statusNeedsUpdate checks each resource status to ensure the legacy status field
 // aligns with the new actuation/reconcile status fields.
 func statusNeedsUpdate(statuses []v1alpha1.ResourceStatus) bool {
@@ -25,7 +25,6 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 
 	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
@@ -61,7 +60,6 @@ func TestRootReconciler(t *testing.T) {
 	time.Sleep(10 * time.Second)
 
 	reconcilerKpt.resMap = resourcemap.NewResourceMap()
-	reconcilerKpt.channel = make(chan event.GenericEvent)
 
 	resources := []v1alpha1.ObjMetadata{}
 
@@ -90,13 +88,8 @@ func TestRootReconciler(t *testing.T) {
 	}
 	assert.True(t, reconcilerKpt.resMap.HasResgroup(request.NamespacedName))
 
-	// There should be one event pushed to the channel.
-	var e event.GenericEvent
-	go func() {
-		e = <-reconcilerKpt.channel
-	}()
-	time.Sleep(time.Second)
-	assert.Equal(t, "group0", e.Object.GetName())
+	// There should be one event pushed to the event bus.
+	assert.Equal(t, []types.NamespacedName{request.NamespacedName}, reconcilerKpt.events.Pending())
 
 	// update the Resourcegroup
 	resources = []v1alpha1.ObjMetadata{
@@ -168,10 +161,8 @@ func TestRootReconciler(t *testing.T) {
 		assert.Equal(t, 3, r.watches.Len())
 	}
 
-	// There should be one event pushed to the channel.
-	go func() { e = <-reconcilerKpt.channel }()
-	time.Sleep(time.Second)
-	assert.Equal(t, "group0", e.Object.GetName())
+	// The pending event for the ResourceGroup should be deduplicated.
+	assert.Equal(t, []types.NamespacedName{request.NamespacedName}, reconcilerKpt.events.Pending())
 
 	// Delete the resource group
 	err = c.Delete(ctx, resourceGroupKpt)
@@ -190,8 +181,6 @@ func TestRootReconciler(t *testing.T) {
 		assert.False(t, reconcilerKpt.resMap.HasResource(resource))
 	}
 
-	// There should be one event pushed to the channel.
-	go func() { e = <-reconcilerKpt.channel }()
-	time.Sleep(time.Second)
-	assert.Equal(t, "group0", e.Object.GetName())
+	// The pending event for the ResourceGroup should be deduplicated.
+	assert.Equal(t, []types.NamespacedName{request.NamespacedName}, reconcilerKpt.events.Pending())
 }
@@ -30,6 +30,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 
 	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/controllers/eventbus"
 	"kpt.dev/resourcegroup/controllers/resourcemap"
 	"kpt.dev/resourcegroup/controllers/watch"
 	// +kubebuilder:scaffold:imports
@@ -80,7 +81,8 @@ func StartTestManager(t *testing.T, mgr manager.Manager) {
 
 func NewReconciler(mgr manager.Manager) (*Reconciler, error) {
 	resmap := resourcemap.NewResourceMap()
-	watches, err := watch.NewManager(mgr.GetConfig(), resmap, nil, nil)
+	events := eventbus.New(0)
+	watches, err := watch.NewManager(mgr.GetConfig(), resmap, events, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -89,7 +91,10 @@ func NewReconciler(mgr manager.Manager) (*Reconciler, error) {
 		cfg:     mgr.GetConfig(),
 		log:     ctrl.Log.WithName("controllers").WithName("Root"),
 		resMap:  resmap,
+		events:  events,
 		watches: watches,
+
+		disableStatusKey: DefaultDisableStatusKey,
 	}
 	obj := &v1alpha1.ResourceGroup{}
 	_, err = ctrl.NewControllerManagedBy(mgr).
@@ -15,24 +15,36 @@
 package runner
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp" // register gcp auth provider plugin
 	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/controllers/eventbus"
+	"kpt.dev/resourcegroup/controllers/hub"
 	"kpt.dev/resourcegroup/controllers/log"
 	ocmetrics "kpt.dev/resourcegroup/controllers/metrics"
+	"kpt.dev/resourcegroup/controllers/orphan"
 	"kpt.dev/resourcegroup/controllers/profiler"
 	"kpt.dev/resourcegroup/controllers/resourcegroup"
 	"kpt.dev/resourcegroup/controllers/resourcemap"
 	"kpt.dev/resourcegroup/controllers/root"
+	controllerstatus "kpt.dev/resourcegroup/controllers/status"
 	"kpt.dev/resourcegroup/controllers/typeresolver"
 	ctrl "sigs.k8s.io/controller-runtime"
-	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -62,12 +74,104 @@ func run() error {
 
 	var metricsAddr string
 	var enableLeaderElection bool
+	var eventBufferSize int
+	var historySize int
+	var orphanScanInterval time.Duration
+	var resyncInterval time.Duration
+	var metadataOnlyKinds string
+	var progressDeadlines string
+	var serviceAccount string
+	var disableStatusKey string
+	var memberClusterNamespace string
+	var accessRecheckInterval time.Duration
+	annotationKeys := controllerstatus.DefaultAnnotationKeys()
+	rgOptions := resourcegroup.DefaultOptions()
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.IntVar(&eventBufferSize, "event-buffer-size", eventbus.DefaultCapacity,
+		"The maximum number of ResourceGroups with pending events for the ResourceGroup controller. "+
+			"Events for additional ResourceGroups are dropped until the pending events are consumed.")
+	flag.IntVar(&rgOptions.StatusShardSize, "status-shard-size", rgOptions.StatusShardSize,
+		"The maximum number of resource statuses stored in a ResourceGroup object. "+
+			"The resource statuses of larger ResourceGroups are split into ConfigMaps owned by the ResourceGroup. "+
+			"0 disables the sharding.")
+	flag.IntVar(&historySize, "status-history-size", resourcemap.DefaultHistorySize,
+		"The maximum number of status transitions of the resources kept per ResourceGroup. "+
+			"The transitions are served on "+resourcemap.HistoryPath+" of the metrics endpoint. "+
+			"0 disables the history.")
+	flag.IntVar(&rgOptions.ListThreshold, "status-list-threshold", rgOptions.ListThreshold,
+		"The minimum number of resources of the same kind and namespace missing from the status cache of a ResourceGroup "+
+			"for which their statuses are computed from a paginated LIST instead of a GET per resource. 0 disables the LISTs.")
+	flag.IntVar(&rgOptions.StatusWorkers, "status-workers", rgOptions.StatusWorkers,
+		"The maximum number of resource statuses of a ResourceGroup computed concurrently. "+
+			"1 computes the statuses sequentially.")
+	flag.BoolVar(&rgOptions.PersistHistory, "persist-status-history", rgOptions.PersistHistory,
+		"Persist the status transitions of the resources of each ResourceGroup into a ConfigMap owned by the ResourceGroup.")
+	flag.DurationVar(&orphanScanInterval, "orphan-scan-interval", 0,
+		"The interval between two scans for the live objects owned by the inventory of a ResourceGroup "+
+			"which are not included in the ResourceGroup. The orphans are reported in the status of the ResourceGroup. "+
+			"0 disables the scan.")
+	flag.DurationVar(&resyncInterval, "status-resync-interval", 0,
+		"The interval between two comparisons of the cached statuses of the resources with their live objects, "+
+			"which corrects the statuses whose watch events were lost. 0 disables the resync.")
+	flag.StringVar(&memberClusterNamespace, "member-cluster-namespace", "",
+		"Enable the hub mode, where ResourceGroups include resources of member clusters. "+
+			"The kubeconfig of each member cluster is read from the \""+hub.KubeconfigKey+"\" key of a Secret in this namespace, "+
+			"whose name is the name of the member cluster.")
+	flag.DurationVar(&accessRecheckInterval, "member-cluster-access-recheck-interval", hub.DefaultAccessRecheckInterval,
+		"The interval between two checks of the kinds which the controller is allowed to watch in each member cluster.")
+	flag.StringVar(&annotationKeys.OwningInventory, "owning-inventory-annotation", controllerstatus.DefaultOwningInventoryKey,
+		"The annotation which contains the id of the inventory owning a resource.")
+	flag.StringVar(&annotationKeys.SourceHash, "source-hash-annotation", controllerstatus.DefaultSourceHashAnnotationKey,
+		"The annotation which contains the source hash a resource was applied from.")
+	flag.StringVar(&disableStatusKey, "disable-status-annotation", root.DefaultDisableStatusKey,
+		"The annotation which disables the status of a ResourceGroup when it is set to \""+root.DisableStatusValue+"\".")
+	flag.StringVar(&rgOptions.LeastPrivilegeRole, "least-privilege-role", rgOptions.LeastPrivilegeRole,
+		"The name of the least-privilege ClusterRole of the controller, e.g. generated by \"rgctl role\". "+
+			"The ResourceGroups including resources of kinds which this ClusterRole does not grant access to "+
+			"are reported with an OutsideRole condition. Empty disables the check.")
+	flag.StringVar(&metadataOnlyKinds, "metadata-only-kinds", controllerstatus.DefaultMetadataOnlyKinds,
+		"The comma-separated list of the kinds whose status is computed from the metadata of their objects, in the Kind.group format. "+
+			"Only the metadata of these objects is read from the API server, so that their payload is never fetched.")
+	flag.StringVar(&progressDeadlines, "progress-deadlines", "",
+		"The comma-separated list of the maximum durations the resources of each kind may stay InProgress, "+
+			"in the Kind.group=duration format, e.g. \"Deployment.apps=30m,*=2h\", where * sets the deadline of the other kinds. "+
+			"The ResourceGroups including resources which exceeded their deadline are Stalled. "+
+			"Empty disables the deadlines.")
+	flag.StringVar(&serviceAccount, "service-account", "",
+		"The ServiceAccount of the controller, in the namespace/name format. "+
+			"Only the changes of the RBAC objects granting access to this ServiceAccount trigger a new check "+
+			"of the kinds which the controller is not allowed to watch. Empty considers all the RBAC objects.")
 	flag.Parse()
 
+	kinds, err := controllerstatus.ParseMetadataOnlyKinds(metadataOnlyKinds)
+	if err != nil {
+		return fmt.Errorf("invalid --metadata-only-kinds: %w", err)
+	}
+	rgOptions.MetadataOnlyKinds = kinds
+
+	deadlines, err := resourcegroup.ParseProgressDeadlines(progressDeadlines)
+	if err != nil {
+		return fmt.Errorf("invalid --progress-deadlines: %w", err)
+	}
+	rgOptions.ProgressDeadlines = deadlines
+	rgOptions.AnnotationKeys = annotationKeys
+
+	sa, err := parseServiceAccount(serviceAccount)
+	if err != nil {
+		return fmt.Errorf("invalid --service-account: %w", err)
+	}
+
+	if err := validateAnnotationKeys(map[string]string{
+		"owning-inventory-annotation": annotationKeys.OwningInventory,
+		"source-hash-annotation":      annotationKeys.SourceHash,
+		"disable-status-annotation":   disableStatusKey,
+	}); err != nil {
+		return err
+	}
+
 	profiler.Service()
 
 	// Register the OpenCensus views
@@ -86,13 +190,22 @@ func run() error {
 			setupLog.Error(err, "Unable to stop the OC Agent exporter")
 		}
 	}()
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	options := ctrl.Options{
 		Scheme:             scheme,
 		MetricsBindAddress: metricsAddr,
 		Port:               9443,
 		LeaderElection:     enableLeaderElection,
 		LeaderElectionID:   "413d8c8e.gke.io",
-	})
+	}
+	if memberClusterNamespace != "" {
+		// Only cache the member cluster Secrets.
+		options.NewCache = cache.BuilderWithOptions(cache.Options{
+			SelectorsByObject: cache.SelectorsByObject{
+				&corev1.Secret{}: {Field: fields.OneTermEqualSelector("metadata.namespace", memberClusterNamespace)},
+			},
+		})
+	}
+	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), options)
 	if err != nil {
 		return fmt.Errorf("failed to build manager: %w", err)
 	}
@@ -100,7 +213,28 @@ func run() error {
 	logger := ctrl.Log.WithName("controllers")
 
 	for _, group := range []string{root.KptGroup} {
-		if err := registerControllersForGroup(mgr, logger, group); err != nil {
+		opts := groupOptions{
+			eventBufferSize:    eventBufferSize,
+			historySize:        historySize,
+			orphanScanInterval: orphanScanInterval,
+			hub: hub.Options{
+				SecretNamespace:       memberClusterNamespace,
+				AccessRecheckInterval: accessRecheckInterval,
+				ResyncInterval:        resyncInterval,
+				MetadataOnlyKinds:     kinds,
+				AnnotationKeys:        annotationKeys,
+			},
+			root: root.Options{
+				ServiceAccount:     sa,
+				LeastPrivilegeRole: rgOptions.LeastPrivilegeRole,
+				ResyncInterval:     resyncInterval,
+				MetadataOnlyKinds:  kinds,
+				AnnotationKeys:     annotationKeys,
+				DisableStatusKey:   disableStatusKey,
+			},
+			resourceGroup: rgOptions,
+		}
+		if err := registerControllersForGroup(mgr, logger, group, opts); err != nil {
 			return fmt.Errorf("failed to register controllers for group %s: %w", group, err)
 		}
 	}
@@ -114,11 +248,43 @@ func run() error {
 	return nil
 }
 
-func registerControllersForGroup(mgr ctrl.Manager, logger logr.Logger, group string) error {
-	// channel is watched by ResourceGroup controller.
-	// The Root controller pushes events to it and
-	// the ResourceGroup controller consumes events.
-	channel := make(chan event.GenericEvent)
+// validateAnnotationKeys checks that the values of the given flags are valid annotation keys.
+func validateAnnotationKeys(keys map[string]string) error {
+	for name, key := range keys {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("invalid --%s %q: %s", name, key, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
+
+// parseServiceAccount parses a ServiceAccount in the namespace/name format.
+func parseServiceAccount(value string) (types.NamespacedName, error) {
+	if value == "" {
+		return types.NamespacedName{}, nil
+	}
+	parts := strings.Split(value, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return types.NamespacedName{}, fmt.Errorf("%q is not in the namespace/name format", value)
+	}
+	return types.NamespacedName{Namespace: parts[0], Name: parts[1]}, nil
+}
+
+// groupOptions holds the settings of the controllers registered for a group.
+type groupOptions struct {
+	eventBufferSize    int
+	historySize        int
+	orphanScanInterval time.Duration
+	hub                hub.Options
+	root               root.Options
+	resourceGroup      resourcegroup.Options
+}
+
+func registerControllersForGroup(mgr ctrl.Manager, logger logr.Logger, group string, opts groupOptions) error {
+	// events is watched by ResourceGroup controller.
+	// The Root controller, the watchers and the CRD event handler
+	// push events to it and the ResourceGroup controller consumes events.
+	events := eventbus.New(opts.eventBufferSize)
 
 	setupLog.Info("adding the type resolver")
 	resolver, err := typeresolver.NewTypeResolver(mgr, logger.WithName("TypeResolver"))
@@ -128,13 +294,42 @@ func registerControllersForGroup(mgr ctrl.Manager, logger logr.Logger, group str
 	resolver.Refresh()
 
 	setupLog.Info("adding the Root controller for group " + group)
-	resMap := resourcemap.NewResourceMap()
-	if err := root.NewController(mgr, channel, logger.WithName("Root"), resolver, group, resMap); err != nil {
+	resMap := resourcemap.NewResourceMapWithHistorySize(opts.historySize)
+	if err := mgr.AddMetricsExtraHandler(resourcemap.HistoryPath, resourcemap.HistoryHandler(resMap)); err != nil {
+		return fmt.Errorf("unable to serve the status history for group %s: %w", group, err)
+	}
+	if err := mgr.AddMetricsExtraHandler(resourcemap.OwnershipPath, resourcemap.OwnershipHandler(resMap)); err != nil {
+		return fmt.Errorf("unable to serve the ownership report for group %s: %w", group, err)
+	}
+	if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		resourcemap.ReportOwnership(ctx, resMap, resourcemap.DefaultOwnershipReportInterval)
+		return nil
+	})); err != nil {
+		return fmt.Errorf("unable to report the ownership metrics for group %s: %w", group, err)
+	}
+	var clusterHub *hub.Hub
+	if opts.hub.SecretNamespace != "" {
+		setupLog.Info("adding the member cluster controller for group " + group)
+		clusterHub = hub.New(events, logger.WithName("Hub"), opts.hub)
+		if err := clusterHub.SetupWithManager(mgr); err != nil {
+			return fmt.Errorf("unable to create the member cluster controller for group %s: %w", group, err)
+		}
+	}
+	if err := root.NewController(mgr, events, logger.WithName("Root"), resolver, group, resMap, clusterHub, opts.root); err != nil {
 		return fmt.Errorf("unable to create the root controller for group %s: %w", group, err)
 	}
 
+	if opts.orphanScanInterval > 0 {
+		setupLog.Info("adding the orphan scanner for group " + group)
+		scanner := orphan.NewScanner(mgr.GetAPIReader(), resolver, resMap, events, opts.orphanScanInterval,
+			opts.resourceGroup.AnnotationKeys.OwningInventory, logger.WithName("Orphan"))
+		if err := mgr.Add(scanner); err != nil {
+			return fmt.Errorf("unable to add the orphan scanner for group %s: %w", group, err)
+		}
+	}
+
 	setupLog.Info("adding the ResourceGroup controller for group " + group)
-	if err := resourcegroup.NewRGController(mgr, channel, logger.WithName(v1alpha1.ResourceGroupKind), resolver, resMap, resourcegroup.DefaultDuration); err != nil {
+	if err := resourcegroup.NewRGController(mgr, events, logger.WithName(v1alpha1.ResourceGroupKind), resolver, resMap, clusterHub, resourcegroup.DefaultDuration, opts.resourceGroup); err != nil {
 		return fmt.Errorf("unable to create the ResourceGroup controller %s: %w", group, err)
 	}
 	return nil
@@ -0,0 +1,114 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultMetadataOnlyKinds is the default comma-separated list of the kinds
+// whose status is fully determined by their metadata, in the Kind.group format.
+// Only the metadata of their objects is read from the API server, so that
+// their payload, e.g. the data of the Secrets, is never fetched by the
+// controller.
+const DefaultMetadataOnlyKinds = "Secret,ConfigMap,ServiceAccount," +
+	"Role.rbac.authorization.k8s.io,RoleBinding.rbac.authorization.k8s.io," +
+	"ClusterRole.rbac.authorization.k8s.io,ClusterRoleBinding.rbac.authorization.k8s.io"
+
+// ParseMetadataOnlyKinds parses a comma-separated list of kinds in the
+// Kind.group format. The kinds of the core group have no group suffix.
+func ParseMetadataOnlyKinds(s string) (map[schema.GroupKind]bool, error) {
+	kinds := make(map[schema.GroupKind]bool)
+	for _, value := range strings.Split(s, ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		gk := schema.ParseGroupKind(value)
+		if gk.Kind == "" {
+			return nil, fmt.Errorf("invalid kind %q: must be in the Kind.group format", value)
+		}
+		kinds[gk] = true
+	}
+	return kinds, nil
+}
+
+// MetadataToUnstructured converts the metadata of an object of the given
+// kind into an unstructured object, from which its status can be computed.
+func MetadataToUnstructured(obj *metav1.PartialObjectMetadata, gvk schema.GroupVersionKind) (*unstructured.Unstructured, error) {
+	m, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&obj.ObjectMeta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert the metadata of %s %s/%s: %w", gvk.Kind, obj.Namespace, obj.Name, err)
+	}
+	u := &unstructured.Unstructured{Object: map[string]interface{}{"metadata": m}}
+	u.SetGroupVersionKind(gvk)
+	return u, nil
+}
+
+// GetObject gets an object of the given kind with reader. Only the metadata
+// of the object is read if metadataOnly is true.
+func GetObject(ctx context.Context, reader client.Reader, gvk schema.GroupVersionKind, key client.ObjectKey, metadataOnly bool) (*unstructured.Unstructured, error) {
+	if !metadataOnly {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gvk)
+		if err := reader.Get(ctx, key, obj); err != nil {
+			return nil, err
+		}
+		return obj, nil
+	}
+	obj := &metav1.PartialObjectMetadata{}
+	obj.SetGroupVersionKind(gvk)
+	if err := reader.Get(ctx, key, obj); err != nil {
+		return nil, err
+	}
+	return MetadataToUnstructured(obj, gvk)
+}
+
+// ListObjects lists a page of the objects of the given kind with reader, and
+// returns the objects and the continue token of the next page. Only the
+// metadata of the objects is read if metadataOnly is true.
+func ListObjects(ctx context.Context, reader client.Reader, gvk schema.GroupVersionKind, metadataOnly bool, opts ...client.ListOption) ([]unstructured.Unstructured, string, error) {
+	listGVK := gvk.GroupVersion().WithKind(gvk.Kind + "List")
+	if !metadataOnly {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(listGVK)
+		if err := reader.List(ctx, list, opts...); err != nil {
+			return nil, "", err
+		}
+		return list.Items, list.GetContinue(), nil
+	}
+	list := &metav1.PartialObjectMetadataList{}
+	list.SetGroupVersionKind(listGVK)
+	if err := reader.List(ctx, list, opts...); err != nil {
+		return nil, "", err
+	}
+	items := make([]unstructured.Unstructured, len(list.Items))
+	for i := range list.Items {
+		obj, err := MetadataToUnstructured(&list.Items[i], gvk)
+		if err != nil {
+			return nil, "", err
+		}
+		items[i] = *obj
+	}
+	return items, list.GetContinue(), nil
+}
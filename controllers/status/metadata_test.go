@@ -0,0 +1,74 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+)
+
+func TestParseMetadataOnlyKinds(t *testing.T) {
+	kinds, err := ParseMetadataOnlyKinds(DefaultMetadataOnlyKinds)
+	assert.NoError(t, err)
+	assert.True(t, kinds[schema.GroupKind{Kind: "Secret"}])
+	assert.True(t, kinds[schema.GroupKind{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"}])
+	assert.False(t, kinds[schema.GroupKind{Group: "apps", Kind: "Deployment"}])
+
+	kinds, err = ParseMetadataOnlyKinds(" Secret, ,Widget.example.com ")
+	assert.NoError(t, err)
+	assert.Equal(t, map[schema.GroupKind]bool{
+		{Kind: "Secret"}:                       true,
+		{Group: "example.com", Kind: "Widget"}: true,
+	}, kinds)
+
+	_, err = ParseMetadataOnlyKinds(".example.com")
+	assert.Error(t, err)
+}
+
+func TestGetObjectMetadataOnly(t *testing.T) {
+	reader := fake.NewClientBuilder().WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "ns1",
+			Name:        "secret",
+			Annotations: map[string]string{DefaultOwningInventoryKey: "inv"},
+		},
+		Data: map[string][]byte{"password": []byte("secret")},
+	}).Build()
+	gvk := schema.GroupVersionKind{Version: "v1", Kind: "Secret"}
+
+	obj, err := GetObject(context.TODO(), reader, gvk, types.NamespacedName{Namespace: "ns1", Name: "secret"}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, gvk, obj.GroupVersionKind())
+	assert.Equal(t, "secret", obj.GetName())
+	// The payload of the Secret is not read.
+	assert.NotContains(t, obj.Object, "data")
+	resStatus := ComputeStatus(obj, nil, DefaultAnnotationKeys())
+	assert.Equal(t, v1alpha1.Current, resStatus.Status)
+	assert.Equal(t, "inv", resStatus.InventoryID)
+
+	items, _, err := ListObjects(context.TODO(), reader, gvk, true)
+	assert.NoError(t, err)
+	assert.Len(t, items, 1)
+	assert.NotContains(t, items[0].Object, "data")
+}
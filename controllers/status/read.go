@@ -0,0 +1,71 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+)
+
+// The reasons of the ReadFailed condition of a resource.
+const (
+	// ReadForbidden means that the controller is not allowed to read the resource.
+	ReadForbidden = "Forbidden"
+	// ReadUnauthorized means that the credentials of the controller were rejected.
+	ReadUnauthorized = "Unauthorized"
+	// ReadTimeout means that the request to read the resource timed out.
+	ReadTimeout = "Timeout"
+	// ReadThrottled means that the API server rejected the request because of
+	// too many requests.
+	ReadThrottled = "Throttled"
+	// ReadError means that the request to read the resource failed for another reason.
+	ReadError = "ReadError"
+)
+
+// ReadFailedReason returns the reason of the ReadFailed condition of a
+// resource which could not be read because of err.
+func ReadFailedReason(err error) string {
+	switch {
+	case apierrors.IsForbidden(err):
+		return ReadForbidden
+	case apierrors.IsUnauthorized(err):
+		return ReadUnauthorized
+	case apierrors.IsTimeout(err), apierrors.IsServerTimeout(err), errors.Is(err, context.DeadlineExceeded):
+		return ReadTimeout
+	case apierrors.IsTooManyRequests(err):
+		return ReadThrottled
+	default:
+		return ReadError
+	}
+}
+
+// ReadFailedConditions returns the conditions of a resource which could not be
+// read because of err. The LastTransitionTime of the ReadFailed condition in
+// existing is preserved if its status did not change.
+func ReadFailedConditions(err error, existing []v1alpha1.Condition) []v1alpha1.Condition {
+	return MergeConditions(existing, []v1alpha1.Condition{{
+		Type:               v1alpha1.ReadFailed,
+		Status:             v1alpha1.TrueConditionStatus,
+		Reason:             ReadFailedReason(err),
+		Message:            err.Error(),
+		LastTransitionTime: metav1.Time{Time: time.Now().UTC()},
+	}})
+}
@@ -0,0 +1,62 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+)
+
+func TestReadFailedReason(t *testing.T) {
+	gr := schema.GroupResource{Resource: "secrets"}
+	tests := map[string]struct {
+		err      error
+		expected string
+	}{
+		"forbidden":         {err: apierrors.NewForbidden(gr, "s", nil), expected: ReadForbidden},
+		"unauthorized":      {err: apierrors.NewUnauthorized(""), expected: ReadUnauthorized},
+		"server timeout":    {err: apierrors.NewServerTimeout(gr, "get", 1), expected: ReadTimeout},
+		"deadline exceeded": {err: fmt.Errorf("get: %w", context.DeadlineExceeded), expected: ReadTimeout},
+		"throttled":         {err: apierrors.NewTooManyRequests("", 1), expected: ReadThrottled},
+		"other":             {err: errors.New("connection refused"), expected: ReadError},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ReadFailedReason(tc.err))
+		})
+	}
+}
+
+func TestReadFailedConditionsPreservesTransitionTime(t *testing.T) {
+	before := metav1.NewTime(time.Now().Add(-time.Hour))
+	err := apierrors.NewUnauthorized("")
+	existing := ReadFailedConditions(err, nil)
+	existing[0].LastTransitionTime = before
+
+	conditions := ReadFailedConditions(err, existing)
+	assert.Len(t, conditions, 1)
+	assert.Equal(t, v1alpha1.ReadFailed, conditions[0].Type)
+	assert.Equal(t, before, conditions[0].LastTransitionTime)
+}
@@ -23,20 +23,45 @@ import (
 	"k8s.io/klog/v2"
 	"kpt.dev/resourcegroup/controllers/resourcemap"
 	kstatus "sigs.k8s.io/cli-utils/pkg/kstatus/status"
+	"sigs.k8s.io/cli-utils/pkg/object/dependson"
 	"sigs.k8s.io/yaml"
 
 	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
 )
 
 const (
-	owningInventoryKey = "config.k8s.io/owning-inventory"
-	// SourceHashAnnotationKey is the name of the annotation which contains the source hash
-	SourceHashAnnotationKey = "configmanagement.gke.io/token"
+	// DefaultOwningInventoryKey is the default name of the annotation which
+	// contains the id of the inventory owning a resource, set by kpt and Config Sync.
+	DefaultOwningInventoryKey = "config.k8s.io/owning-inventory"
+	// DefaultSourceHashAnnotationKey is the default name of the annotation
+	// which contains the source hash, set by Config Sync.
+	DefaultSourceHashAnnotationKey = "configmanagement.gke.io/token"
 )
 
+// AnnotationKeys are the names of the annotations read from the objects when
+// computing their statuses. They can be changed for the appliers which use
+// different annotations.
+type AnnotationKeys struct {
+	// OwningInventory is the name of the annotation which contains the id of
+	// the inventory owning a resource.
+	OwningInventory string
+	// SourceHash is the name of the annotation which contains the source hash.
+	SourceHash string
+}
+
+// DefaultAnnotationKeys returns the annotation keys set by kpt and Config Sync.
+func DefaultAnnotationKeys() AnnotationKeys {
+	return AnnotationKeys{
+		OwningInventory: DefaultOwningInventoryKey,
+		SourceHash:      DefaultSourceHashAnnotationKey,
+	}
+}
+
 // ComputeStatus computes the status and conditions that should be
-// saved in the memory.
-func ComputeStatus(obj *unstructured.Unstructured) *resourcemap.CachedStatus {
+// saved in the memory. The LastTransitionTime of the existing conditions
+// is preserved for the conditions whose status did not change. The source
+// hash and the owning inventory are read from the annotations named by keys.
+func ComputeStatus(obj *unstructured.Unstructured, existing []v1alpha1.Condition, keys AnnotationKeys) *resourcemap.CachedStatus {
 	resStatus := &resourcemap.CachedStatus{}
 
 	// get the resource status using the kstatus library
@@ -54,7 +79,7 @@ func ComputeStatus(obj *unstructured.Unstructured) *resourcemap.CachedStatus {
 
 	resStatus.Status = v1alpha1.Status(result.Status)
 	if resStatus.Status == v1alpha1.Failed {
-		resStatus.Conditions = ConvertKstatusConditions(result.Conditions)
+		resStatus.Conditions = ConvertKstatusConditions(result.Conditions, existing)
 	} else if IsCNRMResource(obj.GroupVersionKind().Group) && resStatus.Status != v1alpha1.Current {
 		// Special handling for KCC resources.
 		// It should be removed after KCC resources implement the stalled conditions.
@@ -62,29 +87,75 @@ func ComputeStatus(obj *unstructured.Unstructured) *resourcemap.CachedStatus {
 		if cErr != nil {
 			klog.Errorf(cErr.Error())
 			// fallback to use the kstatus conditions for this resource.
-			resStatus.Conditions = ConvertKstatusConditions(result.Conditions)
+			resStatus.Conditions = ConvertKstatusConditions(result.Conditions, existing)
 		} else {
 			resStatus.Conditions = conditions
 		}
 	}
 
-	hash := GetSourceHash(obj.GetAnnotations())
+	hash := GetSourceHash(obj.GetAnnotations(), keys.SourceHash)
 	if hash != "" {
 		resStatus.SourceHash = hash
 	}
 	// get the inventory ID.
-	inv := getOwningInventory(obj.GetAnnotations())
+	inv := obj.GetAnnotations()[keys.OwningInventory]
 	resStatus.InventoryID = inv
+	resStatus.Dependencies = getDependencies(obj)
 	return resStatus
 }
 
+// getDependencies returns the resources listed in the depends-on annotation of obj.
+func getDependencies(obj *unstructured.Unstructured) []v1alpha1.ObjMetadata {
+	if !dependson.HasAnnotation(obj) {
+		return nil
+	}
+	deps, err := dependson.ReadAnnotation(obj)
+	if err != nil {
+		klog.Errorf("failed to read the dependencies of %s/%s: %v", obj.GetNamespace(), obj.GetName(), err)
+		return nil
+	}
+	result := make([]v1alpha1.ObjMetadata, len(deps))
+	for i, dep := range deps {
+		result[i] = v1alpha1.ObjMetadata{
+			Namespace: dep.Namespace,
+			Name:      dep.Name,
+			GroupKind: v1alpha1.GroupKind{
+				Group: dep.GroupKind.Group,
+				Kind:  dep.GroupKind.Kind,
+			},
+		}
+	}
+	return result
+}
+
 // ConvertKstatusConditions converts the status from kstatus library to the conditions
-// defined in ResourceGroup apis.
-func ConvertKstatusConditions(kstatusConds []kstatus.Condition) []v1alpha1.Condition {
+// defined in ResourceGroup apis. The LastTransitionTime of the existing conditions
+// is preserved for the conditions whose status did not change.
+func ConvertKstatusConditions(kstatusConds []kstatus.Condition, existing []v1alpha1.Condition) []v1alpha1.Condition {
 	var result []v1alpha1.Condition
 	for _, cond := range kstatusConds {
 		result = append(result, convertKstatusCondition(cond))
 	}
+	return MergeConditions(existing, result)
+}
+
+// MergeConditions returns a copy of conditions where the LastTransitionTime of
+// each condition is taken from the condition of the same type in existing,
+// if the status of the condition did not change.
+func MergeConditions(existing, conditions []v1alpha1.Condition) []v1alpha1.Condition {
+	if conditions == nil {
+		return nil
+	}
+	result := make([]v1alpha1.Condition, len(conditions))
+	for i, cond := range conditions {
+		for _, old := range existing {
+			if old.Type == cond.Type && old.Status == cond.Status && !old.LastTransitionTime.IsZero() {
+				cond.LastTransitionTime = old.LastTransitionTime
+				break
+			}
+		}
+		result[i] = cond
+	}
 	return result
 }
 
@@ -94,9 +165,8 @@ func convertKstatusCondition(kstatusCond kstatus.Condition) v1alpha1.Condition {
 		Status:  v1alpha1.ConditionStatus(kstatusCond.Status),
 		Reason:  kstatusCond.Reason,
 		Message: kstatusCond.Message,
-		// When kstatus adds the support for accepting an existing list of conditions and
-		// compute `LastTransitionTime`, we can set LastTransitionTime to:
-		// LastTransitionTime: kstatusCond.LastTransionTime,
+		// kstatus does not compute `LastTransitionTime`, so it is set to now and
+		// ConvertKstatusConditions preserves the time from the existing conditions.
 		// Leaving LastTransitionTime unset or setting it as `metav1.Time{}` or `metav1.Time{Time: time.Time{}}` will cause serialization error:
 		//     status.resourceStatuses.conditions.lastTransitionTime: Invalid value: \"null\":
 		//     status.resourceStatuses.conditions.lastTransitionTime in body must be of type string: \"null\""
@@ -127,22 +197,18 @@ func ReadKCCResourceConditions(obj *unstructured.Unstructured) ([]v1alpha1.Condi
 	return results, err
 }
 
+// SourceHashLength is the maximum length of the source hashes returned by GetSourceHash.
+const SourceHashLength = 7
+
 // GetSourceHash returns the source hash that is defined in the
-// source hash annotation.
-func GetSourceHash(annotations map[string]string) string {
+// source hash annotation named key.
+func GetSourceHash(annotations map[string]string, key string) string {
 	if len(annotations) == 0 {
 		return ""
 	}
-	sourceHash := annotations[SourceHashAnnotationKey]
-	if len(sourceHash) > 7 {
-		return sourceHash[0:7]
+	sourceHash := annotations[key]
+	if len(sourceHash) > SourceHashLength {
+		return sourceHash[0:SourceHashLength]
 	}
 	return sourceHash
 }
-
-func getOwningInventory(annotations map[string]string) string {
-	if len(annotations) == 0 {
-		return ""
-	}
-	return annotations[owningInventoryKey]
-}
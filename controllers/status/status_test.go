@@ -17,8 +17,15 @@ package status
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kstatus "sigs.k8s.io/cli-utils/pkg/kstatus/status"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
 )
 
 func TestGetSourceHash(t *testing.T) {
@@ -40,16 +47,92 @@ func TestGetSourceHash(t *testing.T) {
 		},
 		"should return commit when annotation key exists": {
 			annotations: map[string]string{
-				"foo":                   "bar",
-				SourceHashAnnotationKey: "1234567890",
+				"foo":                          "bar",
+				DefaultSourceHashAnnotationKey: "1234567890",
 			},
 			expectedCommit: "1234567",
 		},
 	}
 	for name, tc := range tests {
 		t.Run(fmt.Sprintf("GetSourceHash %s", name), func(t *testing.T) {
-			commit := GetSourceHash(tc.annotations)
+			commit := GetSourceHash(tc.annotations, DefaultSourceHashAnnotationKey)
 			assert.Equal(t, tc.expectedCommit, commit)
 		})
 	}
 }
+
+func TestCustomAnnotationKeys(t *testing.T) {
+	keys := AnnotationKeys{SourceHash: "example.com/source-hash", OwningInventory: "example.com/owner"}
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"})
+	obj.SetAnnotations(map[string]string{
+		DefaultSourceHashAnnotationKey: "abcdefghij",
+		DefaultOwningInventoryKey:      "default-inv",
+		"example.com/source-hash":      "1234567890",
+		"example.com/owner":            "custom-inv",
+	})
+	resStatus := ComputeStatus(obj, nil, keys)
+	assert.Equal(t, "1234567", resStatus.SourceHash)
+	assert.Equal(t, "custom-inv", resStatus.InventoryID)
+
+	resStatus = ComputeStatus(obj, nil, DefaultAnnotationKeys())
+	assert.Equal(t, "abcdefg", resStatus.SourceHash)
+	assert.Equal(t, "default-inv", resStatus.InventoryID)
+}
+
+func TestMergeConditions(t *testing.T) {
+	before := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	now := metav1.NewTime(time.Now().Truncate(time.Second))
+	existing := []v1alpha1.Condition{
+		{Type: v1alpha1.Reconciling, Status: v1alpha1.TrueConditionStatus, LastTransitionTime: before},
+		{Type: v1alpha1.Stalled, Status: v1alpha1.FalseConditionStatus, LastTransitionTime: before},
+	}
+	conditions := []v1alpha1.Condition{
+		{Type: v1alpha1.Reconciling, Status: v1alpha1.TrueConditionStatus, Message: "updated", LastTransitionTime: now},
+		{Type: v1alpha1.Stalled, Status: v1alpha1.TrueConditionStatus, LastTransitionTime: now},
+		{Type: v1alpha1.Ownership, Status: v1alpha1.TrueConditionStatus, LastTransitionTime: now},
+	}
+
+	got := MergeConditions(existing, conditions)
+	assert.Equal(t, []v1alpha1.Condition{
+		{Type: v1alpha1.Reconciling, Status: v1alpha1.TrueConditionStatus, Message: "updated", LastTransitionTime: before},
+		{Type: v1alpha1.Stalled, Status: v1alpha1.TrueConditionStatus, LastTransitionTime: now},
+		{Type: v1alpha1.Ownership, Status: v1alpha1.TrueConditionStatus, LastTransitionTime: now},
+	}, got)
+	// The input conditions are not modified.
+	assert.Equal(t, now, conditions[0].LastTransitionTime)
+
+	assert.Nil(t, MergeConditions(existing, nil))
+	assert.Equal(t, conditions, MergeConditions(nil, conditions))
+}
+
+func TestConvertKstatusConditionsPreservesTransitionTime(t *testing.T) {
+	before := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	existing := []v1alpha1.Condition{
+		{Type: v1alpha1.Reconciling, Status: v1alpha1.TrueConditionStatus, LastTransitionTime: before},
+	}
+	got := ConvertKstatusConditions([]kstatus.Condition{
+		{Type: kstatus.ConditionReconciling, Status: "True", Reason: "Progressing"},
+	}, existing)
+	assert.Len(t, got, 1)
+	assert.Equal(t, before, got[0].LastTransitionTime)
+}
+
+func TestGetDependencies(t *testing.T) {
+	obj := &unstructured.Unstructured{}
+	obj.SetName("cron")
+	obj.SetNamespace("ns1")
+	assert.Nil(t, getDependencies(obj))
+
+	obj.SetAnnotations(map[string]string{
+		"config.kubernetes.io/depends-on": "/namespaces/ns1/ConfigMap/cm1,apiextensions.k8s.io/CustomResourceDefinition/crontabs.stable.example.com",
+	})
+	assert.Equal(t, []v1alpha1.ObjMetadata{
+		{Namespace: "ns1", Name: "cm1", GroupKind: v1alpha1.GroupKind{Kind: "ConfigMap"}},
+		{Name: "crontabs.stable.example.com", GroupKind: v1alpha1.GroupKind{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}},
+	}, getDependencies(obj))
+
+	// An invalid annotation is ignored.
+	obj.SetAnnotations(map[string]string{"config.kubernetes.io/depends-on": "invalid"})
+	assert.Nil(t, getDependencies(obj))
+}
@@ -15,6 +15,7 @@
 package typeresolver
 
 import (
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
@@ -56,3 +57,16 @@ func fakeResolver() *TypeResolver {
 		},
 	}
 }
+
+// NewFakeTypeResolver returns a TypeResolver that resolves types from the
+// given static mapping instead of querying the API server.
+func NewFakeTypeResolver(mapping map[schema.GroupKind]schema.GroupVersionKind) *TypeResolver {
+	resourceMapping := make(map[schema.GroupKind]schema.GroupVersionResource, len(mapping))
+	for gk, gvk := range mapping {
+		resourceMapping[gk], _ = meta.UnsafeGuessKindToResource(gvk)
+	}
+	return &TypeResolver{
+		typeMapping:     mapping,
+		resourceMapping: resourceMapping,
+	}
+}
@@ -16,12 +16,14 @@ package typeresolver
 
 import (
 	"context"
+	"strings"
 	"sync"
 
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -36,11 +38,14 @@ type TypeResolver struct {
 	mu          sync.Mutex
 	dc          *discovery.DiscoveryClient
 	typeMapping map[schema.GroupKind]schema.GroupVersionKind
+	// resourceMapping maps a GroupKind to the resource of its preferred version.
+	resourceMapping map[schema.GroupKind]schema.GroupVersionResource
 }
 
 // Refresh refreshes the type mapping by querying the api server
 func (r *TypeResolver) Refresh() {
 	mapping := make(map[schema.GroupKind]schema.GroupVersionKind)
+	resourceMapping := make(map[schema.GroupKind]schema.GroupVersionResource)
 	apiResourcesList, err := discovery.ServerPreferredResources(r.dc)
 	if err != nil {
 		r.log.Error(err, "Unable to fetch api resources list by dynamic client")
@@ -58,11 +63,16 @@ func (r *TypeResolver) Refresh() {
 				Kind:  resource.Kind,
 			}
 			mapping[gk] = gk.WithVersion(gv.Version)
+			// The subresources, e.g. deployments/status, share the kind of their resource.
+			if !strings.Contains(resource.Name, "/") {
+				resourceMapping[gk] = gv.WithResource(resource.Name)
+			}
 		}
 	}
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.typeMapping = mapping
+	r.resourceMapping = resourceMapping
 }
 
 // Resolve maps the provided GroupKind to a GroupVersionKind
@@ -73,6 +83,15 @@ func (r *TypeResolver) Resolve(gk schema.GroupKind) (schema.GroupVersionKind, bo
 	return item, found
 }
 
+// ResolveResource maps the provided GroupKind to the resource of its
+// preferred GroupVersion.
+func (r *TypeResolver) ResolveResource(gk schema.GroupKind) (schema.GroupVersionResource, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	item, found := r.resourceMapping[gk]
+	return item, found
+}
+
 // Reconcile implements reconciler.Reconciler. This function handles reconciliation
 // for the type mapping.
 // +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;watch
@@ -107,3 +126,19 @@ func NewTypeResolver(mgr ctrl.Manager, logger logr.Logger) (*TypeResolver, error
 	})
 	return r, c.Watch(&source.Kind{Type: u}, &handler.EnqueueRequestForObject{})
 }
+
+// NewTypeResolverForConfig creates a TypeResolver which is refreshed once
+// from the API server of the given config, for the commands which do not run
+// a controller manager.
+func NewTypeResolverForConfig(cfg *rest.Config, logger logr.Logger) (*TypeResolver, error) {
+	dc, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	r := &TypeResolver{
+		log: logger,
+		dc:  dc,
+	}
+	r.Refresh()
+	return r, nil
+}
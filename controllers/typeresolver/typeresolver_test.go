@@ -51,3 +51,18 @@ func TestResolve(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveResource(t *testing.T) {
+	r := NewFakeTypeResolver(map[schema.GroupKind]schema.GroupVersionKind{
+		{Group: "apps", Kind: "Deployment"}: {Group: "apps", Version: "v1", Kind: "Deployment"},
+		{Kind: "NetworkPolicy"}:             {Group: "networking.k8s.io", Version: "v1", Kind: "NetworkPolicy"},
+	})
+	gvr, found := r.ResolveResource(schema.GroupKind{Group: "apps", Kind: "Deployment"})
+	assert.True(t, found)
+	assert.Equal(t, schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, gvr)
+	gvr, found = r.ResolveResource(schema.GroupKind{Kind: "NetworkPolicy"})
+	assert.True(t, found)
+	assert.Equal(t, "networkpolicies", gvr.Resource)
+	_, found = r.ResolveResource(schema.GroupKind{Group: "not.exist", Kind: "UnFound"})
+	assert.False(t, found)
+}
@@ -0,0 +1,70 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watch
+
+import (
+	"context"
+	"fmt"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	"k8s.io/client-go/rest"
+)
+
+// watchVerbs are the verbs which the controller needs on a resource to watch it.
+var watchVerbs = []string{"list", "watch"}
+
+// accessFunc returns the verbs needed to watch the objects of a GVK in all
+// the namespaces which the controller is not allowed.
+type accessFunc func(ctx context.Context, gvk schema.GroupVersionKind) ([]string, error)
+
+// newAccessFunc returns an accessFunc which checks the permissions of the
+// controller with SelfSubjectAccessReviews.
+func newAccessFunc(cfg *rest.Config, mapper meta.RESTMapper) (accessFunc, error) {
+	client, err := authorizationv1client.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, gvk schema.GroupVersionKind) ([]string, error) {
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get REST mapping for %s: %w", gvk, err)
+		}
+		var forbidden []string
+		for _, verb := range watchVerbs {
+			review := &authorizationv1.SelfSubjectAccessReview{
+				Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+					ResourceAttributes: &authorizationv1.ResourceAttributes{
+						Verb:     verb,
+						Group:    mapping.Resource.Group,
+						Version:  mapping.Resource.Version,
+						Resource: mapping.Resource.Resource,
+					},
+				},
+			}
+			result, err := client.SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to review the access to %s: %w", mapping.Resource, err)
+			}
+			if !result.Status.Allowed {
+				forbidden = append(forbidden, verb)
+			}
+		}
+		return forbidden, nil
+	}, nil
+}
@@ -27,12 +27,14 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	k8sevent "sigs.k8s.io/controller-runtime/pkg/event"
 
 	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/controllers/eventbus"
+	"kpt.dev/resourcegroup/controllers/metrics"
 	"kpt.dev/resourcegroup/controllers/resourcemap"
 	"kpt.dev/resourcegroup/controllers/status"
 )
@@ -69,18 +71,31 @@ const errorLoggingInterval = time.Second
 // listed in a ResourceGroup CR.
 type filteredWatcher struct {
 	gvk        string
+	gk         schema.GroupKind
 	startWatch startWatchFunc
-	resources  *resourcemap.ResourceMap
+	// startList lists the objects of the watched type after the watch
+	// expired, to find the changes which happened while it was not watched.
+	// The relisting is skipped when it is nil.
+	startList startListFunc
+	// resyncInterval is the interval between two relistings comparing the
+	// cached statuses with the live objects. 0 disables the resync.
+	resyncInterval time.Duration
+	// annotationKeys names the annotations read when computing the statuses.
+	annotationKeys status.AnnotationKeys
+	resources      *resourcemap.ResourceMap
 	// errorTracker maps an error to the time when the same error happened last time.
 	errorTracker map[string]time.Time
 
-	// channel is the channel for ResourceGroup generic events.
-	channel chan k8sevent.GenericEvent
+	// events is the event bus for ResourceGroup events.
+	events *eventbus.Bus
 
 	// The following fields are guarded by the mutex.
 	mux     sync.Mutex
 	base    watch.Interface
 	stopped bool
+	// cancel cancels the context of the current Run call, which aborts any
+	// in-flight watch request and retry backoff.
+	cancel context.CancelFunc
 }
 
 // filteredWatcher implements the Runnable interface.
@@ -89,12 +104,16 @@ var _ Runnable = &filteredWatcher{}
 // NewFiltered returns a new filtered watch initialized with the given options.
 func NewFiltered(_ context.Context, cfg watcherConfig) Runnable {
 	return &filteredWatcher{
-		gvk:          cfg.gvk.String(),
-		startWatch:   cfg.startWatch,
-		resources:    cfg.resources,
-		base:         watch.NewEmptyWatch(),
-		errorTracker: make(map[string]time.Time),
-		channel:      cfg.channel,
+		gvk:            cfg.gvk.String(),
+		gk:             cfg.gvk.GroupKind(),
+		startWatch:     cfg.startWatch,
+		startList:      cfg.startList,
+		resyncInterval: cfg.resyncInterval,
+		annotationKeys: cfg.annotationKeys,
+		resources:      cfg.resources,
+		base:           watch.NewEmptyWatch(),
+		errorTracker:   make(map[string]time.Time),
+		events:         cfg.events,
 	}
 }
 
@@ -134,32 +153,82 @@ func (w *filteredWatcher) Stop() {
 
 	w.base.Stop()
 	w.stopped = true
+	if w.cancel != nil {
+		w.cancel()
+	}
 }
 
-func waitUntilNextRetry(retries int) {
+// waitUntilNextRetry blocks until the backoff for the given number of retries
+// has elapsed or ctx is done, whichever happens first.
+func waitUntilNextRetry(ctx context.Context, retries int) {
 	if retries > maxWatchRetryFactor {
 		retries = maxWatchRetryFactor
 	}
 	milliseconds := int64(math.Pow(2, float64(retries)))
 	duration := time.Duration(milliseconds) * time.Millisecond
-	time.Sleep(duration)
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
 }
 
 // Run reads the event from the base watch interface,
 // filters the event and pushes the object contained
 // in the event to the controller work queue.
-func (w *filteredWatcher) Run(context.Context) error {
+//
+// Run returns when ctx is done or when Stop is called.
+func (w *filteredWatcher) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	w.mux.Lock()
+	w.cancel = cancel
+	w.mux.Unlock()
+
 	klog.Infof("Watch started for %s", w.gvk)
 	var resourceVersion string
 	var retriesForWatchError int
+	// relist is true when the watch expired, so that the events which
+	// happened before the watch is restarted may have been missed.
+	var relist bool
+	// resync ticks when the cached statuses are compared with the live
+	// objects, to correct the statuses of the events which were lost.
+	var resync <-chan time.Time
+	if w.resyncInterval > 0 && w.startList != nil {
+		ticker := time.NewTicker(w.resyncInterval)
+		defer ticker.Stop()
+		resync = ticker.C
+	}
+
+	for ctx.Err() == nil {
+		if relist && w.startList != nil {
+			listVersion, _, err := w.relist(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					break
+				}
+				if w.addError(watchEventErrorType + errorID(err)) {
+					klog.Errorf("Relisting %s failed: %v", w.gvk, err)
+				}
+				retriesForWatchError++
+				waitUntilNextRetry(ctx, retriesForWatchError)
+				continue
+			}
+			resourceVersion = listVersion
+		}
+		relist = false
 
-	for {
 		// There are three ways this function can return:
 		// 1. false, error -> We were unable to start the watch, so exit Run().
 		// 2. false, nil   -> We have been stopped via Stop(), so exit Run().
 		// 3. true,  nil   -> We have not been stopped and we started a new watch.
-		started, err := w.start(resourceVersion)
+		started, err := w.start(ctx, resourceVersion)
 		if err != nil {
+			if ctx.Err() != nil {
+				// The watch request was aborted because we are shutting down.
+				break
+			}
 			return err
 		}
 		if !started {
@@ -169,9 +238,24 @@ func (w *filteredWatcher) Run(context.Context) error {
 		eventCount := 0
 		ignoredEventCount := 0
 		klog.Infof("(Re)starting watch for %s at resource version %q", w.gvk, resourceVersion)
-		for event := range w.base.ResultChan() {
+		results := w.resultChan()
+	Events:
+		for {
+			var event watch.Event
+			var ok bool
+			select {
+			case <-ctx.Done():
+				break Events
+			case <-resync:
+				w.resync(ctx)
+				continue
+			case event, ok = <-results:
+				if !ok {
+					break Events
+				}
+			}
 			w.pruneErrors()
-			newVersion, ignoreEvent, err := w.handle(event)
+			newVersion, ignoreEvent, err := w.handle(ctx, event)
 			eventCount++
 			if ignoreEvent {
 				ignoredEventCount++
@@ -184,11 +268,14 @@ func (w *filteredWatcher) Run(context.Context) error {
 					// Reset `resourceVersion` to an empty string here so that we can start a new
 					// watch at the most recent resource version.
 					resourceVersion = ""
+					// The objects deleted before the new watch starts never produce
+					// a Deleted event, so they are found by relisting.
+					relist = true
 				} else if w.addError(watchEventErrorType + errorID(err)) {
 					klog.Errorf("Watch for %s at resource version %q ended with: %v", w.gvk, resourceVersion, err)
 				}
 				retriesForWatchError++
-				waitUntilNextRetry(retriesForWatchError)
+				waitUntilNextRetry(ctx, retriesForWatchError)
 				// Call `break` to restart the watch.
 				break
 			}
@@ -200,22 +287,33 @@ func (w *filteredWatcher) Run(context.Context) error {
 		klog.Infof("Ending watch for %s at resource version %q (total events: %d, ignored events: %d)",
 			w.gvk, resourceVersion, eventCount, ignoredEventCount)
 	}
+	w.mux.Lock()
+	w.base.Stop()
+	w.mux.Unlock()
 	klog.Infof("Watch stopped for %s", w.gvk)
 	return nil
 }
 
+// resultChan returns the result channel of the current base watch in a
+// threadsafe manner.
+func (w *filteredWatcher) resultChan() <-chan watch.Event {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	return w.base.ResultChan()
+}
+
 // start initiates a new base watch at the given resource version in a
 // threadsafe manner and returns true if the new base watch was created. Returns
 // false if the filteredWatcher is already stopped and returns error if the base
 // watch could not be started.
-func (w *filteredWatcher) start(resourceVersion string) (bool, error) {
+func (w *filteredWatcher) start(ctx context.Context, resourceVersion string) (bool, error) {
 	w.mux.Lock()
-	defer w.mux.Unlock()
-
 	if w.stopped {
+		w.mux.Unlock()
 		return false, nil
 	}
 	w.base.Stop()
+	w.mux.Unlock()
 
 	// We want to avoid situations of hanging watchers. Stop any watchers that
 	// do not receive any events within the timeout window.
@@ -228,10 +326,20 @@ func (w *filteredWatcher) start(resourceVersion string) (bool, error) {
 		Watch:               true,
 	}
 
-	base, err := w.startWatch(options)
+	// The watch request is sent without holding the mutex, so that Stop can
+	// cancel it while it is in flight.
+	base, err := w.startWatch(ctx, options)
 	if err != nil {
 		return false, fmt.Errorf("failed to start watch for %s: %v", w.gvk, err)
 	}
+
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	if w.stopped {
+		// Stop was called while the watch request was in flight.
+		base.Stop()
+		return false, nil
+	}
 	w.base = base
 	return true, nil
 }
@@ -262,7 +370,7 @@ func errorID(err error) string {
 // handle returns the new resource version, whether the event should be ignored,
 // and an error indicating that a watch.Error event type was encountered and the
 // watch should be restarted.
-func (w *filteredWatcher) handle(event watch.Event) (string, bool, error) {
+func (w *filteredWatcher) handle(ctx context.Context, event watch.Event) (string, bool, error) {
 	var deleted bool
 	switch event.Type {
 	case watch.Added, watch.Modified:
@@ -304,27 +412,118 @@ func (w *filteredWatcher) handle(event watch.Event) (string, bool, error) {
 	}
 
 	if deleted {
-		klog.Infof("updating the reconciliation status: %v: %v", id, v1alpha1.NotFound)
-		w.resources.SetStatus(id, &resourcemap.CachedStatus{Status: v1alpha1.NotFound})
+		w.setNotFound(ctx, id)
 	} else {
 		klog.Infof("Received watch event for created/updated object %q", id)
-		resStatus := status.ComputeStatus(object)
-		if resStatus != nil {
-			klog.Infof("updating the reconciliation status: %v: %v", id, resStatus.Status)
-			w.resources.SetStatus(id, resStatus)
-		}
+		w.setStatus(ctx, id, object)
+	}
+	return object.GetResourceVersion(), false, nil
+}
+
+// setStatus updates the cached status of a resource from its object, and
+// sends an event for the ResourceGroups including it. It returns true if the
+// cached status was updated.
+func (w *filteredWatcher) setStatus(ctx context.Context, id v1alpha1.ObjMetadata, object *unstructured.Unstructured) bool {
+	var existing []v1alpha1.Condition
+	if cached := w.resources.GetStatus(id); cached != nil {
+		existing = cached.Conditions
 	}
+	resStatus := status.ComputeStatus(object, existing, w.annotationKeys)
+	if resStatus != nil {
+		klog.Infof("updating the reconciliation status: %v: %v", id, resStatus.Status)
+		resStatus.ResourceVersion = object.GetResourceVersion()
+		w.resources.SetStatus(id, resStatus)
+	}
+	w.publish(ctx, id)
+	return resStatus != nil
+}
 
+// setNotFound marks a resource as NotFound, and sends an event for the
+// ResourceGroups including it.
+func (w *filteredWatcher) setNotFound(ctx context.Context, id v1alpha1.ObjMetadata) {
+	klog.Infof("updating the reconciliation status: %v: %v", id, v1alpha1.NotFound)
+	w.resources.SetStatus(id, &resourcemap.CachedStatus{Status: v1alpha1.NotFound})
+	w.publish(ctx, id)
+}
+
+// publish sends an event for the ResourceGroups including the resource.
+func (w *filteredWatcher) publish(ctx context.Context, id v1alpha1.ObjMetadata) {
 	for _, r := range w.resources.Get(id) {
-		resgroup := &v1alpha1.ResourceGroup{}
-		resgroup.SetNamespace(r.Namespace)
-		resgroup.SetName(r.Name)
+		klog.Infof("sending a generic event from watcher for %v", r)
+		w.events.Publish(ctx, r)
+	}
+}
 
-		klog.Infof("sending a generic event from watcher for %v", resgroup.GetObjectMeta())
-		w.channel <- k8sevent.GenericEvent{Object: resgroup}
+// resync compares the cached statuses with the live objects, and records the
+// number of corrected statuses.
+func (w *filteredWatcher) resync(ctx context.Context) {
+	_, corrected, err := w.relist(ctx)
+	if err != nil {
+		if ctx.Err() == nil && w.addError(watchEventErrorType+errorID(err)) {
+			klog.Errorf("Resyncing %s failed: %v", w.gvk, err)
+		}
+		return
+	}
+	if corrected > 0 {
+		klog.Warningf("Resyncing %s corrected %d cached statuses", w.gvk, corrected)
 	}
+	metrics.RecordResyncCorrections(ctx, int64(corrected))
+}
 
-	return object.GetResourceVersion(), false, nil
+// relistPageSize is the maximum number of objects in a page of a relist.
+const relistPageSize = 500
+
+// relist lists the objects of the watched type page by page. It updates the
+// cached statuses of the listed resources whose resource version changed, and
+// marks the resources of the ResourceGroups which are not listed as NotFound,
+// since their Deleted events may have been missed. It returns the resource
+// version of the list, from which the watch resumes, and the number of
+// corrected statuses.
+func (w *filteredWatcher) relist(ctx context.Context) (string, int, error) {
+	var corrected, count int
+	var resourceVersion, continueToken string
+	listed := map[v1alpha1.ObjMetadata]bool{}
+	for {
+		list, err := w.startList(ctx, metav1.ListOptions{Limit: relistPageSize, Continue: continueToken})
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to list %s: %w", w.gvk, err)
+		}
+		for i := range list.Items {
+			object := &list.Items[i]
+			id := getID(object)
+			listed[id] = true
+			if !w.shouldProcess(object) {
+				continue
+			}
+			if cached := w.resources.GetStatus(id); cached != nil && cached.ResourceVersion == object.GetResourceVersion() {
+				continue
+			}
+			if w.setStatus(ctx, id, object) {
+				corrected++
+			}
+		}
+		count += len(list.Items)
+		// All the pages are read from the snapshot of the first one.
+		resourceVersion = list.GetResourceVersion()
+		continueToken = list.GetContinue()
+		if continueToken == "" {
+			break
+		}
+	}
+	var vanished int
+	for _, id := range w.resources.GetResources(w.gk) {
+		if listed[id] {
+			continue
+		}
+		if cached := w.resources.GetStatus(id); cached != nil && cached.Status == v1alpha1.NotFound {
+			continue
+		}
+		w.setNotFound(ctx, id)
+		vanished++
+	}
+	klog.Infof("Relisted %s at resource version %q: %d objects, %d statuses updated, %d resources not found",
+		w.gvk, resourceVersion, count, corrected, vanished)
+	return resourceVersion, corrected + vanished, nil
 }
 
 // shouldProcess returns true if the given object should be enqueued by the
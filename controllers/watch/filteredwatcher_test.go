@@ -0,0 +1,254 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/goleak"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/controllers/eventbus"
+	"kpt.dev/resourcegroup/controllers/resourcemap"
+)
+
+// runAsync starts w.Run in a goroutine and returns a channel that is closed
+// when Run returns.
+func runAsync(ctx context.Context, t *testing.T, w Runnable) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := w.Run(ctx); err != nil {
+			t.Errorf("Run() returned error: %v", err)
+		}
+	}()
+	return done
+}
+
+func waitForDone(t *testing.T, done <-chan struct{}) {
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return")
+	}
+}
+
+func TestFilteredWatcherStop(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	started := make(chan struct{}, 1)
+	w := NewFiltered(context.Background(), watcherConfig{
+		startWatch: func(context.Context, metav1.ListOptions) (watch.Interface, error) {
+			started <- struct{}{}
+			return watch.NewFake(), nil
+		},
+	})
+	done := runAsync(context.Background(), t, w)
+	<-started
+	w.Stop()
+	waitForDone(t, done)
+}
+
+func TestFilteredWatcherContextCanceled(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{}, 1)
+	w := NewFiltered(ctx, watcherConfig{
+		startWatch: func(context.Context, metav1.ListOptions) (watch.Interface, error) {
+			started <- struct{}{}
+			return watch.NewFake(), nil
+		},
+	})
+	done := runAsync(ctx, t, w)
+	<-started
+	cancel()
+	waitForDone(t, done)
+}
+
+func TestFilteredWatcherCanceledDuringStart(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	w := NewFiltered(ctx, watcherConfig{
+		// startWatch simulates an in-flight watch request which only
+		// returns when its context is done.
+		startWatch: func(ctx context.Context, _ metav1.ListOptions) (watch.Interface, error) {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	})
+	done := runAsync(ctx, t, w)
+	<-started
+	w.Stop()
+	waitForDone(t, done)
+	cancel()
+}
+
+func TestFilteredWatcherStopDuringRetry(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	fake := watch.NewFake()
+	w := NewFiltered(context.Background(), watcherConfig{
+		startWatch: func(context.Context, metav1.ListOptions) (watch.Interface, error) {
+			return fake, nil
+		},
+	}).(*filteredWatcher)
+	done := runAsync(context.Background(), t, w)
+
+	// Report an error event, which makes the watcher back off before it
+	// restarts the watch.
+	fake.Error(&metav1.Status{Status: metav1.StatusFailure, Reason: metav1.StatusReasonInternalError})
+	w.Stop()
+	waitForDone(t, done)
+}
+
+func TestWaitUntilNextRetry(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	// Without cancellation, this would sleep for more than 4 minutes.
+	waitUntilNextRetry(ctx, maxWatchRetryFactor)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("waitUntilNextRetry() took %v after the context was canceled", elapsed)
+	}
+}
+
+func newConfigMap(name, resourceVersion string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion("v1")
+	u.SetKind("ConfigMap")
+	u.SetNamespace("ns1")
+	u.SetName(name)
+	u.SetResourceVersion(resourceVersion)
+	return u
+}
+
+func TestFilteredWatcherRelistAfterExpiredWatch(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	cm1 := v1alpha1.ObjMetadata{Namespace: "ns1", Name: "cm1", GroupKind: v1alpha1.GroupKind{Kind: "ConfigMap"}}
+	cm2 := v1alpha1.ObjMetadata{Namespace: "ns1", Name: "cm2", GroupKind: v1alpha1.GroupKind{Kind: "ConfigMap"}}
+	group := types.NamespacedName{Namespace: "ns1", Name: "group"}
+	resources := resourcemap.NewResourceMap()
+	resources.Reconcile(context.Background(), group, []v1alpha1.ObjMetadata{cm1, cm2}, false)
+	events := eventbus.New(10)
+
+	first := watch.NewFake()
+	second := watch.NewFake()
+	watches := make(chan metav1.ListOptions, 2)
+	var started int
+	w := NewFiltered(context.Background(), watcherConfig{
+		gvk: schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+		startWatch: func(_ context.Context, options metav1.ListOptions) (watch.Interface, error) {
+			watches <- options
+			started++
+			if started == 1 {
+				return first, nil
+			}
+			return second, nil
+		},
+		// cm2 was deleted while the watch was expired. The objects are
+		// listed in pages of one object.
+		startList: func(_ context.Context, options metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+			assert.Equal(t, int64(relistPageSize), options.Limit)
+			list := &unstructured.UnstructuredList{}
+			list.SetResourceVersion("5")
+			if options.Continue == "" {
+				list.Items = []unstructured.Unstructured{*newConfigMap("cm1", "3")}
+				list.SetContinue("page2")
+				return list, nil
+			}
+			assert.Equal(t, "page2", options.Continue)
+			list.Items = []unstructured.Unstructured{*newConfigMap("cm3", "4")}
+			return list, nil
+		},
+		resources: resources,
+		events:    events,
+	})
+	done := runAsync(context.Background(), t, w)
+
+	assert.Equal(t, "", (<-watches).ResourceVersion)
+	first.Add(newConfigMap("cm1", "1"))
+	first.Add(newConfigMap("cm2", "2"))
+	first.Error(&metav1.Status{Status: metav1.StatusFailure, Reason: metav1.StatusReasonExpired, Code: 410})
+
+	// The new watch resumes from the resource version of the list.
+	assert.Equal(t, "5", (<-watches).ResourceVersion)
+	assert.Equal(t, v1alpha1.Current, resources.GetStatus(cm1).Status)
+	assert.Equal(t, v1alpha1.NotFound, resources.GetStatus(cm2).Status)
+	assert.Equal(t, []types.NamespacedName{group}, events.Pending())
+
+	w.Stop()
+	waitForDone(t, done)
+}
+
+func TestFilteredWatcherResync(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	cm1 := v1alpha1.ObjMetadata{Namespace: "ns1", Name: "cm1", GroupKind: v1alpha1.GroupKind{Kind: "ConfigMap"}}
+	cm2 := v1alpha1.ObjMetadata{Namespace: "ns1", Name: "cm2", GroupKind: v1alpha1.GroupKind{Kind: "ConfigMap"}}
+	group := types.NamespacedName{Namespace: "ns1", Name: "group"}
+	resources := resourcemap.NewResourceMap()
+	resources.Reconcile(context.Background(), group, []v1alpha1.ObjMetadata{cm1, cm2}, false)
+	// The event updating cm1 to resource version "2" was lost.
+	resources.SetStatus(cm1, &resourcemap.CachedStatus{Status: v1alpha1.InProgress, ResourceVersion: "1"})
+	resources.SetStatus(cm2, &resourcemap.CachedStatus{Status: v1alpha1.Current, ResourceVersion: "3"})
+	events := eventbus.New(10)
+
+	listed := make(chan struct{}, 1)
+	w := NewFiltered(context.Background(), watcherConfig{
+		gvk: schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+		startWatch: func(context.Context, metav1.ListOptions) (watch.Interface, error) {
+			return watch.NewFake(), nil
+		},
+		startList: func(context.Context, metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+			defer func() {
+				select {
+				case listed <- struct{}{}:
+				default:
+				}
+			}()
+			return &unstructured.UnstructuredList{Items: []unstructured.Unstructured{
+				*newConfigMap("cm1", "2"),
+				*newConfigMap("cm2", "3"),
+			}}, nil
+		},
+		resyncInterval: 10 * time.Millisecond,
+		resources:      resources,
+		events:         events,
+	})
+	done := runAsync(context.Background(), t, w)
+	<-listed
+	w.Stop()
+	waitForDone(t, done)
+
+	assert.Equal(t, v1alpha1.Current, resources.GetStatus(cm1).Status)
+	assert.Equal(t, "2", resources.GetStatus(cm1).ResourceVersion)
+	// The status of cm2 is unchanged, since its resource version did not change.
+	assert.Equal(t, &resourcemap.CachedStatus{Status: v1alpha1.Current, ResourceVersion: "3"}, resources.GetStatus(cm2))
+	assert.Equal(t, []types.NamespacedName{group}, events.Pending())
+}
@@ -17,15 +17,18 @@ package watch
 import (
 	"context"
 	"sync"
+	"time"
 
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/rest"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
-	"sigs.k8s.io/controller-runtime/pkg/event"
 
+	"kpt.dev/resourcegroup/controllers/eventbus"
+	"kpt.dev/resourcegroup/controllers/metrics"
 	"kpt.dev/resourcegroup/controllers/resourcemap"
+	"kpt.dev/resourcegroup/controllers/status"
 )
 
 // Manager records which GVK's are watched.
@@ -44,13 +47,35 @@ type Manager struct {
 	// createWatcherFunc is the function to create a watcher.
 	createWatcherFunc createWatcherFunc
 
-	// channel is the channel for ResourceGroup generic events.
-	channel chan event.GenericEvent
+	// checkAccess returns the verbs needed to watch a GVK which the
+	// controller is not allowed. The access is not checked when it is nil.
+	checkAccess accessFunc
+
+	// events is the event bus for ResourceGroup events.
+	events *eventbus.Bus
+
+	// resyncInterval is the interval between two resyncs of the cached
+	// statuses of each watched type. 0 disables the resync.
+	resyncInterval time.Duration
+
+	// metadataOnlyKinds is the set of kinds of which only the metadata of
+	// the objects is watched.
+	metadataOnlyKinds map[schema.GroupKind]bool
+
+	// annotationKeys names the annotations read when computing the statuses.
+	annotationKeys status.AnnotationKeys
 
 	// The following fields are guarded by the mutex.
 	mux sync.Mutex
 	// watcherMap maps GVKs to their associated watchers
 	watcherMap map[schema.GroupVersionKind]Runnable
+	// forbidden maps the GVKs which are not watched because the controller
+	// is not allowed to, to the forbidden verbs. Their access is checked
+	// again only after ResetForbidden is called.
+	forbidden map[schema.GroupVersionKind][]string
+	// resets counts the calls to ResetForbidden, so that the access checked
+	// before a reset is not recorded after it.
+	resets uint64
 	// needsUpdate indicates if the Manager's watches need to be updated.
 	needsUpdate bool
 }
@@ -60,26 +85,48 @@ type Options struct {
 	// Mapper is the RESTMapper to use for mapping GroupVersionKinds to Resources.
 	Mapper meta.RESTMapper
 
+	// ResyncInterval is the interval between two comparisons of the cached
+	// statuses with the live objects of each watched type.
+	// 0 disables the resync.
+	ResyncInterval time.Duration
+
+	// MetadataOnlyKinds is the set of kinds whose status is fully determined
+	// by their metadata. Only the metadata of their objects is watched.
+	MetadataOnlyKinds map[schema.GroupKind]bool
+
+	// AnnotationKeys names the annotations read when computing the statuses
+	// of the watched objects.
+	AnnotationKeys status.AnnotationKeys
+
 	watcherFunc createWatcherFunc
+	accessFunc  accessFunc
 }
 
 // DefaultOptions return the default options:
 // - create discovery RESTmapper from the passed rest.Config
 // - use createWatcher to create watchers
+// - check the access to each GVK with a SelfSubjectAccessReview before watching it
+// - read the source hash and the owning inventory from the default annotations
 func DefaultOptions(cfg *rest.Config) (*Options, error) {
 	mapper, err := apiutil.NewDynamicRESTMapper(cfg)
 	if err != nil {
 		return nil, err
 	}
+	access, err := newAccessFunc(cfg, mapper)
+	if err != nil {
+		return nil, err
+	}
 
 	return &Options{
-		Mapper:      mapper,
-		watcherFunc: createWatcher,
+		Mapper:         mapper,
+		AnnotationKeys: status.DefaultAnnotationKeys(),
+		watcherFunc:    createWatcher,
+		accessFunc:     access,
 	}, nil
 }
 
 // NewManager starts a new watch manager
-func NewManager(cfg *rest.Config, decls *resourcemap.ResourceMap, channel chan event.GenericEvent, options *Options) (*Manager, error) {
+func NewManager(cfg *rest.Config, decls *resourcemap.ResourceMap, events *eventbus.Bus, options *Options) (*Manager, error) {
 	if options == nil {
 		var err error
 		options, err = DefaultOptions(cfg)
@@ -92,9 +139,14 @@ func NewManager(cfg *rest.Config, decls *resourcemap.ResourceMap, channel chan e
 		cfg:               cfg,
 		resources:         decls,
 		watcherMap:        make(map[schema.GroupVersionKind]Runnable),
+		forbidden:         make(map[schema.GroupVersionKind][]string),
 		createWatcherFunc: options.watcherFunc,
+		checkAccess:       options.accessFunc,
 		mapper:            options.Mapper,
-		channel:           channel,
+		events:            events,
+		resyncInterval:    options.ResyncInterval,
+		metadataOnlyKinds: options.MetadataOnlyKinds,
+		annotationKeys:    options.AnnotationKeys,
 		mux:               sync.Mutex{},
 	}, nil
 }
@@ -111,14 +163,57 @@ func (m *Manager) NeedsUpdate() bool {
 // - stop watchers for any GroupVersionKind that is not present in the given map.
 // - start watchers for any GroupVersionKind that is present in the given map and not present in the current watch map.
 //
+// The access to the new GVKs is checked without holding the lock, since it
+// requires a request to the API server for each of them.
+//
 // This function is threadsafe.
 func (m *Manager) UpdateWatches(ctx context.Context, gvkMap map[schema.GroupVersionKind]struct{}) error {
+	newGVKs, resets := m.stopWatches(ctx, gvkMap)
+	accesses := m.checkAccesses(ctx, newGVKs)
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	// Start new watchers
+	var startedWatches uint64
+	var errs []error
+	for _, gvk := range newGVKs {
+		if _, isWatched := m.watcherMap[gvk]; isWatched {
+			// The watcher was started by a concurrent call.
+			continue
+		}
+		if access, checked := accesses[gvk]; checked && !m.recordAccess(ctx, gvk, access, resets) {
+			continue
+		}
+		if err := m.startWatcher(ctx, gvk); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		startedWatches++
+	}
+
+	if startedWatches > 0 {
+		klog.Infof("The watch manager made new progress: started %d new watches", startedWatches)
+	} else {
+		klog.V(4).Infof("The watch manager started no new watches")
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs[0]
+}
+
+// stopWatches stops the watchers of the GVKs which are not present in gvkMap,
+// and returns the GVKs of gvkMap which are neither watched nor known to be
+// forbidden, along with the number of resets of the forbidden GVKs. This
+// function is threadsafe.
+func (m *Manager) stopWatches(ctx context.Context, gvkMap map[schema.GroupVersionKind]struct{}) ([]schema.GroupVersionKind, uint64) {
 	m.mux.Lock()
 	defer m.mux.Unlock()
 
 	m.needsUpdate = false
 
-	var startedWatches, stoppedWatches uint64
+	var stoppedWatches uint64
 	// Stop obsolete watchers.
 	for gvk := range m.watcherMap {
 		if _, keepWatching := gvkMap[gvk]; !keepWatching {
@@ -128,32 +223,34 @@ func (m *Manager) UpdateWatches(ctx context.Context, gvkMap map[schema.GroupVers
 			stoppedWatches++
 		}
 	}
-
-	// Start new watchers
-	var errs []error
-	for gvk := range gvkMap {
-		if _, isWatched := m.watcherMap[gvk]; !isWatched {
-			// We don't have a watcher for this type, so add a watcher for it.
-			if err := m.startWatcher(ctx, gvk); err != nil {
-				errs = append(errs, err)
-			}
-			startedWatches++
+	for gvk := range m.forbidden {
+		if _, keepWatching := gvkMap[gvk]; !keepWatching {
+			m.setForbidden(ctx, gvk, nil)
 		}
 	}
 
-	if startedWatches > 0 || stoppedWatches > 0 {
-		klog.Infof("The watch manager made new progress: started %d new watches, and stopped %d watches", startedWatches, stoppedWatches)
-	} else {
-		klog.V(4).Infof("The watch manager made no new progress")
+	if stoppedWatches > 0 {
+		klog.Infof("The watch manager stopped %d watches", stoppedWatches)
 	}
-	if len(errs) == 0 {
-		return nil
+
+	var newGVKs []schema.GroupVersionKind
+	for gvk := range gvkMap {
+		if _, isWatched := m.watcherMap[gvk]; isWatched {
+			continue
+		}
+		if _, isForbidden := m.forbidden[gvk]; isForbidden {
+			continue
+		}
+		newGVKs = append(newGVKs, gvk)
 	}
-	return errs[0]
+	return newGVKs, m.resets
 }
 
 // watchedGVKs returns a list of all GroupVersionKinds currently being watched.
+// This function is threadsafe.
 func (m *Manager) watchedGVKs() []schema.GroupVersionKind {
+	m.mux.Lock()
+	defer m.mux.Unlock()
 	var gvks []schema.GroupVersionKind
 	for gvk := range m.watcherMap {
 		gvks = append(gvks, gvk)
@@ -170,11 +267,14 @@ func (m *Manager) startWatcher(ctx context.Context, gvk schema.GroupVersionKind)
 		return nil
 	}
 	cfg := watcherConfig{
-		gvk:       gvk,
-		mapper:    m.mapper,
-		config:    m.cfg,
-		channel:   m.channel,
-		resources: m.resources,
+		gvk:            gvk,
+		mapper:         m.mapper,
+		config:         m.cfg,
+		events:         m.events,
+		resources:      m.resources,
+		resyncInterval: m.resyncInterval,
+		metadataOnly:   m.metadataOnlyKinds[gvk.GroupKind()],
+		annotationKeys: m.annotationKeys,
 	}
 	w, err := m.createWatcherFunc(ctx, cfg)
 	if err != nil {
@@ -212,13 +312,107 @@ func (m *Manager) stopWatcher(gvk schema.GroupVersionKind) {
 	delete(m.watcherMap, gvk)
 }
 
-// Len returns the number of types that are currently watched.
+// Len returns the number of types that are currently watched. This function
+// is threadsafe.
 func (m *Manager) Len() int {
+	m.mux.Lock()
+	defer m.mux.Unlock()
 	return len(m.watcherMap)
 }
 
-// IsWatched returns whether the given GVK is being watched
+// IsWatched returns whether the given GVK is being watched. This function is
+// threadsafe.
 func (m *Manager) IsWatched(gvk schema.GroupVersionKind) bool {
+	m.mux.Lock()
+	defer m.mux.Unlock()
 	_, found := m.watcherMap[gvk]
 	return found
 }
+
+// access is the result of an access check of a GVK.
+type access struct {
+	// verbs are the verbs needed to watch the GVK which the controller is
+	// not allowed.
+	verbs []string
+	// err is the error of the check, if any.
+	err error
+}
+
+// checkAccesses checks whether the controller is allowed to watch each of the
+// given GVKs. It returns nil if the access is not checked. This function does
+// not use m.mux, so that the checks do not block the other calls.
+func (m *Manager) checkAccesses(ctx context.Context, gvks []schema.GroupVersionKind) map[schema.GroupVersionKind]access {
+	if m.checkAccess == nil || len(gvks) == 0 {
+		return nil
+	}
+	accesses := make(map[schema.GroupVersionKind]access, len(gvks))
+	for _, gvk := range gvks {
+		verbs, err := m.checkAccess(ctx, gvk)
+		accesses[gvk] = access{verbs: verbs, err: err}
+	}
+	return accesses
+}
+
+// recordAccess records the result of the access check of a GVK, and returns
+// whether the GVK can be watched. A GVK which is forbidden is not checked
+// again until ResetForbidden is called, so that the manager does not retry
+// watching it until the RBAC rules change. The result is not recorded if
+// ResetForbidden was called since the check, i.e. if m.resets differs from
+// resets. This function is NOT threadsafe; caller must have a lock on m.mux.
+func (m *Manager) recordAccess(ctx context.Context, gvk schema.GroupVersionKind, result access, resets uint64) bool {
+	if result.err != nil {
+		// The watcher reports the errors if the access is really denied.
+		klog.Warningf("Unable to check the access to %s, watching it anyway: %v", gvk, result.err)
+		return true
+	}
+	if len(result.verbs) == 0 {
+		m.setForbidden(ctx, gvk, nil)
+		return true
+	}
+	if m.resets != resets {
+		// The RBAC rules changed since the check, so the access is checked
+		// again by the next update.
+		m.needsUpdate = true
+		return false
+	}
+	m.setForbidden(ctx, gvk, result.verbs)
+	klog.Warningf("Not watching %s: the controller is not allowed to %v it", gvk, result.verbs)
+	return false
+}
+
+// setForbidden records the verbs needed to watch a GVK which the controller
+// is not allowed, or that it is allowed if verbs is empty. This function is
+// NOT threadsafe; caller must have a lock on m.mux.
+func (m *Manager) setForbidden(ctx context.Context, gvk schema.GroupVersionKind, verbs []string) {
+	if len(verbs) == 0 {
+		delete(m.forbidden, gvk)
+	} else {
+		m.forbidden[gvk] = verbs
+	}
+	if m.resources != nil {
+		m.resources.SetForbiddenVerbs(gvk.GroupKind(), verbs)
+	}
+	metrics.RecordForbiddenWatchCount(ctx, int64(len(m.forbidden)))
+}
+
+// ResetForbidden forgets the GVKs which the controller was not allowed to
+// watch, so that their access is checked again by the next call to
+// UpdateWatches, and returns their GroupKinds. It is called when the RBAC
+// rules change. This function is threadsafe.
+func (m *Manager) ResetForbidden() []schema.GroupKind {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.resets++
+	if len(m.forbidden) == 0 {
+		return nil
+	}
+	var gks []schema.GroupKind
+	for gvk := range m.forbidden {
+		gks = append(gks, gvk.GroupKind())
+	}
+	// The forbidden verbs are kept in the resource map until the access is
+	// checked again.
+	m.forbidden = make(map[schema.GroupVersionKind][]string)
+	m.needsUpdate = true
+	return gks
+}
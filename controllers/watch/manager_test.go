@@ -24,12 +24,14 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/watch"
-	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	"kpt.dev/resourcegroup/controllers/eventbus"
+	"kpt.dev/resourcegroup/controllers/resourcemap"
 )
 
 func fakeRunnable(ctx context.Context) Runnable {
 	cfg := watcherConfig{
-		startWatch: func(options metav1.ListOptions) (watch.Interface, error) {
+		startWatch: func(_ context.Context, _ metav1.ListOptions) (watch.Interface, error) {
 			return watch.NewFake(), nil
 		},
 	}
@@ -137,8 +139,7 @@ func TestManager_Update(t *testing.T) {
 			options := &Options{
 				watcherFunc: testRunnables(ctx, tc.failedWatchers),
 			}
-			ch := make(chan event.GenericEvent)
-			m, err := NewManager(nil, nil, ch, options)
+			m, err := NewManager(nil, nil, eventbus.New(0), options)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -183,3 +184,97 @@ func roleBindingKind() schema.GroupVersionKind {
 		Kind:    "RoleBinding",
 	}
 }
+
+func TestManager_Forbidden(t *testing.T) {
+	ctx := context.Background()
+	forbidden := map[schema.GroupVersionKind][]string{roleKind(): {"list", "watch"}}
+	var checks int
+	options := &Options{
+		watcherFunc: testRunnables(ctx, nil),
+		accessFunc: func(_ context.Context, gvk schema.GroupVersionKind) ([]string, error) {
+			checks++
+			return forbidden[gvk], nil
+		},
+	}
+	resMap := resourcemap.NewResourceMap()
+	m, err := NewManager(nil, resMap, eventbus.New(0), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gvks := map[schema.GroupVersionKind]struct{}{
+		namespaceKind(): {},
+		roleKind():      {},
+	}
+
+	if err := m.UpdateWatches(ctx, gvks); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]schema.GroupVersionKind{namespaceKind()}, m.watchedGVKs()); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff([]string{"list", "watch"}, resMap.GetForbiddenVerbs(roleKind().GroupKind())); diff != "" {
+		t.Error(diff)
+	}
+	if checks != 2 {
+		t.Errorf("got %d access checks, want 2", checks)
+	}
+
+	// The access to a forbidden GVK is not checked again until the RBAC rules change.
+	if err := m.UpdateWatches(ctx, gvks); err != nil {
+		t.Fatal(err)
+	}
+	if checks != 2 {
+		t.Errorf("got %d access checks, want 2", checks)
+	}
+
+	forbidden = nil
+	if diff := cmp.Diff([]schema.GroupKind{roleKind().GroupKind()}, m.ResetForbidden()); diff != "" {
+		t.Error(diff)
+	}
+	if !m.NeedsUpdate() {
+		t.Error("got NeedsUpdate() = false after ResetForbidden(), want true")
+	}
+	if err := m.UpdateWatches(ctx, gvks); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]schema.GroupVersionKind{namespaceKind(), roleKind()}, m.watchedGVKs(), cmpopts.SortSlices(sortGVKs)); diff != "" {
+		t.Error(diff)
+	}
+	if verbs := resMap.GetForbiddenVerbs(roleKind().GroupKind()); len(verbs) != 0 {
+		t.Errorf("got forbidden verbs %v after the access is allowed, want none", verbs)
+	}
+}
+
+func TestManager_ForbiddenResetDuringCheck(t *testing.T) {
+	ctx := context.Background()
+	var m *Manager
+	options := &Options{
+		watcherFunc: testRunnables(ctx, nil),
+		accessFunc: func(_ context.Context, _ schema.GroupVersionKind) ([]string, error) {
+			// The access is checked without the lock, so the RBAC rules can
+			// change during the check.
+			m.ResetForbidden()
+			return []string{"watch"}, nil
+		},
+	}
+	var err error
+	m, err = NewManager(nil, resourcemap.NewResourceMap(), eventbus.New(0), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.UpdateWatches(ctx, map[schema.GroupVersionKind]struct{}{roleKind(): {}}); err != nil {
+		t.Fatal(err)
+	}
+	if m.IsWatched(roleKind()) {
+		t.Error("got IsWatched() = true for a forbidden GVK, want false")
+	}
+	// The access checked before the reset is not recorded, so that it is
+	// checked again by the next update.
+	if len(m.forbidden) != 0 {
+		t.Errorf("got forbidden GVKs %v after a reset during the check, want none", m.forbidden)
+	}
+	if !m.NeedsUpdate() {
+		t.Error("got NeedsUpdate() = false after a reset during the check, want true")
+	}
+}
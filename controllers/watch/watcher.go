@@ -17,19 +17,26 @@ package watch
 import (
 	"context"
 	"fmt"
+	"time"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/rest"
-	"sigs.k8s.io/controller-runtime/pkg/event"
 
+	"kpt.dev/resourcegroup/controllers/eventbus"
 	"kpt.dev/resourcegroup/controllers/resourcemap"
+	"kpt.dev/resourcegroup/controllers/status"
 )
 
-type startWatchFunc func(metav1.ListOptions) (watch.Interface, error)
+type startWatchFunc func(context.Context, metav1.ListOptions) (watch.Interface, error)
+
+type startListFunc func(context.Context, metav1.ListOptions) (*unstructured.UnstructuredList, error)
 
 // watcherConfig contains the options needed
 // to create a watcher.
@@ -39,7 +46,15 @@ type watcherConfig struct {
 	config     *rest.Config
 	resources  *resourcemap.ResourceMap
 	startWatch startWatchFunc
-	channel    chan event.GenericEvent
+	startList  startListFunc
+	// resyncInterval is the interval between two resyncs of the cached
+	// statuses. 0 disables the resync.
+	resyncInterval time.Duration
+	// metadataOnly indicates that only the metadata of the objects is watched.
+	metadataOnly bool
+	// annotationKeys names the annotations read when computing the statuses.
+	annotationKeys status.AnnotationKeys
+	events         *eventbus.Bus
 }
 
 // createWatcherFunc is the type of functions to create watchers
@@ -53,15 +68,75 @@ func createWatcher(ctx context.Context, cfg watcherConfig) (Runnable, error) {
 			return nil, fmt.Errorf("watcher failed to get REST mapping for %s: %v", cfg.gvk.String(), err)
 		}
 
+		if cfg.metadataOnly {
+			// Only the metadata of the objects is watched, so that their
+			// payload is never sent to the controller.
+			metadataClient, err := metadata.NewForConfig(cfg.config)
+			if err != nil {
+				return nil, fmt.Errorf("watcher failed to get metadata client for %s: %v", cfg.gvk.String(), err)
+			}
+			gvk := cfg.gvk
+			cfg.startWatch = func(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+				base, err := metadataClient.Resource(mapping.Resource).Watch(ctx, options)
+				if err != nil {
+					return nil, err
+				}
+				return watch.Filter(base, func(event watch.Event) (watch.Event, bool) {
+					return metadataEvent(event, gvk), true
+				}), nil
+			}
+			cfg.startList = func(ctx context.Context, options metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+				list, err := metadataClient.Resource(mapping.Resource).List(ctx, options)
+				if err != nil {
+					return nil, err
+				}
+				return metadataList(list, gvk)
+			}
+			return NewFiltered(ctx, cfg), nil
+		}
+
 		dynamicClient, err := dynamic.NewForConfig(cfg.config)
 		if err != nil {
 			return nil, fmt.Errorf("watcher failed to get dynamic client for %s: %v", cfg.gvk.String(), err)
 		}
 
-		cfg.startWatch = func(options metav1.ListOptions) (watch.Interface, error) {
+		cfg.startWatch = func(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
 			return dynamicClient.Resource(mapping.Resource).Watch(ctx, options)
 		}
+		cfg.startList = func(ctx context.Context, options metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+			return dynamicClient.Resource(mapping.Resource).List(ctx, options)
+		}
 	}
 
 	return NewFiltered(ctx, cfg), nil
 }
+
+// metadataEvent converts the metadata of the object of a watch event into an
+// unstructured object of the given kind. The error events are not converted.
+func metadataEvent(event watch.Event, gvk schema.GroupVersionKind) watch.Event {
+	obj, ok := event.Object.(*metav1.PartialObjectMetadata)
+	if !ok {
+		return event
+	}
+	u, err := status.MetadataToUnstructured(obj, gvk)
+	if err != nil {
+		return watch.Event{Type: watch.Error, Object: &apierrors.NewInternalError(err).ErrStatus}
+	}
+	return watch.Event{Type: event.Type, Object: u}
+}
+
+// metadataList converts the metadata of the listed objects into unstructured
+// objects of the given kind.
+func metadataList(list *metav1.PartialObjectMetadataList, gvk schema.GroupVersionKind) (*unstructured.UnstructuredList, error) {
+	result := &unstructured.UnstructuredList{Items: make([]unstructured.Unstructured, len(list.Items))}
+	result.SetResourceVersion(list.GetResourceVersion())
+	result.SetContinue(list.GetContinue())
+	for i := range list.Items {
+		u, err := status.MetadataToUnstructured(&list.Items[i], gvk)
+		if err != nil {
+			return nil, err
+		}
+		result.Items[i] = *u
+	}
+	return result, nil
+}
@@ -769,8 +769,8 @@ func applyResources(kubeClient client.Client, resources []v1alpha1.ObjMetadata,
 			Kind:    r.Kind,
 		})
 		u.SetAnnotations(map[string]string{
-			"config.k8s.io/owning-inventory": id,
-			status.SourceHashAnnotationKey:   "1234567890",
+			"config.k8s.io/owning-inventory":      id,
+			status.DefaultSourceHashAnnotationKey: "1234567890",
 		})
 
 		err := kubeClient.Get(context.TODO(), client.ObjectKey{Name: r.Name, Namespace: r.Namespace}, u.DeepCopy())
@@ -870,7 +870,53 @@ func getResourceVersion(kubeClient client.Client, name string) (string, error) {
 	return rg.ResourceVersion, nil
 }
 
+// withDefaultConditions adds the Blocked condition expected without
+// dependencies, the Drifted condition expected without a revision, and the
+// Ready condition expected for the default readiness policy, to a status
+// which does not set them.
+func withDefaultConditions(status v1alpha1.ResourceGroupStatus) v1alpha1.ResourceGroupStatus {
+	if len(status.Conditions) == 0 {
+		return status
+	}
+	for _, cond := range status.Conditions {
+		if cond.Type == v1alpha1.Blocked || cond.Type == v1alpha1.Drifted || cond.Type == v1alpha1.Ready {
+			return status
+		}
+	}
+	blocked := v1alpha1.Condition{
+		Type:   v1alpha1.Blocked,
+		Status: v1alpha1.FalseConditionStatus,
+		Reason: resourcegroup.DependenciesReady,
+	}
+	drifted := v1alpha1.Condition{
+		Type:   v1alpha1.Drifted,
+		Status: v1alpha1.UnknownConditionStatus,
+		Reason: resourcegroup.RevisionUnknown,
+	}
+	ready := v1alpha1.Condition{
+		Type:   v1alpha1.Ready,
+		Status: v1alpha1.TrueConditionStatus,
+		Reason: resourcegroup.ResourcesReady,
+	}
+	for _, res := range status.ResourceStatuses {
+		switch res.Status {
+		case v1alpha1.Current:
+		case v1alpha1.InProgress, v1alpha1.Terminating:
+			if ready.Status == v1alpha1.TrueConditionStatus {
+				ready.Status = v1alpha1.FalseConditionStatus
+				ready.Reason = resourcegroup.ResourcesInProgress
+			}
+		default:
+			ready.Status = v1alpha1.FalseConditionStatus
+			ready.Reason = resourcegroup.ResourcesNotReady
+		}
+	}
+	status.Conditions = append(append([]v1alpha1.Condition{}, status.Conditions...), blocked, drifted, ready)
+	return status
+}
+
 func waitForResourceGroupStatus(kubeClient client.Client, status v1alpha1.ResourceGroupStatus, name string) {
+	status = withDefaultConditions(status)
 	EventuallyWithOffset(1, func() v1alpha1.ResourceGroupStatus {
 		obj := &v1alpha1.ResourceGroup{}
 		obj.SetNamespace(testNamespace)
@@ -944,7 +990,7 @@ func patchStatusDisabled(kubeClient client.Client, name string) error {
 		if annotations == nil {
 			annotations = make(map[string]string, 1)
 		}
-		annotations[root.DisableStatusKey] = root.DisableStatusValue
+		annotations[root.DefaultDisableStatusKey] = root.DisableStatusValue
 		obj.SetAnnotations(annotations)
 	})
 }
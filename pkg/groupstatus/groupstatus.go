@@ -0,0 +1,90 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package groupstatus reads the status written by the ResourceGroup
+// controller. It is shared by the controller and the tools reading the
+// status of ResourceGroups, and only depends on the ResourceGroup API.
+package groupstatus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+)
+
+// StatusShardDataKey is the key of the resource statuses in the data of a status shard.
+const StatusShardDataKey = "resourceStatuses"
+
+// ExceedTimeoutReason is the reason of the conditions of a ResourceGroup whose
+// status could not be computed before the reconcile timeout. The status is
+// computed again by the next reconciliation.
+const ExceedTimeoutReason = "ExceedTimeout"
+
+// GetResourceStatuses returns the resource statuses of the ResourceGroup.
+// If the ResourceGroup is sharded, the resource statuses are read from its
+// status shards, and the strategy, actuation and reconcile fields set by the
+// applier in the ResourceGroup object take precedence over the ones in the shards.
+//
+// Tools which read the resource statuses of ResourceGroups should use it
+// instead of reading .status.resourceStatuses directly.
+func GetResourceStatuses(ctx context.Context, c client.Reader, resgroup *v1alpha1.ResourceGroup) ([]v1alpha1.ResourceStatus, error) {
+	if len(resgroup.Status.StatusShards) == 0 {
+		return resgroup.Status.ResourceStatuses, nil
+	}
+
+	var statuses []v1alpha1.ResourceStatus
+	for _, shard := range resgroup.Status.StatusShards {
+		cm := &corev1.ConfigMap{}
+		if err := c.Get(ctx, types.NamespacedName{Namespace: resgroup.Namespace, Name: shard.Name}, cm); err != nil {
+			return nil, fmt.Errorf("failed to get the status shard %s: %w", shard.Name, err)
+		}
+		var shardStatuses []v1alpha1.ResourceStatus
+		if err := json.Unmarshal([]byte(cm.Data[StatusShardDataKey]), &shardStatuses); err != nil {
+			return nil, fmt.Errorf("failed to decode the status shard %s: %w", shard.Name, err)
+		}
+		if len(shardStatuses) != shard.Count {
+			return nil, fmt.Errorf("the status shard %s has %d resource statuses, expected %d", shard.Name, len(shardStatuses), shard.Count)
+		}
+		statuses = append(statuses, shardStatuses...)
+	}
+
+	applied := make(map[v1alpha1.ObjMetadata]v1alpha1.ResourceStatus, len(resgroup.Status.ResourceStatuses))
+	for _, res := range resgroup.Status.ResourceStatuses {
+		applied[res.ObjMetadata] = res
+	}
+	for i := range statuses {
+		if res, found := applied[statuses[i].ObjMetadata]; found {
+			statuses[i].Strategy = res.Strategy
+			statuses[i].Actuation = res.Actuation
+			statuses[i].Reconcile = res.Reconcile
+		}
+	}
+	return statuses, nil
+}
+
+// GetCondition returns the condition of the given type, or false if there is none.
+func GetCondition(conditions []v1alpha1.Condition, condType v1alpha1.ConditionType) (v1alpha1.Condition, bool) {
+	for _, cond := range conditions {
+		if cond.Type == condType {
+			return cond, true
+		}
+	}
+	return v1alpha1.Condition{}, false
+}
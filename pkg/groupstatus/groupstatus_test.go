@@ -0,0 +1,112 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package groupstatus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+)
+
+func testStatuses(count int) []v1alpha1.ResourceStatus {
+	statuses := make([]v1alpha1.ResourceStatus, count)
+	for i := range statuses {
+		statuses[i] = v1alpha1.ResourceStatus{
+			ObjMetadata: v1alpha1.ObjMetadata{
+				Name:      fmt.Sprintf("cm-%d", i),
+				Namespace: "ns1",
+				GroupKind: v1alpha1.GroupKind{Kind: "ConfigMap"},
+			},
+			Status: v1alpha1.Current,
+		}
+	}
+	return statuses
+}
+
+func TestGetResourceStatuses(t *testing.T) {
+	statuses := testStatuses(3)
+	resgroup := &v1alpha1.ResourceGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "group", Namespace: "ns1"},
+		Status: v1alpha1.ResourceGroupStatus{
+			ResourceStatuses: statuses,
+		},
+	}
+
+	// The resource statuses of a ResourceGroup which is not sharded are in the object.
+	got, err := GetResourceStatuses(context.TODO(), fake.NewClientBuilder().Build(), resgroup)
+	assert.NoError(t, err)
+	assert.Equal(t, statuses, got)
+
+	shard := func(name string, statuses []v1alpha1.ResourceStatus) *corev1.ConfigMap {
+		data, err := json.Marshal(statuses)
+		assert.NoError(t, err)
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns1"},
+			Data:       map[string]string{StatusShardDataKey: string(data)},
+		}
+	}
+	c := fake.NewClientBuilder().WithObjects(
+		shard("group-status-0", statuses[:2]),
+		shard("group-status-1", statuses[2:]),
+	).Build()
+
+	// The applier updated the actuation of a resource in the ResourceGroup object.
+	resgroup.Status.ResourceStatuses = []v1alpha1.ResourceStatus{
+		{
+			ObjMetadata: statuses[2].ObjMetadata,
+			Actuation:   v1alpha1.ActuationSucceeded,
+		},
+	}
+	resgroup.Status.StatusShards = []v1alpha1.StatusShard{
+		{Name: "group-status-0", Count: 2},
+		{Name: "group-status-1", Count: 1},
+	}
+	got, err = GetResourceStatuses(context.TODO(), c, resgroup)
+	assert.NoError(t, err)
+	expected := testStatuses(3)
+	expected[2].Actuation = v1alpha1.ActuationSucceeded
+	assert.Equal(t, expected, got)
+
+	// A shard which does not match the reference is an error.
+	resgroup.Status.StatusShards[1].Count = 2
+	_, err = GetResourceStatuses(context.TODO(), c, resgroup)
+	assert.Error(t, err)
+
+	// A missing shard is an error.
+	resgroup.Status.StatusShards = append(resgroup.Status.StatusShards, v1alpha1.StatusShard{Name: "group-status-2", Count: 1})
+	_, err = GetResourceStatuses(context.TODO(), c, resgroup)
+	assert.Error(t, err)
+}
+
+func TestGetCondition(t *testing.T) {
+	conditions := []v1alpha1.Condition{
+		{Type: v1alpha1.Reconciling, Status: v1alpha1.FalseConditionStatus},
+		{Type: v1alpha1.Stalled, Status: v1alpha1.TrueConditionStatus},
+	}
+	cond, found := GetCondition(conditions, v1alpha1.Stalled)
+	assert.True(t, found)
+	assert.Equal(t, conditions[1], cond)
+
+	_, found = GetCondition(conditions, v1alpha1.Ready)
+	assert.False(t, found)
+}
@@ -0,0 +1,185 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package wait waits for ResourceGroups to reach a condition, by watching
+// the ResourceGroup objects and evaluating the conditions set by the
+// ResourceGroup controller.
+package wait
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/pkg/groupstatus"
+)
+
+// ErrStalled is returned when the ResourceGroup is Stalled, which means that
+// it cannot become ready without a change.
+var ErrStalled = errors.New("the ResourceGroup is stalled")
+
+// ErrDeleted is returned when the ResourceGroup is deleted while waiting for it.
+var ErrDeleted = errors.New("the ResourceGroup was deleted")
+
+// ProgressFunc is called with the ResourceGroup and the statuses of its
+// resources every time the ResourceGroup is observed while waiting.
+type ProgressFunc func(rg *v1alpha1.ResourceGroup, statuses []v1alpha1.ResourceStatus)
+
+// Options configures the waiting.
+type Options struct {
+	// Timeout is the maximum duration of the waiting. 0 means no timeout.
+	Timeout time.Duration
+
+	// OnProgress is called every time the ResourceGroup is observed, if set.
+	OnProgress ProgressFunc
+}
+
+// ForReady waits until the ResourceGroup identified by key is ready, and
+// returns it.
+//
+// It returns an error wrapping ErrStalled if the ResourceGroup becomes Stalled,
+// ErrDeleted if it is deleted, or context.DeadlineExceeded if the timeout
+// expires first.
+func ForReady(ctx context.Context, c client.WithWatch, key types.NamespacedName, opts Options) (*v1alpha1.ResourceGroup, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	for {
+		rg := &v1alpha1.ResourceGroup{}
+		if err := c.Get(ctx, key, rg); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, fmt.Errorf("%w: %s", ErrDeleted, key)
+			}
+			return nil, waitError(ctx, key, err)
+		}
+		if done, err := observe(ctx, c, rg, opts.OnProgress); done || err != nil {
+			return rg, err
+		}
+
+		w, err := c.Watch(ctx, &v1alpha1.ResourceGroupList{}, &client.ListOptions{
+			Namespace:     key.Namespace,
+			FieldSelector: fields.OneTermEqualSelector("metadata.name", key.Name),
+			Raw:           &metav1.ListOptions{ResourceVersion: rg.ResourceVersion},
+		})
+		if err != nil {
+			return nil, waitError(ctx, key, err)
+		}
+		rg, done, err := watchUntilReady(ctx, c, w, key, opts.OnProgress)
+		w.Stop()
+		if done || err != nil {
+			return rg, err
+		}
+		// The watch was closed by the server, so get the latest object and
+		// watch again.
+	}
+}
+
+// watchUntilReady consumes the events of w until the ResourceGroup is ready,
+// an error happens, or w is closed, in which case done is false.
+func watchUntilReady(ctx context.Context, c client.Reader, w watch.Interface, key types.NamespacedName, onProgress ProgressFunc) (*v1alpha1.ResourceGroup, bool, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, false, waitError(ctx, key, ctx.Err())
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return nil, false, nil
+			}
+			switch event.Type {
+			case watch.Deleted:
+				return nil, false, fmt.Errorf("%w: %s", ErrDeleted, key)
+			case watch.Added, watch.Modified:
+				rg, ok := event.Object.(*v1alpha1.ResourceGroup)
+				if !ok || rg.Name != key.Name {
+					continue
+				}
+				if done, err := observe(ctx, c, rg, onProgress); done || err != nil {
+					return rg, done, err
+				}
+			case watch.Error:
+				// The watch expired or failed, so start over.
+				return nil, false, nil
+			}
+		}
+	}
+}
+
+// observe reports the progress of rg and evaluates its readiness.
+func observe(ctx context.Context, c client.Reader, rg *v1alpha1.ResourceGroup, onProgress ProgressFunc) (bool, error) {
+	statuses, err := groupstatus.GetResourceStatuses(ctx, c, rg)
+	if err != nil {
+		return false, err
+	}
+	if onProgress != nil {
+		onProgress(rg, statuses)
+	}
+	return IsReady(rg, statuses)
+}
+
+// IsReady checks whether the ResourceGroup is ready given the statuses of its
+// resources. The status of the ResourceGroup must be computed for its current
+// generation and not be in the middle of a reconciliation.
+//
+// The ResourceGroup is ready when its Ready condition is True. If the
+// ResourceGroup has no Ready condition, which is the case with older versions
+// of the controller, it is ready when all its resources are Current.
+// It returns an error wrapping ErrStalled if the ResourceGroup is Stalled,
+// unless it is Stalled because its status could not be computed in time, in
+// which case the controller retries and the ResourceGroup is not ready yet.
+func IsReady(rg *v1alpha1.ResourceGroup, statuses []v1alpha1.ResourceStatus) (bool, error) {
+	if rg.Status.ObservedGeneration != rg.Generation {
+		return false, nil
+	}
+	if cond, found := groupstatus.GetCondition(rg.Status.Conditions, v1alpha1.Reconciling); found && cond.Status == v1alpha1.TrueConditionStatus {
+		return false, nil
+	}
+	if cond, found := groupstatus.GetCondition(rg.Status.Conditions, v1alpha1.Stalled); found && cond.Status == v1alpha1.TrueConditionStatus {
+		if cond.Reason == groupstatus.ExceedTimeoutReason {
+			return false, nil
+		}
+		return false, fmt.Errorf("%w: %s: %s", ErrStalled, cond.Reason, cond.Message)
+	}
+	if cond, found := groupstatus.GetCondition(rg.Status.Conditions, v1alpha1.Ready); found {
+		return cond.Status == v1alpha1.TrueConditionStatus, nil
+	}
+	if len(statuses) != len(rg.Spec.Resources) {
+		return false, nil
+	}
+	for _, status := range statuses {
+		if status.Status != v1alpha1.Current {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// waitError wraps err with the context error if the context is done.
+func waitError(ctx context.Context, key types.NamespacedName, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return fmt.Errorf("timed out waiting for ResourceGroup %s: %w", key, ctxErr)
+	}
+	return fmt.Errorf("failed to wait for ResourceGroup %s: %w", key, err)
+}
@@ -0,0 +1,164 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wait
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kpt.dev/resourcegroup/apis/kpt.dev/v1alpha1"
+	"kpt.dev/resourcegroup/pkg/groupstatus"
+)
+
+func testResourceGroup(conditions ...v1alpha1.Condition) *v1alpha1.ResourceGroup {
+	res := v1alpha1.ObjMetadata{Name: "cm", Namespace: "ns1", GroupKind: v1alpha1.GroupKind{Kind: "ConfigMap"}}
+	return &v1alpha1.ResourceGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "group", Namespace: "ns1", Generation: 1},
+		Spec: v1alpha1.ResourceGroupSpec{
+			Resources: []v1alpha1.ObjMetadata{res},
+		},
+		Status: v1alpha1.ResourceGroupStatus{
+			ObservedGeneration: 1,
+			ResourceStatuses: []v1alpha1.ResourceStatus{
+				{ObjMetadata: res, Status: v1alpha1.InProgress},
+			},
+			Conditions: conditions,
+		},
+	}
+}
+
+func condition(condType v1alpha1.ConditionType, status v1alpha1.ConditionStatus) v1alpha1.Condition {
+	return v1alpha1.Condition{Type: condType, Status: status}
+}
+
+func TestIsReady(t *testing.T) {
+	reconciled := condition(v1alpha1.Reconciling, v1alpha1.FalseConditionStatus)
+	notStalled := condition(v1alpha1.Stalled, v1alpha1.FalseConditionStatus)
+	tests := map[string]struct {
+		rg            *v1alpha1.ResourceGroup
+		current       bool
+		expectedReady bool
+		expectedErr   error
+	}{
+		"should be ready with a True Ready condition": {
+			rg:            testResourceGroup(reconciled, notStalled, condition(v1alpha1.Ready, v1alpha1.TrueConditionStatus)),
+			expectedReady: true,
+		},
+		"should not be ready with a False Ready condition": {
+			rg:            testResourceGroup(reconciled, notStalled, condition(v1alpha1.Ready, v1alpha1.FalseConditionStatus)),
+			current:       true,
+			expectedReady: false,
+		},
+		"should not be ready while reconciling": {
+			rg:            testResourceGroup(condition(v1alpha1.Reconciling, v1alpha1.TrueConditionStatus), condition(v1alpha1.Ready, v1alpha1.TrueConditionStatus)),
+			expectedReady: false,
+		},
+		"should fail when stalled": {
+			rg:          testResourceGroup(reconciled, condition(v1alpha1.Stalled, v1alpha1.TrueConditionStatus)),
+			expectedErr: ErrStalled,
+		},
+		"should not be ready when the status computation timed out": {
+			rg: testResourceGroup(
+				v1alpha1.Condition{Type: v1alpha1.Reconciling, Status: v1alpha1.FalseConditionStatus, Reason: groupstatus.ExceedTimeoutReason},
+				v1alpha1.Condition{Type: v1alpha1.Stalled, Status: v1alpha1.TrueConditionStatus, Reason: groupstatus.ExceedTimeoutReason},
+				v1alpha1.Condition{Type: v1alpha1.Ready, Status: v1alpha1.UnknownConditionStatus, Reason: groupstatus.ExceedTimeoutReason},
+			),
+			current:       true,
+			expectedReady: false,
+		},
+		"should be ready without a Ready condition when all the resources are current": {
+			rg:            testResourceGroup(reconciled, notStalled),
+			current:       true,
+			expectedReady: true,
+		},
+		"should not be ready without a Ready condition when a resource is not current": {
+			rg:            testResourceGroup(reconciled, notStalled),
+			expectedReady: false,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(fmt.Sprintf("IsReady %s", name), func(t *testing.T) {
+			if tc.current {
+				tc.rg.Status.ResourceStatuses[0].Status = v1alpha1.Current
+			}
+			ready, err := IsReady(tc.rg, tc.rg.Status.ResourceStatuses)
+			assert.Equal(t, tc.expectedReady, ready)
+			assert.True(t, errors.Is(err, tc.expectedErr), "unexpected error %v", err)
+		})
+	}
+
+	// A status computed for an old generation is not ready.
+	rg := testResourceGroup(condition(v1alpha1.Ready, v1alpha1.TrueConditionStatus))
+	rg.Generation = 2
+	ready, err := IsReady(rg, rg.Status.ResourceStatuses)
+	assert.NoError(t, err)
+	assert.False(t, ready)
+}
+
+func newFakeClient(t *testing.T, objs ...client.Object) client.WithWatch {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, v1alpha1.AddToScheme(scheme))
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+}
+
+func TestForReady(t *testing.T) {
+	rg := testResourceGroup(condition(v1alpha1.Ready, v1alpha1.FalseConditionStatus))
+	c := newFakeClient(t, rg)
+	key := types.NamespacedName{Namespace: "ns1", Name: "group"}
+
+	observed := make(chan struct{}, 10)
+	go func() {
+		<-observed
+		updated := &v1alpha1.ResourceGroup{}
+		assert.NoError(t, c.Get(context.TODO(), key, updated))
+		updated.Status.ResourceStatuses[0].Status = v1alpha1.Current
+		updated.Status.Conditions = []v1alpha1.Condition{condition(v1alpha1.Ready, v1alpha1.TrueConditionStatus)}
+		assert.NoError(t, c.Update(context.TODO(), updated))
+	}()
+
+	var progress []v1alpha1.Status
+	got, err := ForReady(context.TODO(), c, key, Options{
+		Timeout: 10 * time.Second,
+		OnProgress: func(_ *v1alpha1.ResourceGroup, statuses []v1alpha1.ResourceStatus) {
+			progress = append(progress, statuses[0].Status)
+			observed <- struct{}{}
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, v1alpha1.Current, got.Status.ResourceStatuses[0].Status)
+	assert.Equal(t, []v1alpha1.Status{v1alpha1.InProgress, v1alpha1.Current}, progress)
+}
+
+func TestForReadyTimeout(t *testing.T) {
+	c := newFakeClient(t, testResourceGroup(condition(v1alpha1.Ready, v1alpha1.FalseConditionStatus)))
+	_, err := ForReady(context.TODO(), c, types.NamespacedName{Namespace: "ns1", Name: "group"}, Options{Timeout: 100 * time.Millisecond})
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "unexpected error %v", err)
+}
+
+func TestForReadyNotFound(t *testing.T) {
+	c := newFakeClient(t)
+	_, err := ForReady(context.TODO(), c, types.NamespacedName{Namespace: "ns1", Name: "group"}, Options{Timeout: time.Second})
+	assert.True(t, errors.Is(err, ErrDeleted), "unexpected error %v", err)
+}